@@ -1,19 +1,43 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/kayumovtd/url-shortener/internal/config"
 	"github.com/kayumovtd/url-shortener/internal/handler"
 	"github.com/kayumovtd/url-shortener/internal/logger"
+	"github.com/kayumovtd/url-shortener/internal/metrics"
+	"github.com/kayumovtd/url-shortener/internal/middleware"
 	"github.com/kayumovtd/url-shortener/internal/repository"
+	"github.com/kayumovtd/url-shortener/internal/server"
 	"github.com/kayumovtd/url-shortener/internal/service"
+	"github.com/kayumovtd/url-shortener/internal/tracing"
+	"github.com/kayumovtd/url-shortener/migrations"
 	"go.uber.org/zap"
 )
 
 func main() {
-	cfg := config.NewConfig()
+	cfg, err := config.NewConfig()
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatalf("invalid configuration:\n%v", err)
+	}
 
 	l, err := logger.New(cfg.LogLevel)
 	if err != nil {
@@ -21,33 +45,334 @@ func main() {
 	}
 
 	defer func() {
-		if err := l.Sync(); err != nil {
+		// fsync на stderr, подключённом к терминалу или пайпу, не поддерживается — это не ошибка
+		if err := l.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) && !errors.Is(err, syscall.ENOTTY) {
 			log.Printf("logger sync failed: %v", err)
 		}
 	}()
 
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName: "url-shortener",
+		Exporter:    cfg.TracingExporter,
+		Endpoint:    cfg.TracingEndpoint,
+		File:        cfg.TracingFile,
+	})
+	if err != nil {
+		l.Fatal("failed to set up tracing", zap.Error(err))
+	}
+
 	store, err := repository.NewStore(cfg, l)
 	if err != nil {
 		l.Fatal("failed to create store", zap.Error(err))
 	}
 
-	bd := service.NewBatchDeleter(store, l)
-	defer bd.Close()
+	m := metrics.New()
+	checks := []service.HealthCheck{service.StoreHealthCheck(store)}
+
+	if cache, ok := store.(*repository.CachedStore); ok {
+		if err := m.Register(metrics.NewCacheCollector(cache.Stats)); err != nil {
+			l.Fatal("failed to register url cache metrics", zap.Error(err))
+		}
+	}
+
+	switch s := repository.Unwrap(store).(type) {
+	case *repository.DBStore:
+		if err := m.Register(metrics.NewPoolCollector(s.PoolStat)); err != nil {
+			l.Fatal("failed to register db pool metrics", zap.Error(err))
+		}
+		schemaVersion, err := migrations.LatestVersion()
+		if err != nil {
+			l.Fatal("failed to read migrations", zap.Error(err))
+		}
+		checks = append(checks, service.HealthCheck{Name: "migrations", Check: func(ctx context.Context) error {
+			return s.CheckSchemaVersion(ctx, schemaVersion)
+		}})
+	case *repository.FileStore:
+		checks = append(checks, service.HealthCheck{Name: "file_storage", Check: func(context.Context) error {
+			return s.CheckWritable()
+		}})
+	}
+	backend := repository.BackendName(store)
+	store = metrics.InstrumentStore(tracing.TraceStore(store, backend), backend, m)
+
+	bd := service.NewBatchDeleter(store, l, service.WithDeleterMetrics(m))
+	err = m.RegisterGaugeFunc("batch_deleter", "queue_depth", "Deletion tasks waiting in the queue.",
+		func() float64 { return float64(bd.QueueLen()) })
+	if err != nil {
+		l.Fatal("failed to register batch deleter metrics", zap.Error(err))
+	}
 
 	svc := service.NewShortenerService(store, cfg.BaseURL, bd)
+	svc.SetMetrics(m)
+
+	health := service.NewHealthService(append(checks, service.BatchDeleterHealthCheck(bd))...)
+
+	authSecret := cfg.AuthSecret
+	if cfg.AuthSecret == "" && cfg.AuthKeys == "" && cfg.AuthKeysFile == "" {
+		// Подписывать пустым ключом нельзя, а падать на dev-запуске неудобно
+		l.Warn("no auth secret configured, using a random one: sessions will not survive a restart")
+		authSecret = rand.Text()
+	}
+	keyRing, err := loadKeyRing(cfg, authSecret)
+	if err != nil {
+		l.Fatal("failed to load auth keys", zap.Error(err))
+	}
+	auth := service.NewAuthServiceWithKeyRing(keyRing)
+	keys := service.NewAPIKeyService(store)
+	accounts := service.NewAccountService(store, auth)
+
+	limits, err := parseRateLimits(cfg)
+	if err != nil {
+		l.Fatal("invalid rate limits", zap.Error(err))
+	}
+	rl := middleware.NewRateLimiter(repository.NewInMemoryRateLimitStore(), limits, auth, l)
+
+	idempotencyTTL, err := time.ParseDuration(cfg.IdempotencyTTL)
+	if err != nil {
+		l.Fatal("invalid idempotency ttl", zap.Error(err))
+	}
+	idem := middleware.NewIdempotency(repository.NewInMemoryIdempotencyStore(), idempotencyTTL, auth, l)
+
+	var oidc *service.OIDCService
+	if cfg.OIDCIssuer != "" {
+		redirectURL := cfg.OIDCRedirectURL
+		if redirectURL == "" {
+			redirectURL = strings.TrimSuffix(cfg.BaseURL, "/") + "/api/auth/oidc/callback"
+		}
+		oidc = service.NewOIDCService(service.OIDCConfig{
+			Issuer:       cfg.OIDCIssuer,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  redirectURL,
+		}, auth, store)
+	}
+
+	trustedSubnet, err := parsePrefix(cfg.TrustedSubnet)
+	if err != nil {
+		l.Fatal("invalid trusted subnet", zap.Error(err))
+	}
+	var trustedProxies []netip.Prefix
+	for _, s := range config.SplitList(cfg.TrustedProxies) {
+		p, err := parsePrefix(s)
+		if err != nil {
+			l.Fatal("invalid trusted proxies", zap.Error(err))
+		}
+		trustedProxies = append(trustedProxies, p)
+	}
 
-	auth := service.NewAuthService(cfg.AuthSecret)
-	r := handler.NewRouter(svc, auth, l)
+	r := handler.NewRouter(handler.RouterDeps{
+		Shortener:   svc,
+		Auth:        auth,
+		APIKeys:     keys,
+		Accounts:    accounts,
+		Workspaces:  service.NewWorkspaceService(store, svc),
+		Admin:       service.NewAdminService(store, svc),
+		Moderation:  service.NewModerationService(store, svc),
+		Health:      health,
+		OIDC:        oidc,
+		RateLimiter: rl,
+		Idempotency: idem,
+		Metrics:     m,
+		Logger:      l,
+
+		TrustedSubnet:  trustedSubnet,
+		AdminUserIDs:   config.SplitList(cfg.AdminUsers),
+		TrustedProxies: trustedProxies,
+		SecureCookies:  cfg.EnableHTTPS || strings.HasPrefix(strings.ToLower(cfg.BaseURL), "https://"),
+	})
 
 	l.Info("starting server",
 		zap.String("address", cfg.Address),
 		zap.String("baseURL", cfg.BaseURL),
 		zap.String("logLevel", cfg.LogLevel),
 		zap.String("fileStoragePath", cfg.FileStoragePath),
-		zap.String("databaseDSN", cfg.DatabaseDSN),
+		zap.String("storage", backend), // DSN и адрес Redis не логируем: в них бывает пароль
+		zap.Bool("https", cfg.EnableHTTPS),
 	)
 
-	if err := http.ListenAndServe(cfg.Address, r); err != nil {
-		l.Fatal("server stopped with error", zap.Error(err))
+	timeouts, shutdownTimeout, err := parseTimeouts(cfg)
+	if err != nil {
+		l.Fatal("invalid server timeouts", zap.Error(err))
+	}
+	shutdownDelay, err := time.ParseDuration(cfg.ShutdownDelay)
+	if err != nil {
+		l.Fatal("invalid shutdown delay", zap.Error(err))
+	}
+
+	srv := server.New(cfg.Address, r, timeouts)
+	servers := []*http.Server{srv}
+
+	if cfg.EnableHTTPS {
+		srv.TLSConfig, err = server.NewTLSConfig(cfg.TLSCertFile, cfg.TLSKeyFile, tlsHosts(cfg.BaseURL))
+		if err != nil {
+			l.Fatal("failed to configure tls", zap.Error(err))
+		}
+		if cfg.TLSCertFile == "" {
+			l.Warn("serving with a self-signed certificate, do not use it in production")
+		}
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		srv.Protocols.SetHTTP2(true)
+
+		if cfg.HTTPRedirectAddress != "" {
+			servers = append(servers, server.New(cfg.HTTPRedirectAddress, server.RedirectToHTTPS(cfg.Address), timeouts))
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	rld := &reloader{
+		current:     cfg,
+		load:        config.NewConfig,
+		log:         l,
+		shortener:   svc,
+		rateLimiter: rl,
+	}
+	// Подписываемся до запуска горутины: SIGHUP сразу после старта иначе завершил бы процесс
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	go rld.watch(ctx, cfg.ConfigPath, hup)
+
+	errCh := make(chan error, len(servers))
+	for _, s := range servers {
+		go func() {
+			if err := server.Serve(s); err != nil {
+				errCh <- fmt.Errorf("server %s: %w", s.Addr, err)
+			}
+		}()
+	}
+
+	var serveErr error
+	select {
+	case serveErr = <-errCh:
+		l.Error("server stopped with error", zap.Error(serveErr))
+	case <-ctx.Done():
+		l.Info("shutting down", zap.Duration("timeout", shutdownTimeout))
+	}
+	// Повторный сигнал завершит процесс сразу
+	stop()
+
+	// Пока балансировщик замечает проваленную /readyz, продолжаем принимать запросы
+	health.SetDraining()
+	if serveErr == nil && shutdownDelay > 0 {
+		l.Info("draining before shutdown", zap.Duration("delay", shutdownDelay))
+		time.Sleep(shutdownDelay)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// Порядок важен: пока идут запросы, они могут ставить удаления в очередь,
+	// а сброс очереди ещё пишет в хранилище
+	deleterStopped := false
+	err = server.Shutdown(shutdownCtx, servers,
+		server.Closer{Name: "batch deleter", Close: func(ctx context.Context) error {
+			if err := bd.Shutdown(ctx); err != nil {
+				return fmt.Errorf("%w: %d deletion tasks still queued", err, bd.QueueLen())
+			}
+			deleterStopped = true
+			return nil
+		}},
+		server.Closer{Name: "store", Close: func(context.Context) error {
+			// Сброс удалений не уложился в таймаут и ещё пишет в хранилище — не закрываем его
+			// из-под MarkURLsDeleted, соединения закроются вместе с процессом
+			if !deleterStopped {
+				l.Warn("store is left open: batch deleter is still flushing")
+				return nil
+			}
+			store.Close()
+			return nil
+		}},
+		// Последним: спаны, открытые при остановке, тоже должны уйти в экспортёр
+		server.Closer{Name: "tracing", Close: shutdownTracing},
+	)
+	if err != nil {
+		l.Error("shutdown finished with errors", zap.Error(err))
+	}
+
+	if serveErr != nil || err != nil {
+		l.Sync()
+		os.Exit(1)
+	}
+	l.Info("server stopped")
+}
+
+// tlsHosts — для кого выписывать самоподписанный сертификат: localhost и хост из BaseURL
+func tlsHosts(baseURL string) []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	if u, err := url.Parse(baseURL); err == nil && u.Hostname() != "" && !slices.Contains(hosts, u.Hostname()) {
+		hosts = append(hosts, u.Hostname())
+	}
+	return hosts
+}
+
+func parseTimeouts(cfg *config.Config) (server.Timeouts, time.Duration, error) {
+	var t server.Timeouts
+	fields := []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"read header timeout", cfg.ReadHeaderTimeout, &t.ReadHeader},
+		{"read timeout", cfg.ReadTimeout, &t.Read},
+		{"write timeout", cfg.WriteTimeout, &t.Write},
+		{"idle timeout", cfg.IdleTimeout, &t.Idle},
+	}
+
+	for _, f := range fields {
+		d, err := time.ParseDuration(f.value)
+		if err != nil {
+			return t, 0, fmt.Errorf("%s: %w", f.name, err)
+		}
+		*f.dst = d
+	}
+
+	shutdown, err := time.ParseDuration(cfg.ShutdownTimeout)
+	if err != nil {
+		return t, 0, fmt.Errorf("shutdown timeout: %w", err)
+	}
+
+	return t, shutdown, nil
+}
+
+func parseRateLimits(cfg *config.Config) (middleware.RateLimits, error) {
+	var limits middleware.RateLimits
+	var err error
+
+	if limits.Create, err = middleware.ParseRateLimit(cfg.RateLimitCreate); err != nil {
+		return limits, err
+	}
+	if limits.Delete, err = middleware.ParseRateLimit(cfg.RateLimitDelete); err != nil {
+		return limits, err
+	}
+	if limits.Redirect, err = middleware.ParseRateLimit(cfg.RateLimitRedirect); err != nil {
+		return limits, err
+	}
+
+	return limits, nil
+}
+
+func loadKeyRing(cfg *config.Config, authSecret string) (*service.KeyRing, error) {
+	if cfg.AuthKeysFile != "" {
+		return service.LoadKeyRingFile(cfg.AuthKeysFile)
+	}
+	return service.ParseKeyRing(cfg.AuthKeys, cfg.AuthActiveKey, authSecret)
+}
+
+// parsePrefix принимает CIDR или одиночный адрес. Пустая строка — невалидный (выключенный) префикс.
+func parsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return netip.Prefix{}, nil
+	}
+	if p, err := netip.ParsePrefix(s); err == nil {
+		return p.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid CIDR %q", s)
 	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
 }
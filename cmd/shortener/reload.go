@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/kayumovtd/url-shortener/internal/config"
+	"github.com/kayumovtd/url-shortener/internal/logger"
+	"github.com/kayumovtd/url-shortener/internal/middleware"
+	"github.com/kayumovtd/url-shortener/internal/service"
+	"go.uber.org/zap"
+)
+
+// Редакторы сохраняют файл в несколько событий, перечитываем один раз после паузы
+const reloadDebounce = 200 * time.Millisecond
+
+// Поля, которые применяются без перезапуска. Остальные изменения только логируются.
+var reloadableFields = map[string]bool{
+	"log_level":           true,
+	"base_url":            true,
+	"rate_limit_create":   true,
+	"rate_limit_delete":   true,
+	"rate_limit_redirect": true,
+}
+
+type reloader struct {
+	mu      sync.Mutex
+	current *config.Config
+	load    func() (*config.Config, error)
+
+	log         *logger.Logger
+	shortener   *service.ShortenerService
+	rateLimiter *middleware.RateLimiter
+}
+
+// reload перечитывает конфигурацию целиком. Невалидная новая конфигурация отбрасывается,
+// работающая остаётся как была.
+func (r *reloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := r.load()
+	if err == nil {
+		err = r.apply(next)
+	}
+	if err != nil {
+		r.log.Error("config reload rejected, keeping the current config", zap.Error(err))
+	}
+	return err
+}
+
+// apply применяет поля из reloadableFields и логирует дифф остальных. Вызывается под r.mu.
+func (r *reloader) apply(next *config.Config) error {
+	changes := config.Diff(r.current, next)
+	if len(changes) == 0 {
+		r.log.Info("config reloaded, nothing changed")
+		return nil
+	}
+
+	// Всё разбираем до применения, чтобы не применить конфигурацию наполовину
+	limits, err := parseRateLimits(next)
+	if err != nil {
+		return err
+	}
+	if err := r.log.SetLevel(next.LogLevel); err != nil {
+		return err
+	}
+	r.shortener.SetBaseURL(next.BaseURL)
+	r.rateLimiter.SetLimits(limits)
+
+	applied := *r.current
+	applied.LogLevel = next.LogLevel
+	applied.BaseURL = next.BaseURL
+	applied.RateLimitCreate = next.RateLimitCreate
+	applied.RateLimitDelete = next.RateLimitDelete
+	applied.RateLimitRedirect = next.RateLimitRedirect
+
+	for _, c := range changes {
+		fields := []zap.Field{zap.String("field", c.Field), zap.String("old", c.Old), zap.String("new", c.New)}
+		if reloadableFields[c.Field] {
+			r.log.Info("config changed", fields...)
+		} else {
+			r.log.Warn("config changed, restart required to apply", fields...)
+		}
+	}
+	if applied.BaseURL != r.current.BaseURL && applied.OIDCIssuer != "" && applied.OIDCRedirectURL == "" {
+		r.log.Warn("oidc redirect url is derived from base_url and keeps the old value until restart")
+	}
+
+	r.current = &applied
+	return nil
+}
+
+// watch перечитывает конфигурацию по сигналу из hup и при изменении файла конфигурации, пока не отменён ctx.
+// Если за файлом следить не получилось, остаётся только сигнал.
+func (r *reloader) watch(ctx context.Context, path string, hup <-chan os.Signal) {
+	var (
+		events <-chan fsnotify.Event
+		errs   <-chan error
+	)
+	if path != "" {
+		w, err := watchFile(path)
+		if err != nil {
+			r.log.Warn("config file watch disabled, use SIGHUP to reload", zap.Error(err))
+		} else {
+			defer w.Close()
+			events, errs = w.Events, w.Errors
+			path = filepath.Clean(path)
+		}
+	}
+
+	debounce := time.NewTimer(reloadDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.log.Info("received SIGHUP, reloading config")
+			r.reload()
+		case ev := <-events:
+			if filepath.Clean(ev.Name) == path && (ev.Has(fsnotify.Write) || ev.Has(fsnotify.Create)) {
+				debounce.Reset(reloadDebounce)
+			}
+		case err := <-errs:
+			r.log.Warn("config watcher error", zap.Error(err))
+		case <-debounce.C:
+			r.log.Info("config file changed, reloading config", zap.String("path", path))
+			r.reload()
+		}
+	}
+}
+
+// watchFile следит за каталогом файла: многие редакторы сохраняют через запись во временный файл и переименование
+func watchFile(path string) (*fsnotify.Watcher, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("create watcher: %w", err)
+	}
+	if err := w.Add(filepath.Dir(path)); err != nil {
+		w.Close()
+		return nil, fmt.Errorf("watch %s: %w", filepath.Dir(path), err)
+	}
+	return w, nil
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/kayumovtd/url-shortener/internal/config"
+	"github.com/kayumovtd/url-shortener/internal/logger"
+	"github.com/kayumovtd/url-shortener/internal/middleware"
+	"github.com/kayumovtd/url-shortener/internal/repository"
+	"github.com/kayumovtd/url-shortener/internal/service"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newTestReloader(t *testing.T, cfg *config.Config) (*reloader, *observer.ObservedLogs) {
+	t.Helper()
+
+	lvl := zap.NewAtomicLevelAt(zap.InfoLevel)
+	core, logs := observer.New(lvl)
+	l := logger.NewWithCore(core, lvl)
+
+	limits, err := parseRateLimits(cfg)
+	if err != nil {
+		t.Fatalf("parse rate limits: %v", err)
+	}
+	return &reloader{
+		current:     cfg,
+		load:        func() (*config.Config, error) { return nil, errors.New("load is not set") },
+		log:         l,
+		shortener:   service.NewShortenerService(repository.NewInMemoryStore(), cfg.BaseURL, nil),
+		rateLimiter: middleware.NewRateLimiter(repository.NewInMemoryRateLimitStore(), limits, service.NewAuthService("secret"), l),
+	}, logs
+}
+
+func testConfig() *config.Config {
+	return &config.Config{
+		Address:  ":8080",
+		BaseURL:  "http://old.example",
+		LogLevel: "info",
+		AuthKeys: "old-secret",
+	}
+}
+
+// createAllowed отправляет запрос под лимитом создания и сообщает, пропустил ли его лимитер
+func createAllowed(rl *middleware.RateLimiter) bool {
+	h := rl.Middleware(middleware.RateLimitCreate)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+	return w.Code != http.StatusTooManyRequests
+}
+
+func TestReloaderApply(t *testing.T) {
+	r, logs := newTestReloader(t, testConfig())
+
+	next := testConfig()
+	next.LogLevel = "debug"
+	next.BaseURL = "http://new.example"
+	next.RateLimitCreate = "1/m"
+	next.Address = ":9090"
+	next.AuthKeys = "new-secret"
+
+	if err := r.apply(next); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if r.log.Level() != "debug" {
+		t.Errorf("log level not applied: %s", r.log.Level())
+	}
+	short, err := r.shortener.Shorten(t.Context(), "https://example.com", "u1")
+	if err != nil || !strings.HasPrefix(short, "http://new.example/") {
+		t.Errorf("base url not applied: %q, %v", short, err)
+	}
+	if !createAllowed(r.rateLimiter) || createAllowed(r.rateLimiter) {
+		t.Error("rate limit not applied")
+	}
+
+	// Поля, которые требуют перезапуска, в действующую конфигурацию не попадают
+	if r.current.Address != ":8080" || r.current.AuthKeys != "old-secret" || r.current.BaseURL != "http://new.example" {
+		t.Errorf("unexpected current config: %+v", r.current)
+	}
+
+	restart := logs.FilterMessage("config changed, restart required to apply").All()
+	if len(restart) != 2 {
+		t.Fatalf("expected 2 restart warnings, got %d", len(restart))
+	}
+	for _, e := range logs.All() {
+		for _, f := range e.Context {
+			if strings.Contains(f.String, "secret") {
+				t.Errorf("secret leaked into the log: %s %v", e.Message, e.ContextMap())
+			}
+		}
+	}
+	if applied := logs.FilterMessage("config changed").All(); len(applied) != 3 {
+		t.Errorf("expected 3 applied changes, got %d", len(applied))
+	}
+}
+
+func TestReloaderApply_RejectsInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*config.Config)
+	}{
+		{"rate_limit", func(c *config.Config) { c.RateLimitCreate = "many" }},
+		{"log_level", func(c *config.Config) { c.LogLevel = "loud" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			r, _ := newTestReloader(t, cfg)
+
+			next := testConfig()
+			next.BaseURL = "http://new.example"
+			next.LogLevel = "debug"
+			tt.modify(next)
+
+			if err := r.apply(next); err == nil {
+				t.Fatal("expected error")
+			}
+			if r.current != cfg || r.log.Level() != "info" {
+				t.Errorf("rejected config was partially applied: %+v, level %s", r.current, r.log.Level())
+			}
+			if short, _ := r.shortener.Shorten(t.Context(), "https://example.com", "u1"); !strings.HasPrefix(short, "http://old.example/") {
+				t.Errorf("base url changed: %q", short)
+			}
+		})
+	}
+}
+
+func TestReloaderReload_LoadError(t *testing.T) {
+	cfg := testConfig()
+	r, logs := newTestReloader(t, cfg)
+	r.load = func() (*config.Config, error) { return nil, errors.New("broken yaml") }
+
+	if err := r.reload(); err == nil {
+		t.Fatal("expected error")
+	}
+	if r.current != cfg {
+		t.Error("current config replaced after a failed load")
+	}
+	if logs.FilterMessage("config reload rejected, keeping the current config").Len() != 1 {
+		t.Error("rejection is not logged")
+	}
+}
+
+func TestReloaderWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r, _ := newTestReloader(t, testConfig())
+	loads := make(chan struct{}, 10)
+	r.load = func() (*config.Config, error) {
+		loads <- struct{}{}
+		return testConfig(), nil
+	}
+
+	hup := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	ctx, cancel := context.WithCancel(t.Context())
+	go func() {
+		r.watch(ctx, path, hup)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	waitLoad := func(what string) {
+		t.Helper()
+		select {
+		case <-loads:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("config was not reloaded on %s", what)
+		}
+	}
+
+	hup <- syscall.SIGHUP
+	waitLoad("SIGHUP")
+
+	if err := os.WriteFile(path, []byte("log_level: debug\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	waitLoad("file change")
+}
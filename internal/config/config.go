@@ -1,8 +1,18 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 const (
@@ -11,51 +21,358 @@ const (
 	defaultLogLevel        = "info"
 	defaultFileStoragePath = "storage.json"
 	defaultDatabaseDSN     = ""
-	defaultAuthSecret      = "" // оповещать, если не установлен?
+	defaultAuthSecret      = "" // пустой — main сгенерирует временный ключ и предупредит
+	defaultRateLimit       = "" // по умолчанию лимиты выключены
+	defaultIdempotencyTTL  = "24h"
+
+	defaultURLCacheSize        = "0" // кэш редиректов выключен
+	defaultURLCacheTTL         = "1m"
+	defaultURLCacheNegativeTTL = "10s"
+
+	defaultReadHeaderTimeout = "5s"
+	defaultReadTimeout       = "15s"
+	defaultWriteTimeout      = "30s"
+	defaultIdleTimeout       = "2m"
+	defaultShutdownTimeout   = "30s"
+	defaultShutdownDelay     = "0s"
+
+	envConfig = "CONFIG"
 
 	envServerAddr      = "SERVER_ADDRESS"
 	envBaseURL         = "BASE_URL"
+	envLogLevel        = "LOG_LEVEL"
 	envFileStoragePath = "FILE_STORAGE_PATH"
 	envDatabaseDSN     = "DATABASE_DSN"
+	envRedisURL        = "REDIS_URL"
 	envAuthSecret      = "AUTH_SECRET"
+
+	envRateLimitCreate   = "RATE_LIMIT_CREATE"
+	envRateLimitDelete   = "RATE_LIMIT_DELETE"
+	envRateLimitRedirect = "RATE_LIMIT_REDIRECT"
+
+	envIdempotencyTTL = "IDEMPOTENCY_TTL"
+
+	envURLCacheSize        = "URL_CACHE_SIZE"
+	envURLCacheTTL         = "URL_CACHE_TTL"
+	envURLCacheNegativeTTL = "URL_CACHE_NEGATIVE_TTL"
+
+	envAuthKeys      = "AUTH_KEYS"
+	envAuthActiveKey = "AUTH_ACTIVE_KEY"
+	envAuthKeysFile  = "AUTH_KEYS_FILE"
+
+	envOIDCIssuer       = "OIDC_ISSUER"
+	envOIDCClientID     = "OIDC_CLIENT_ID"
+	envOIDCClientSecret = "OIDC_CLIENT_SECRET"
+	envOIDCRedirectURL  = "OIDC_REDIRECT_URL"
+
+	envTrustedSubnet  = "TRUSTED_SUBNET"
+	envTrustedProxies = "TRUSTED_PROXIES"
+	envAdminUsers     = "ADMIN_USERS"
+
+	envEnableHTTPS         = "ENABLE_HTTPS"
+	envTLSCertFile         = "TLS_CERT_FILE"
+	envTLSKeyFile          = "TLS_KEY_FILE"
+	envHTTPRedirectAddress = "HTTP_REDIRECT_ADDRESS"
+
+	envReadHeaderTimeout = "SERVER_READ_HEADER_TIMEOUT"
+	envReadTimeout       = "SERVER_READ_TIMEOUT"
+	envWriteTimeout      = "SERVER_WRITE_TIMEOUT"
+	envIdleTimeout       = "SERVER_IDLE_TIMEOUT"
+	envShutdownTimeout   = "SHUTDOWN_TIMEOUT"
+	envShutdownDelay     = "SHUTDOWN_DELAY"
+
+	envTracingExporter = "TRACING_EXPORTER"
+	envTracingEndpoint = "TRACING_ENDPOINT"
+	envTracingFile     = "TRACING_FILE"
+
+	// Секреты можно передать файлом: <VAR>_FILE=/run/secrets/... (Docker, Kubernetes)
+	secretFileSuffix = "_FILE"
 )
 
 type Config struct {
-	Address         string
-	BaseURL         string
-	LogLevel        string
-	FileStoragePath string
-	DatabaseDSN     string
-	AuthSecret      string
+	Address         string `json:"server_address" yaml:"server_address"`
+	BaseURL         string `json:"base_url" yaml:"base_url"`
+	LogLevel        string `json:"log_level" yaml:"log_level"`
+	FileStoragePath string `json:"file_storage_path" yaml:"file_storage_path"`
+	// DatabaseDSN — строка подключения к PostgreSQL или встроенная база: bolt:///path/to/file.db, sqlite:///path/to/file.db
+	DatabaseDSN string `json:"database_dsn" yaml:"database_dsn"`
+	// RedisURL — redis://[:password@]host:port/db, хранилище для нескольких реплик без PostgreSQL.
+	// Используется, если не задан DatabaseDSN.
+	RedisURL   string `json:"redis_url" yaml:"redis_url"`
+	AuthSecret string `json:"auth_secret" yaml:"auth_secret"`
+
+	// Связка ключей подписи JWT: "kid:secret,kid:secret" или JSON-файл.
+	// Файл важнее AuthKeys, AuthSecret остаётся ключом для токенов без kid.
+	AuthKeys      string `json:"auth_keys" yaml:"auth_keys"`
+	AuthActiveKey string `json:"auth_active_key" yaml:"auth_active_key"`
+	AuthKeysFile  string `json:"auth_keys_file" yaml:"auth_keys_file"`
+
+	// Вход через OpenID Connect включается, если задан OIDCIssuer.
+	// OIDCRedirectURL по умолчанию — BaseURL + /api/auth/oidc/callback.
+	OIDCIssuer       string `json:"oidc_issuer" yaml:"oidc_issuer"`
+	OIDCClientID     string `json:"oidc_client_id" yaml:"oidc_client_id"`
+	OIDCClientSecret string `json:"oidc_client_secret" yaml:"oidc_client_secret"`
+	OIDCRedirectURL  string `json:"oidc_redirect_url" yaml:"oidc_redirect_url"`
+
+	// Лимиты в формате "<count>/<s|m|h>", например "100/m"
+	RateLimitCreate   string `json:"rate_limit_create" yaml:"rate_limit_create"`
+	RateLimitDelete   string `json:"rate_limit_delete" yaml:"rate_limit_delete"`
+	RateLimitRedirect string `json:"rate_limit_redirect" yaml:"rate_limit_redirect"`
+
+	// Сколько хранить ответы на запросы с Idempotency-Key, например "24h"
+	IdempotencyTTL string `json:"idempotency_ttl" yaml:"idempotency_ttl"`
+
+	// Кэш ссылок для редиректов: размер в записях ("0" — выключен), TTL найденных
+	// и ненайденных ссылок. Кэш у каждого инстанса свой, поэтому TTL — это и предел
+	// того, сколько другие инстансы будут видеть удалённую ссылку.
+	URLCacheSize        string `json:"url_cache_size" yaml:"url_cache_size"`
+	URLCacheTTL         string `json:"url_cache_ttl" yaml:"url_cache_ttl"`
+	URLCacheNegativeTTL string `json:"url_cache_negative_ttl" yaml:"url_cache_negative_ttl"`
+
+	// Доступ к /api/internal: CIDR доверенной подсети и/или ID администраторов через запятую.
+	// Если не задано ни то, ни другое, админский API закрыт для всех.
+	TrustedSubnet string `json:"trusted_subnet" yaml:"trusted_subnet"`
+	AdminUsers    string `json:"admin_users" yaml:"admin_users"`
+	// CIDR прокси через запятую, от которых принимаем X-Real-IP, X-Forwarded-For и X-Forwarded-Proto
+	TrustedProxies string `json:"trusted_proxies" yaml:"trusted_proxies"`
+
+	// HTTPS с HTTP/2. Без файлов сертификата генерируется самоподписанный — только для разработки.
+	// HTTPRedirectAddress — адрес, на котором слушаем HTTP и отправляем всех на HTTPS.
+	EnableHTTPS         bool   `json:"enable_https" yaml:"enable_https"`
+	TLSCertFile         string `json:"tls_cert_file" yaml:"tls_cert_file"`
+	TLSKeyFile          string `json:"tls_key_file" yaml:"tls_key_file"`
+	HTTPRedirectAddress string `json:"http_redirect_address" yaml:"http_redirect_address"`
+
+	// Таймауты http.Server в формате time.ParseDuration, "0" — без таймаута.
+	// ShutdownTimeout — сколько ждать завершения запросов и фоновых задач при остановке.
+	// ShutdownDelay — сколько после сигнала отдавать неготовность в /readyz, продолжая обслуживать запросы.
+	ReadHeaderTimeout string `json:"read_header_timeout" yaml:"read_header_timeout"`
+	ReadTimeout       string `json:"read_timeout" yaml:"read_timeout"`
+	WriteTimeout      string `json:"write_timeout" yaml:"write_timeout"`
+	IdleTimeout       string `json:"idle_timeout" yaml:"idle_timeout"`
+	ShutdownTimeout   string `json:"shutdown_timeout" yaml:"shutdown_timeout"`
+	ShutdownDelay     string `json:"shutdown_delay" yaml:"shutdown_delay"`
+
+	// Трассировка OpenTelemetry: "" — выключена, "otlp", "stdout" или "file" (JSON-строки в TracingFile).
+	// Без TracingEndpoint OTLP-экспортёр берёт адрес из стандартных OTEL_EXPORTER_OTLP_*.
+	TracingExporter string `json:"tracing_exporter" yaml:"tracing_exporter"`
+	TracingEndpoint string `json:"tracing_endpoint" yaml:"tracing_endpoint"`
+	TracingFile     string `json:"tracing_file" yaml:"tracing_file"`
+
+	// Файл, из которого загрузились, — за ним следим при горячей перезагрузке
+	ConfigPath string `json:"-" yaml:"-"`
+}
+
+func NewConfig() (*Config, error) {
+	return Load(os.Args[0], os.Args[1:], os.LookupEnv)
 }
 
-func NewConfig() *Config {
-	cfg := &Config{
-		AuthSecret: defaultAuthSecret,
+// Load собирает конфигурацию слоями: значения по умолчанию, файл из -c/CONFIG,
+// переменные окружения, флаги. Каждый следующий слой перекрывает предыдущий.
+// Ошибки всех слоёв и валидации возвращаются вместе.
+func Load(name string, args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	// Первый проход только ради пути к файлу: флаги должны перекрыть файл и окружение,
+	// поэтому по-настоящему применим их в самом конце
+	probe := defaultConfig()
+	var path string
+	fs := newFlagSet(name, probe, &path)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if path == "" {
+		path, _ = lookupEnv(envConfig)
 	}
 
-	flag.StringVar(&cfg.Address, "a", defaultAddress, "HTTP server listen address")
-	flag.StringVar(&cfg.BaseURL, "b", defaultBaseURL, "Base URL for shortened URLs")
-	flag.StringVar(&cfg.LogLevel, "l", defaultLogLevel, "Level for logs")
-	flag.StringVar(&cfg.FileStoragePath, "f", defaultFileStoragePath, "Path to file storage")
-	flag.StringVar(&cfg.DatabaseDSN, "d", defaultDatabaseDSN, "PostgreSQL DSN")
-	flag.Parse()
+	cfg := defaultConfig()
+	var errs []error
 
-	if v, ok := os.LookupEnv(envServerAddr); ok {
-		cfg.Address = v
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			errs = append(errs, fmt.Errorf("config file %s: %w", path, err))
+		}
 	}
-	if v, ok := os.LookupEnv(envBaseURL); ok {
-		cfg.BaseURL = v
+
+	errs = append(errs, cfg.applyEnv(lookupEnv)...)
+
+	// Значения по умолчанию у второго набора — то, что уже собрали, поэтому непереданные флаги ничего не меняют
+	fs = newFlagSet(name, cfg, &path)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
 	}
-	if v, ok := os.LookupEnv(envFileStoragePath); ok {
-		cfg.FileStoragePath = v
+	cfg.ConfigPath = path
+
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
 	}
-	if v, ok := os.LookupEnv(envDatabaseDSN); ok {
-		cfg.DatabaseDSN = v
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
 	}
-	if v, ok := os.LookupEnv(envAuthSecret); ok {
-		cfg.AuthSecret = v
+	return cfg, nil
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		Address:             defaultAddress,
+		BaseURL:             defaultBaseURL,
+		LogLevel:            defaultLogLevel,
+		FileStoragePath:     defaultFileStoragePath,
+		DatabaseDSN:         defaultDatabaseDSN,
+		AuthSecret:          defaultAuthSecret,
+		RateLimitCreate:     defaultRateLimit,
+		RateLimitDelete:     defaultRateLimit,
+		RateLimitRedirect:   defaultRateLimit,
+		IdempotencyTTL:      defaultIdempotencyTTL,
+		URLCacheSize:        defaultURLCacheSize,
+		URLCacheTTL:         defaultURLCacheTTL,
+		URLCacheNegativeTTL: defaultURLCacheNegativeTTL,
+		ReadHeaderTimeout:   defaultReadHeaderTimeout,
+		ReadTimeout:         defaultReadTimeout,
+		WriteTimeout:        defaultWriteTimeout,
+		IdleTimeout:         defaultIdleTimeout,
+		ShutdownTimeout:     defaultShutdownTimeout,
+		ShutdownDelay:       defaultShutdownDelay,
+	}
+}
+
+func newFlagSet(name string, cfg *Config, configPath *string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+
+	fs.StringVar(configPath, "c", *configPath, "Path to JSON or YAML config file")
+	fs.StringVar(configPath, "config", *configPath, "Path to JSON or YAML config file")
+	fs.StringVar(&cfg.Address, "a", cfg.Address, "HTTP server listen address")
+	fs.StringVar(&cfg.BaseURL, "b", cfg.BaseURL, "Base URL for shortened URLs")
+	fs.StringVar(&cfg.LogLevel, "l", cfg.LogLevel, "Level for logs")
+	fs.StringVar(&cfg.FileStoragePath, "f", cfg.FileStoragePath, "Path to file storage")
+	fs.StringVar(&cfg.DatabaseDSN, "d", cfg.DatabaseDSN, "PostgreSQL DSN, bolt:///path/to/file.db or sqlite:///path/to/file.db")
+	fs.StringVar(&cfg.RedisURL, "redis-url", cfg.RedisURL, "Redis URL, e.g. redis://localhost:6379/0")
+	fs.StringVar(&cfg.RateLimitCreate, "rl-create", cfg.RateLimitCreate, "Rate limit for creating URLs, e.g. 100/m")
+	fs.StringVar(&cfg.RateLimitDelete, "rl-delete", cfg.RateLimitDelete, "Rate limit for deleting URLs, e.g. 100/m")
+	fs.StringVar(&cfg.RateLimitRedirect, "rl-redirect", cfg.RateLimitRedirect, "Rate limit for redirects, e.g. 100/s")
+	fs.StringVar(&cfg.IdempotencyTTL, "idempotency-ttl", cfg.IdempotencyTTL, "How long to keep responses for Idempotency-Key replays")
+	fs.StringVar(&cfg.URLCacheSize, "url-cache-size", cfg.URLCacheSize, "How many URLs to cache for redirects, 0 disables the cache")
+	fs.StringVar(&cfg.URLCacheTTL, "url-cache-ttl", cfg.URLCacheTTL, "How long to cache found URLs")
+	fs.StringVar(&cfg.URLCacheNegativeTTL, "url-cache-negative-ttl", cfg.URLCacheNegativeTTL, "How long to cache unknown URL ids, 0 disables negative caching")
+	fs.StringVar(&cfg.AuthKeysFile, "auth-keys-file", cfg.AuthKeysFile, "Path to JSON file with JWT signing keys")
+	fs.StringVar(&cfg.TrustedSubnet, "t", cfg.TrustedSubnet, "Trusted subnet (CIDR) for the internal API")
+	fs.BoolVar(&cfg.EnableHTTPS, "s", cfg.EnableHTTPS, "Serve HTTPS")
+	fs.StringVar(&cfg.TLSCertFile, "tls-cert", cfg.TLSCertFile, "Path to TLS certificate file")
+	fs.StringVar(&cfg.TLSKeyFile, "tls-key", cfg.TLSKeyFile, "Path to TLS key file")
+	fs.StringVar(&cfg.HTTPRedirectAddress, "http-redirect", cfg.HTTPRedirectAddress, "Listen address for plain HTTP that redirects to HTTPS")
+	fs.StringVar(&cfg.ReadHeaderTimeout, "read-header-timeout", cfg.ReadHeaderTimeout, "Timeout for reading request headers")
+	fs.StringVar(&cfg.ReadTimeout, "read-timeout", cfg.ReadTimeout, "Timeout for reading the whole request")
+	fs.StringVar(&cfg.WriteTimeout, "write-timeout", cfg.WriteTimeout, "Timeout for writing the response")
+	fs.StringVar(&cfg.IdleTimeout, "idle-timeout", cfg.IdleTimeout, "How long to keep idle keep-alive connections")
+	fs.StringVar(&cfg.ShutdownTimeout, "shutdown-timeout", cfg.ShutdownTimeout, "How long to wait for graceful shutdown")
+	fs.StringVar(&cfg.ShutdownDelay, "shutdown-delay", cfg.ShutdownDelay, "How long to fail readiness before shutting down")
+	fs.StringVar(&cfg.TracingExporter, "tracing-exporter", cfg.TracingExporter, "Trace exporter: otlp, stdout or file")
+	fs.StringVar(&cfg.TracingEndpoint, "tracing-endpoint", cfg.TracingEndpoint, "OTLP/HTTP collector URL, e.g. http://localhost:4318")
+	fs.StringVar(&cfg.TracingFile, "tracing-file", cfg.TracingFile, "File for the file trace exporter")
+
+	return fs
+}
+
+// loadFile читает JSON или YAML по расширению. Незнакомые ключи — ошибка, чтобы опечатки не терялись молча.
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		return dec.Decode(c)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown format %q, expected .json, .yaml or .yml", filepath.Ext(path))
+	}
+}
+
+func (c *Config) applyEnv(lookupEnv func(string) (string, bool)) []error {
+	vars := []struct {
+		name   string
+		dst    *string
+		secret bool
+	}{
+		{envServerAddr, &c.Address, false},
+		{envBaseURL, &c.BaseURL, false},
+		{envLogLevel, &c.LogLevel, false},
+		{envFileStoragePath, &c.FileStoragePath, false},
+		{envDatabaseDSN, &c.DatabaseDSN, true},
+		{envRedisURL, &c.RedisURL, true},
+		{envAuthSecret, &c.AuthSecret, true},
+		{envAuthKeys, &c.AuthKeys, true},
+		{envAuthActiveKey, &c.AuthActiveKey, false},
+		{envAuthKeysFile, &c.AuthKeysFile, false},
+		{envOIDCIssuer, &c.OIDCIssuer, false},
+		{envOIDCClientID, &c.OIDCClientID, false},
+		{envOIDCClientSecret, &c.OIDCClientSecret, true},
+		{envOIDCRedirectURL, &c.OIDCRedirectURL, false},
+		{envRateLimitCreate, &c.RateLimitCreate, false},
+		{envRateLimitDelete, &c.RateLimitDelete, false},
+		{envRateLimitRedirect, &c.RateLimitRedirect, false},
+		{envIdempotencyTTL, &c.IdempotencyTTL, false},
+		{envURLCacheSize, &c.URLCacheSize, false},
+		{envURLCacheTTL, &c.URLCacheTTL, false},
+		{envURLCacheNegativeTTL, &c.URLCacheNegativeTTL, false},
+		{envTrustedSubnet, &c.TrustedSubnet, false},
+		{envTrustedProxies, &c.TrustedProxies, false},
+		{envAdminUsers, &c.AdminUsers, false},
+		{envTLSCertFile, &c.TLSCertFile, false},
+		{envTLSKeyFile, &c.TLSKeyFile, false},
+		{envHTTPRedirectAddress, &c.HTTPRedirectAddress, false},
+		{envReadHeaderTimeout, &c.ReadHeaderTimeout, false},
+		{envReadTimeout, &c.ReadTimeout, false},
+		{envWriteTimeout, &c.WriteTimeout, false},
+		{envIdleTimeout, &c.IdleTimeout, false},
+		{envShutdownTimeout, &c.ShutdownTimeout, false},
+		{envShutdownDelay, &c.ShutdownDelay, false},
+		{envTracingExporter, &c.TracingExporter, false},
+		{envTracingEndpoint, &c.TracingEndpoint, false},
+		{envTracingFile, &c.TracingFile, false},
+	}
+
+	var errs []error
+	for _, v := range vars {
+		value, ok := lookupEnv(v.name)
+
+		if v.secret {
+			if path, fromFile := lookupEnv(v.name + secretFileSuffix); fromFile {
+				if ok {
+					errs = append(errs, fmt.Errorf("%s and %s%s are both set", v.name, v.name, secretFileSuffix))
+					continue
+				}
+				data, err := os.ReadFile(path)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s%s: %w", v.name, secretFileSuffix, err))
+					continue
+				}
+				// Редакторы и echo оставляют перевод строки в конце файла
+				value, ok = strings.TrimRight(string(data), "\r\n"), true
+			}
+		}
+
+		if ok {
+			*v.dst = value
+		}
+	}
+
+	if v, ok := lookupEnv(envEnableHTTPS); ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid boolean %q", envEnableHTTPS, v))
+		} else {
+			c.EnableHTTPS = b
+		}
 	}
 
-	return cfg
+	return errs
 }
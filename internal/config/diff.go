@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const redacted = "[redacted]"
+
+// Поля с секретами: в диффе видно только, что значение поменялось
+var secretFields = map[string]bool{
+	"database_dsn":       true,
+	"redis_url":          true,
+	"auth_secret":        true,
+	"auth_keys":          true,
+	"oidc_client_secret": true,
+}
+
+type Change struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// Diff перечисляет отличающиеся поля по их именам в файле конфигурации.
+func Diff(old, new *Config) []Change {
+	ov := reflect.ValueOf(old).Elem()
+	nv := reflect.ValueOf(new).Elem()
+	t := ov.Type()
+
+	var changes []Change
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		o, n := fmt.Sprint(ov.Field(i).Interface()), fmt.Sprint(nv.Field(i).Interface())
+		if o == n {
+			continue
+		}
+		if secretFields[name] {
+			o, n = redact(o), redact(n)
+		}
+		changes = append(changes, Change{Field: name, Old: o, New: n})
+	}
+	return changes
+}
+
+func redact(s string) string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
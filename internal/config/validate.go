@@ -0,0 +1,212 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap/zapcore"
+)
+
+// MinSecretLength — HS256 требует ключ не короче выхода SHA-256
+const MinSecretLength = 32
+
+var rateLimitPattern = regexp.MustCompile(`^\d+/[smh]$`)
+
+// Validate проверяет все поля и возвращает все найденные ошибки разом
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(field string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", field, err))
+		}
+	}
+
+	check("server_address", validateAddress(c.Address))
+	check("base_url", validateHTTPURL(c.BaseURL))
+	if _, err := zapcore.ParseLevel(c.LogLevel); err != nil {
+		check("log_level", fmt.Errorf("unknown level %q", c.LogLevel))
+	}
+	if c.DatabaseDSN != "" {
+		switch DSNScheme(c.DatabaseDSN) {
+		case "bolt", "bbolt", "sqlite":
+			if DSNPath(c.DatabaseDSN) == "" {
+				check("database_dsn", errors.New("DSN has no file path"))
+			}
+		default:
+			if _, err := pgconn.ParseConfig(c.DatabaseDSN); err != nil {
+				// Текст ошибки pgconn может содержать DSN с паролем
+				check("database_dsn", errors.New("invalid PostgreSQL connection string"))
+			}
+		}
+	}
+	if c.RedisURL != "" {
+		if _, err := redis.ParseURL(c.RedisURL); err != nil {
+			check("redis_url", errors.New("invalid Redis URL"))
+		}
+	}
+
+	if c.AuthSecret != "" && len(c.AuthSecret) < MinSecretLength {
+		check("auth_secret", fmt.Errorf("must be at least %d bytes", MinSecretLength))
+	}
+	for _, pair := range SplitList(c.AuthKeys) {
+		id, secret, ok := strings.Cut(pair, ":")
+		if !ok || id == "" {
+			check("auth_keys", errors.New(`expected "kid:secret,kid:secret"`))
+			break
+		}
+		if len(secret) < MinSecretLength {
+			check("auth_keys", fmt.Errorf("secret for key %q must be at least %d bytes", id, MinSecretLength))
+		}
+	}
+
+	if c.OIDCIssuer != "" {
+		check("oidc_issuer", validateHTTPURL(c.OIDCIssuer))
+		if c.OIDCClientID == "" {
+			check("oidc_client_id", errors.New("required when oidc_issuer is set"))
+		}
+	}
+	if c.OIDCRedirectURL != "" {
+		check("oidc_redirect_url", validateHTTPURL(c.OIDCRedirectURL))
+	}
+
+	check("rate_limit_create", validateRateLimit(c.RateLimitCreate))
+	check("rate_limit_delete", validateRateLimit(c.RateLimitDelete))
+	check("rate_limit_redirect", validateRateLimit(c.RateLimitRedirect))
+
+	check("idempotency_ttl", validateDuration(c.IdempotencyTTL))
+	if n, err := strconv.Atoi(c.URLCacheSize); err != nil || n < 0 {
+		check("url_cache_size", fmt.Errorf("invalid size %q: expected a non-negative integer", c.URLCacheSize))
+	}
+	check("url_cache_ttl", validateDuration(c.URLCacheTTL))
+	check("url_cache_negative_ttl", validateDuration(c.URLCacheNegativeTTL))
+
+	check("read_header_timeout", validateDuration(c.ReadHeaderTimeout))
+	check("read_timeout", validateDuration(c.ReadTimeout))
+	check("write_timeout", validateDuration(c.WriteTimeout))
+	check("idle_timeout", validateDuration(c.IdleTimeout))
+	check("shutdown_timeout", validateDuration(c.ShutdownTimeout))
+	check("shutdown_delay", validateDuration(c.ShutdownDelay))
+
+	if c.TrustedSubnet != "" {
+		check("trusted_subnet", validatePrefix(c.TrustedSubnet))
+	}
+	for _, p := range SplitList(c.TrustedProxies) {
+		check("trusted_proxies", validatePrefix(p))
+	}
+
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		check("tls_cert_file", errors.New("tls_cert_file and tls_key_file must be set together"))
+	}
+	if c.HTTPRedirectAddress != "" {
+		if !c.EnableHTTPS {
+			check("http_redirect_address", errors.New("requires enable_https"))
+		}
+		check("http_redirect_address", validateAddress(c.HTTPRedirectAddress))
+	}
+
+	switch c.TracingExporter {
+	case "", "otlp", "stdout":
+	case "file":
+		if c.TracingFile == "" {
+			check("tracing_file", errors.New("required when tracing_exporter is file"))
+		}
+	default:
+		check("tracing_exporter", fmt.Errorf("unknown exporter %q, expected otlp, stdout or file", c.TracingExporter))
+	}
+	if c.TracingEndpoint != "" {
+		check("tracing_endpoint", validateHTTPURL(c.TracingEndpoint))
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateAddress(addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
+
+func validateHTTPURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return fmt.Errorf("invalid url %q", s)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("url %q must use http or https", s)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("url %q has no host", s)
+	}
+	return nil
+}
+
+func validateRateLimit(s string) error {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" || rateLimitPattern.MatchString(s) {
+		return nil
+	}
+	return fmt.Errorf("invalid rate limit %q: expected <count>/<s|m|h>", s)
+}
+
+func validateDuration(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", s)
+	}
+	if d < 0 {
+		return fmt.Errorf("duration %q must not be negative", s)
+	}
+	return nil
+}
+
+// validatePrefix принимает CIDR или одиночный адрес, как и main
+func validatePrefix(s string) error {
+	s = strings.TrimSpace(s)
+	if _, err := netip.ParsePrefix(s); err == nil {
+		return nil
+	}
+	if _, err := netip.ParseAddr(s); err == nil {
+		return nil
+	}
+	return fmt.Errorf("invalid CIDR %q", s)
+}
+
+// SplitList разбирает список через запятую, пропуская пустые элементы
+func SplitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// DSNScheme возвращает схему DSN в нижнем регистре. Для PostgreSQL в формате key=value — пустую строку
+func DSNScheme(dsn string) string {
+	scheme, _, ok := strings.Cut(dsn, "://")
+	if !ok {
+		return ""
+	}
+	return strings.ToLower(scheme)
+}
+
+// DSNPath — путь к файлу встроенной базы: bolt:///abs/path.db, sqlite://rel/path.db
+func DSNPath(dsn string) string {
+	_, path, _ := strings.Cut(dsn, "://")
+	return path
+}
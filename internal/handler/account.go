@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/kayumovtd/url-shortener/internal/middleware"
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"github.com/kayumovtd/url-shortener/internal/service"
+	"github.com/kayumovtd/url-shortener/internal/utils"
+)
+
+func RegisterHandler(accounts *service.AccountService, up service.UserProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req model.CredentialsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			return
+		}
+
+		sessionUserID, _ := up.GetUserID(r.Context())
+
+		user, token, err := accounts.Register(r.Context(), req.Login, req.Password, sessionUserID)
+		if err != nil {
+			var invalid *service.ErrInvalidAccount
+			switch {
+			case errors.As(err, &invalid):
+				utils.WriteJSONError(w, http.StatusBadRequest, invalid.Reason)
+			case errors.Is(err, service.ErrUserExists):
+				utils.WriteJSONError(w, http.StatusConflict, "login is already taken")
+			default:
+				utils.WriteJSONError(w, http.StatusInternalServerError, "failed to register")
+			}
+			return
+		}
+
+		middleware.SetAuthCookie(w, r, token)
+		utils.WriteJSON(w, http.StatusCreated, model.UserResponse{ID: user.ID, Login: user.Login})
+	}
+}
+
+func LoginHandler(accounts *service.AccountService, up service.UserProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req model.CredentialsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			return
+		}
+
+		sessionUserID, _ := up.GetUserID(r.Context())
+
+		user, token, err := accounts.Login(r.Context(), req.Login, req.Password, sessionUserID)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidCredentials) {
+				utils.WriteJSONError(w, http.StatusUnauthorized, err.Error())
+				return
+			}
+			utils.WriteJSONError(w, http.StatusInternalServerError, "failed to login")
+			return
+		}
+
+		middleware.SetAuthCookie(w, r, token)
+		utils.WriteJSON(w, http.StatusOK, model.UserResponse{ID: user.ID, Login: user.Login})
+	}
+}
+
+func LogoutHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		middleware.ClearAuthCookie(w, r)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/kayumovtd/url-shortener/internal/service"
+	"github.com/kayumovtd/url-shortener/internal/utils"
+)
+
+func AdminStatsHandler(admin *service.AdminService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats, err := admin.Stats(r.Context())
+		if err != nil {
+			utils.WriteJSONError(w, http.StatusInternalServerError, "failed to get stats")
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusOK, stats)
+	}
+}
+
+func AdminGetURLHandler(admin *service.AdminService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp, err := admin.GetURL(r.Context(), chi.URLParam(r, "id"))
+		if errors.Is(err, service.ErrURLNotFound) {
+			utils.WriteJSONError(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+			return
+		}
+		if err != nil {
+			utils.WriteJSONError(w, http.StatusInternalServerError, "failed to get url")
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+func AdminSetURLDisabledHandler(admin *service.AdminService, disabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := admin.SetDisabled(r.Context(), chi.URLParam(r, "id"), disabled)
+		if errors.Is(err, service.ErrURLNotFound) {
+			utils.WriteJSONError(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+			return
+		}
+		if err != nil {
+			utils.WriteJSONError(w, http.StatusInternalServerError, "failed to update url")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func AdminUserURLsHandler(admin *service.AdminService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp, err := admin.UserURLs(r.Context(), chi.URLParam(r, "userID"))
+		if err != nil {
+			utils.WriteJSONError(w, http.StatusInternalServerError, "failed to get user urls")
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"github.com/kayumovtd/url-shortener/internal/service"
+	"github.com/kayumovtd/url-shortener/internal/utils"
+)
+
+func CreateAPIKeyHandler(keys *service.APIKeyService, up service.UserProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req model.CreateAPIKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			return
+		}
+
+		userID, ok := RequireUserID(w, r, up)
+		if !ok {
+			return
+		}
+
+		plain, key, err := keys.Create(r.Context(), userID, req.Name)
+		if err != nil {
+			var invalid *service.ErrInvalidAPIKeyName
+			if errors.As(err, &invalid) {
+				utils.WriteJSONError(w, http.StatusBadRequest, invalid.Reason)
+				return
+			}
+			utils.WriteJSONError(w, http.StatusInternalServerError, "failed to create api key")
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusCreated, model.CreateAPIKeyResponse{
+			ID:        key.ID,
+			Name:      key.Name,
+			Key:       plain,
+			CreatedAt: key.CreatedAt,
+		})
+	}
+}
+
+func GetAPIKeysHandler(keys *service.APIKeyService, up service.UserProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := RequireUserID(w, r, up)
+		if !ok {
+			return
+		}
+
+		resp, err := keys.List(r.Context(), userID)
+		if err != nil {
+			utils.WriteJSONError(w, http.StatusInternalServerError, "failed to get api keys")
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+func RevokeAPIKeyHandler(keys *service.APIKeyService, up service.UserProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := RequireUserID(w, r, up)
+		if !ok {
+			return
+		}
+
+		err := keys.Revoke(r.Context(), userID, chi.URLParam(r, "id"))
+		if errors.Is(err, service.ErrAPIKeyNotFound) {
+			utils.WriteJSONError(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+			return
+		}
+		if err != nil {
+			utils.WriteJSONError(w, http.StatusInternalServerError, "failed to revoke api key")
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
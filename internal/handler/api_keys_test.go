@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"github.com/kayumovtd/url-shortener/internal/repository"
+	"github.com/kayumovtd/url-shortener/internal/service"
+	"github.com/kayumovtd/url-shortener/internal/service/mocks"
+)
+
+func TestCreateAPIKeyHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		statusCode int
+	}{
+		{
+			name:       "valid_name",
+			body:       `{"name":"ci"}`,
+			statusCode: http.StatusCreated,
+		},
+		{
+			name:       "name_too_long",
+			body:       `{"name":"` + strings.Repeat("к", 101) + `"}`,
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name:       "invalid_json",
+			body:       `{"name":`,
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	keys := service.NewAPIKeyService(repository.NewMockStore())
+	up := mocks.NewMockUserProvider(testUserID, true)
+	handler := CreateAPIKeyHandler(keys, up)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/user/keys", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != tt.statusCode {
+				t.Fatalf("status code = %d, want %d", res.StatusCode, tt.statusCode)
+			}
+			if res.StatusCode == http.StatusCreated {
+				return
+			}
+
+			var errResp model.ErrorResponse
+			if err := json.NewDecoder(res.Body).Decode(&errResp); err != nil {
+				t.Fatalf("invalid error response: %v", err)
+			}
+			if errResp.Code != "400" || errResp.Message == "" {
+				t.Errorf("unexpected error response: %+v", errResp)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/go-chi/chi/v5"
 
+	"github.com/kayumovtd/url-shortener/internal/model"
 	"github.com/kayumovtd/url-shortener/internal/service"
 	"github.com/kayumovtd/url-shortener/internal/utils"
 )
@@ -47,10 +48,22 @@ func GetHandler(svc *service.ShortenerService) http.HandlerFunc {
 			utils.WritePlainText(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
 			return
 		}
-		if rec.IsDeleted {
+		if rec.IsDeleted || rec.IsDisabled {
 			utils.WritePlainText(w, http.StatusGone, http.StatusText(http.StatusGone))
 			return
 		}
+
+		switch rec.Moderation {
+		case model.ModerationBlocked:
+			utils.WritePlainText(w, http.StatusUnavailableForLegalReasons, "blocked: "+rec.ModerationReason)
+			return
+		case model.ModerationWarned:
+			if r.URL.Query().Get(confirmParam) == "" {
+				writeInterstitial(w, rec)
+				return
+			}
+		}
+
 		http.Redirect(w, r, rec.OriginalURL, http.StatusTemporaryRedirect)
 	}
 }
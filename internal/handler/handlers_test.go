@@ -117,9 +117,10 @@ func TestGetHandler(t *testing.T) {
 	}
 
 	tests := []struct {
-		name string
-		id   string
-		want want
+		name  string
+		id    string
+		query string
+		want  want
 	}{
 		{
 			name: "existing_id",
@@ -136,6 +137,31 @@ func TestGetHandler(t *testing.T) {
 				statusCode: http.StatusGone,
 			},
 		},
+		{
+			name: "blocked_id",
+			id:   "abc3",
+			want: want{
+				statusCode: http.StatusUnavailableForLegalReasons,
+				body:       "phishing",
+			},
+		},
+		{
+			name: "warned_id",
+			id:   "abc4",
+			want: want{
+				statusCode: http.StatusOK,
+				body:       "https://example4.com",
+			},
+		},
+		{
+			name:  "warned_id_confirmed",
+			id:    "abc4",
+			query: "?confirm=1",
+			want: want{
+				statusCode: http.StatusTemporaryRedirect,
+				location:   "https://example4.com",
+			},
+		},
 		{
 			name: "non_existing_id",
 			id:   "xyz999",
@@ -158,6 +184,8 @@ func TestGetHandler(t *testing.T) {
 	store.Data = []model.URLRecord{
 		{ShortURL: "abc1", OriginalURL: "https://example1.com", UserID: testUserID, IsDeleted: false},
 		{ShortURL: "abc2", OriginalURL: "https://example2.com", UserID: testUserID, IsDeleted: true},
+		{ShortURL: "abc3", OriginalURL: "https://example3.com", UserID: testUserID, Moderation: model.ModerationBlocked, ModerationReason: "phishing"},
+		{ShortURL: "abc4", OriginalURL: "https://example4.com", UserID: testUserID, Moderation: model.ModerationWarned},
 	}
 
 	bd := service.NewBatchDeleter(store, logger.NewNoOp())
@@ -167,7 +195,7 @@ func TestGetHandler(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			url := "/" + tt.id
+			url := "/" + tt.id + tt.query
 			req := httptest.NewRequest(http.MethodGet, url, nil)
 			w := httptest.NewRecorder()
 
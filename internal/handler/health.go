@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"github.com/kayumovtd/url-shortener/internal/service"
+	"github.com/kayumovtd/url-shortener/internal/utils"
+)
+
+// HealthzHandler — процесс жив и обслуживает HTTP. Зависимости не проверяем:
+// иначе упавшая база перезапускала бы все поды разом.
+func HealthzHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		utils.WriteJSON(w, http.StatusOK, model.HealthReport{Status: model.HealthOK})
+	}
+}
+
+func ReadyzHandler(health *service.HealthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := health.Ready(r.Context())
+
+		status := http.StatusOK
+		if report.Status != model.HealthOK {
+			status = http.StatusServiceUnavailable
+		}
+		utils.WriteJSON(w, status, report)
+	}
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"github.com/kayumovtd/url-shortener/internal/service"
+	"github.com/kayumovtd/url-shortener/internal/utils"
+)
+
+// Параметр, которым пользователь подтверждает переход по ссылке с предупреждением
+const confirmParam = "confirm"
+
+var interstitialTemplate = template.Must(template.New("interstitial").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Warning</title></head>
+<body>
+<h1>This link has been flagged</h1>
+<p>{{if .Reason}}{{.Reason}}{{else}}Other users reported this link as suspicious.{{end}}</p>
+<p>It leads to <code>{{.OriginalURL}}</code></p>
+<p><a href="?` + confirmParam + `=1" rel="noreferrer">Continue anyway</a></p>
+</body>
+</html>
+`))
+
+func ReportHandler(mod *service.ModerationService, up service.UserProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req model.ReportRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			return
+		}
+
+		// Жаловаться может и аноним, автора запоминаем, если он есть
+		userID, _ := up.GetUserID(r.Context())
+
+		err := mod.Report(r.Context(), chi.URLParam(r, "id"), req.Reason, userID)
+		if err != nil {
+			writeModerationError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func ModerationQueueHandler(mod *service.ModerationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		queue, err := mod.Queue(r.Context())
+		if err != nil {
+			utils.WriteJSONError(w, http.StatusInternalServerError, "failed to get moderation queue")
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusOK, queue)
+	}
+}
+
+func ModerateHandler(mod *service.ModerationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req model.ModerateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			return
+		}
+
+		err := mod.Moderate(r.Context(), chi.URLParam(r, "id"), req.State, req.Reason)
+		if err != nil {
+			writeModerationError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeModerationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidModeration):
+		utils.WriteJSONError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrURLNotFound):
+		utils.WriteJSONError(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+	default:
+		utils.WriteJSONError(w, http.StatusInternalServerError, "failed to process moderation request")
+	}
+}
+
+func writeInterstitial(w http.ResponseWriter, rec model.URLRecord) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	interstitialTemplate.Execute(w, struct {
+		OriginalURL string
+		Reason      string
+	}{rec.OriginalURL, rec.ModerationReason})
+}
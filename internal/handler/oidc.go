@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	"github.com/kayumovtd/url-shortener/internal/middleware"
+	"github.com/kayumovtd/url-shortener/internal/service"
+	"github.com/kayumovtd/url-shortener/internal/utils"
+)
+
+const (
+	oidcFlowCookie     = "oidc_flow"
+	oidcFlowCookiePath = "/api/auth/oidc"
+	oidcFlowMaxAge     = 10 * 60
+)
+
+func OIDCLoginHandler(oidc *service.OIDCService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		authURL, flow, err := oidc.AuthURL(r.Context())
+		if err != nil {
+			utils.WritePlainText(w, http.StatusBadGateway, "identity provider is not available")
+			return
+		}
+
+		data, err := json.Marshal(flow)
+		if err != nil {
+			utils.WritePlainText(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+
+		// state, nonce и PKCE verifier живут в куке до колбэка, серверного состояния не держим
+		middleware.SetCookie(w, r, &http.Cookie{
+			Name:     oidcFlowCookie,
+			Value:    base64.RawURLEncoding.EncodeToString(data),
+			Path:     oidcFlowCookiePath,
+			MaxAge:   oidcFlowMaxAge,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+		http.Redirect(w, r, authURL, http.StatusFound)
+	}
+}
+
+func OIDCCallbackHandler(oidc *service.OIDCService, redirectTo string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flow, ok := readOIDCFlow(r)
+		// Кука одноразовая
+		middleware.SetCookie(w, r, &http.Cookie{Name: oidcFlowCookie, Path: oidcFlowCookiePath, MaxAge: -1})
+
+		if !ok || r.URL.Query().Get("state") != flow.State {
+			utils.WritePlainText(w, http.StatusBadRequest, "invalid oidc state")
+			return
+		}
+		if errParam := r.URL.Query().Get("error"); errParam != "" {
+			utils.WritePlainText(w, http.StatusUnauthorized, "identity provider error: "+errParam)
+			return
+		}
+
+		_, token, err := oidc.Exchange(r.Context(), r.URL.Query().Get("code"), flow)
+		if err != nil {
+			utils.WritePlainText(w, http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+			return
+		}
+
+		middleware.SetAuthCookie(w, r, token)
+		http.Redirect(w, r, redirectTo, http.StatusFound)
+	}
+}
+
+func readOIDCFlow(r *http.Request) (service.OIDCFlow, bool) {
+	var flow service.OIDCFlow
+
+	cookie, err := r.Cookie(oidcFlowCookie)
+	if err != nil {
+		return flow, false
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil {
+		return flow, false
+	}
+	if err := json.Unmarshal(data, &flow); err != nil || flow.State == "" {
+		return flow, false
+	}
+	return flow, true
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/kayumovtd/url-shortener/internal/repository"
+	"github.com/kayumovtd/url-shortener/internal/service"
+	"github.com/kayumovtd/url-shortener/internal/service/mocks"
+)
+
+func TestOIDCHandlers(t *testing.T) {
+	idp := mocks.NewMockOIDCProvider("shortener", "employee-42")
+	defer idp.Close()
+
+	auth := service.NewAuthService("secret")
+	oidc := service.NewOIDCService(service.OIDCConfig{
+		Issuer:      idp.Issuer(),
+		ClientID:    "shortener",
+		RedirectURL: "http://localhost:8080/api/auth/oidc/callback",
+	}, auth, repository.NewMockStore())
+
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	login := func(t *testing.T) (*http.Cookie, *url.URL) {
+		t.Helper()
+
+		w := httptest.NewRecorder()
+		OIDCLoginHandler(oidc)(w, httptest.NewRequest(http.MethodGet, "/api/auth/oidc/login", nil))
+		res := w.Result()
+		defer res.Body.Close()
+
+		if res.StatusCode != http.StatusFound {
+			t.Fatalf("status = %d, want %d", res.StatusCode, http.StatusFound)
+		}
+		cookies := res.Cookies()
+		if len(cookies) != 1 || cookies[0].Name != oidcFlowCookie {
+			t.Fatalf("expected %s cookie", oidcFlowCookie)
+		}
+
+		// провайдер сразу редиректит обратно на колбэк с кодом
+		idpRes, err := noRedirect.Get(res.Header.Get("Location"))
+		if err != nil {
+			t.Fatalf("authorize request failed: %v", err)
+		}
+		defer idpRes.Body.Close()
+
+		callback, err := url.Parse(idpRes.Header.Get("Location"))
+		if err != nil {
+			t.Fatalf("invalid callback url: %v", err)
+		}
+		return cookies[0], callback
+	}
+
+	callback := func(flowCookie *http.Cookie, query string) *http.Response {
+		req := httptest.NewRequest(http.MethodGet, "/api/auth/oidc/callback?"+query, nil)
+		if flowCookie != nil {
+			req.AddCookie(flowCookie)
+		}
+		w := httptest.NewRecorder()
+		OIDCCallbackHandler(oidc, "/")(w, req)
+		return w.Result()
+	}
+
+	t.Run("success", func(t *testing.T) {
+		flowCookie, cb := login(t)
+		res := callback(flowCookie, cb.RawQuery)
+		defer res.Body.Close()
+
+		if res.StatusCode != http.StatusFound {
+			t.Fatalf("status = %d, want %d", res.StatusCode, http.StatusFound)
+		}
+
+		var token string
+		for _, c := range res.Cookies() {
+			if c.Name == "auth_token" {
+				token = c.Value
+			}
+		}
+		if _, _, err := auth.ParseAndVerify(token); err != nil {
+			t.Errorf("expected valid auth_token cookie, got: %v", err)
+		}
+	})
+
+	t.Run("state_mismatch", func(t *testing.T) {
+		flowCookie, cb := login(t)
+		q := cb.Query()
+		q.Set("state", "forged")
+
+		res := callback(flowCookie, q.Encode())
+		defer res.Body.Close()
+
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", res.StatusCode, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("no_flow_cookie", func(t *testing.T) {
+		_, cb := login(t)
+
+		res := callback(nil, cb.RawQuery)
+		defer res.Body.Close()
+
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", res.StatusCode, http.StatusBadRequest)
+		}
+	})
+}
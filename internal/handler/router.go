@@ -1,31 +1,121 @@
 package handler
 
 import (
+	"net/netip"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/kayumovtd/url-shortener/internal/logger"
+	"github.com/kayumovtd/url-shortener/internal/metrics"
 	"github.com/kayumovtd/url-shortener/internal/middleware"
 	"github.com/kayumovtd/url-shortener/internal/service"
 )
 
-func NewRouter(
-	svc *service.ShortenerService,
-	auth *service.AuthService,
-	l *logger.Logger,
-) chi.Router {
+type RouterDeps struct {
+	Shortener  *service.ShortenerService
+	Auth       *service.AuthService
+	APIKeys    *service.APIKeyService
+	Accounts   *service.AccountService
+	Workspaces *service.WorkspaceService
+	Admin      *service.AdminService
+	Moderation *service.ModerationService
+	Health     *service.HealthService
+	// OIDC == nil — вход через OpenID Connect выключен
+	OIDC *service.OIDCService
+
+	// Доступ к /api/internal: подсеть (невалидная — выключено) и ID администраторов
+	TrustedSubnet netip.Prefix
+	AdminUserIDs  []string
+	// Прокси, которым доверяем X-Real-IP, X-Forwarded-For и X-Forwarded-Proto
+	TrustedProxies []netip.Prefix
+	// SecureCookies — сервис доступен только по HTTPS, куки всегда Secure, даже если TLS снят прокси
+	SecureCookies bool
+
+	// Metrics == nil — /metrics не отдаём и запросы не считаем
+	Metrics *metrics.Metrics
+
+	RateLimiter *middleware.RateLimiter
+	Idempotency *middleware.Idempotency
+	Logger      *logger.Logger
+}
+
+func NewRouter(d RouterDeps) chi.Router {
 	r := chi.NewRouter()
 
-	r.Use(middleware.GzipMiddleware)
-	r.Use(middleware.LoggingMiddleware(l))
-	r.Use(middleware.AuthMiddleware(auth))
+	svc, auth := d.Shortener, d.Auth
+
+	r.Use(middleware.RealIPMiddleware(d.TrustedProxies))
+	if d.SecureCookies {
+		r.Use(middleware.SecureCookiesMiddleware)
+	}
+	r.Use(middleware.RequestIDMiddleware)
+	if d.Metrics != nil {
+		r.Use(middleware.MetricsMiddleware(d.Metrics))
+	}
+	r.Use(middleware.TracingMiddleware)
+	r.Use(middleware.CompressMiddleware(middleware.DefaultCompressMinSize))
+	r.Use(middleware.LoggingMiddleware(d.Logger))
+	r.Use(middleware.AuthMiddleware(auth, d.APIKeys))
+
+	// Лимит проверяем раньше идемпотентности, чтобы повторы тоже тратили бюджет
+	create := chi.Chain(d.RateLimiter.Middleware(middleware.RateLimitCreate), d.Idempotency.Middleware)
+	remove := d.RateLimiter.Middleware(middleware.RateLimitDelete)
+	redirect := d.RateLimiter.Middleware(middleware.RateLimitRedirect)
 
-	r.Post("/", PostHandler(svc, auth))
-	r.Get("/{id}", GetHandler(svc))
+	r.With(create...).Post("/", PostHandler(svc, auth))
+	r.With(redirect).Get("/{id}", GetHandler(svc))
 	r.Get("/ping", PingHandler(svc))
+	r.Get("/healthz", HealthzHandler())
+	r.Get("/readyz", ReadyzHandler(d.Health))
+	// Жалобы создают записи, поэтому живут под тем же лимитом, что и создание ссылок
+	r.With(d.RateLimiter.Middleware(middleware.RateLimitCreate)).Post("/{id}/report", ReportHandler(d.Moderation, auth))
 
 	r.Get("/api/user/urls", GetUserURLsHandler(svc, auth))
-	r.Post("/api/shorten", ShortenHandler(svc, auth))
-	r.Post("/api/shorten/batch", ShortenBatchHandler(svc, auth))
-	r.Delete("/api/user/urls", DeleteUserURLsHandler(svc, auth))
+	r.With(create...).Post("/api/shorten", ShortenHandler(svc, auth))
+	r.With(create...).Post("/api/shorten/batch", ShortenBatchHandler(svc, auth))
+	r.With(remove).Delete("/api/user/urls", DeleteUserURLsHandler(svc, auth))
+	r.Patch("/api/user/urls/{id}", RetargetURLHandler(d.Workspaces, auth))
+
+	// Регистрация и вход считают bcrypt и годятся для перебора паролей, поэтому под лимитом создания
+	r.With(d.RateLimiter.Middleware(middleware.RateLimitCreate)).Post("/api/user/register", RegisterHandler(d.Accounts, auth))
+	r.With(d.RateLimiter.Middleware(middleware.RateLimitCreate)).Post("/api/user/login", LoginHandler(d.Accounts, auth))
+	r.Post("/api/user/logout", LogoutHandler())
+
+	if d.OIDC != nil {
+		r.Get("/api/auth/oidc/login", OIDCLoginHandler(d.OIDC))
+		r.Get("/api/auth/oidc/callback", OIDCCallbackHandler(d.OIDC, "/"))
+	}
+
+	r.Post("/api/user/keys", CreateAPIKeyHandler(d.APIKeys, auth))
+	r.Get("/api/user/keys", GetAPIKeysHandler(d.APIKeys, auth))
+	r.Delete("/api/user/keys/{id}", RevokeAPIKeyHandler(d.APIKeys, auth))
+
+	r.Route("/api/workspaces", func(r chi.Router) {
+		r.Post("/", CreateWorkspaceHandler(d.Workspaces, auth))
+		r.Get("/", GetWorkspacesHandler(d.Workspaces, auth))
+		r.Get("/{id}/members", GetWorkspaceMembersHandler(d.Workspaces, auth))
+		r.Put("/{id}/members/{userID}", SetWorkspaceMemberHandler(d.Workspaces, auth))
+		r.Delete("/{id}/members/{userID}", RemoveWorkspaceMemberHandler(d.Workspaces, auth))
+		r.Post("/{id}/urls", AddWorkspaceURLsHandler(d.Workspaces, auth))
+		r.Get("/{id}/urls", GetWorkspaceURLsHandler(d.Workspaces, auth))
+	})
+
+	adminOnly := middleware.AdminAccessMiddleware(d.TrustedSubnet, d.AdminUserIDs, auth)
+
+	// Метрики открыты тем же, кому и внутренний API: сборщик должен ходить из доверенной подсети
+	if d.Metrics != nil {
+		r.With(adminOnly).Get("/metrics", d.Metrics.Handler().ServeHTTP)
+	}
+
+	r.Route("/api/internal", func(r chi.Router) {
+		r.Use(adminOnly)
+		r.Get("/stats", AdminStatsHandler(d.Admin))
+		r.Get("/urls/{id}", AdminGetURLHandler(d.Admin))
+		r.Post("/urls/{id}/disable", AdminSetURLDisabledHandler(d.Admin, true))
+		r.Post("/urls/{id}/enable", AdminSetURLDisabledHandler(d.Admin, false))
+		r.Get("/users/{userID}/urls", AdminUserURLsHandler(d.Admin))
+		r.Get("/moderation/queue", ModerationQueueHandler(d.Moderation))
+		r.Put("/moderation/urls/{id}", ModerateHandler(d.Moderation))
+	})
 
 	return r
 }
@@ -106,7 +106,11 @@ func DeleteUserURLsHandler(svc *service.ShortenerService, up service.UserProvide
 			return
 		}
 
-		svc.EnqueueDeletion(userID, ids)
+		if err := svc.EnqueueDeletion(r.Context(), userID, ids); err != nil {
+			// Сервер останавливается, клиент может повторить запрос позже
+			utils.WriteJSONError(w, http.StatusServiceUnavailable, "service is shutting down")
+			return
+		}
 		utils.WriteJSON(w, http.StatusAccepted, http.StatusText(http.StatusAccepted))
 	}
 }
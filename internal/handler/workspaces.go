@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"github.com/kayumovtd/url-shortener/internal/service"
+	"github.com/kayumovtd/url-shortener/internal/utils"
+)
+
+func CreateWorkspaceHandler(ws *service.WorkspaceService, up service.UserProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req model.CreateWorkspaceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			return
+		}
+
+		userID, ok := RequireUserID(w, r, up)
+		if !ok {
+			return
+		}
+
+		resp, err := ws.Create(r.Context(), userID, req.Name)
+		if err != nil {
+			writeWorkspaceError(w, err)
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusCreated, resp)
+	}
+}
+
+func GetWorkspacesHandler(ws *service.WorkspaceService, up service.UserProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := RequireUserID(w, r, up)
+		if !ok {
+			return
+		}
+
+		resp, err := ws.List(r.Context(), userID)
+		if err != nil {
+			writeWorkspaceError(w, err)
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+func GetWorkspaceMembersHandler(ws *service.WorkspaceService, up service.UserProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := RequireUserID(w, r, up)
+		if !ok {
+			return
+		}
+
+		resp, err := ws.Members(r.Context(), userID, chi.URLParam(r, "id"))
+		if err != nil {
+			writeWorkspaceError(w, err)
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+func SetWorkspaceMemberHandler(ws *service.WorkspaceService, up service.UserProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req model.SetWorkspaceMemberRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			return
+		}
+
+		userID, ok := RequireUserID(w, r, up)
+		if !ok {
+			return
+		}
+
+		err := ws.SetMember(r.Context(), userID, chi.URLParam(r, "id"), chi.URLParam(r, "userID"), req.Role)
+		if err != nil {
+			writeWorkspaceError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func RemoveWorkspaceMemberHandler(ws *service.WorkspaceService, up service.UserProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := RequireUserID(w, r, up)
+		if !ok {
+			return
+		}
+
+		err := ws.RemoveMember(r.Context(), userID, chi.URLParam(r, "id"), chi.URLParam(r, "userID"))
+		if err != nil {
+			writeWorkspaceError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func AddWorkspaceURLsHandler(ws *service.WorkspaceService, up service.UserProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := RequireUserID(w, r, up)
+		if !ok {
+			return
+		}
+
+		var ids []string
+		if err := json.NewDecoder(r.Body).Decode(&ids); err != nil || len(ids) == 0 {
+			utils.WriteJSONError(w, http.StatusBadRequest, "no ids provided")
+			return
+		}
+
+		if err := ws.AddURLs(r.Context(), userID, chi.URLParam(r, "id"), ids); err != nil {
+			writeWorkspaceError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func GetWorkspaceURLsHandler(ws *service.WorkspaceService, up service.UserProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := RequireUserID(w, r, up)
+		if !ok {
+			return
+		}
+
+		urls, err := ws.URLs(r.Context(), userID, chi.URLParam(r, "id"))
+		if err != nil {
+			writeWorkspaceError(w, err)
+			return
+		}
+
+		if len(urls) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusOK, urls)
+	}
+}
+
+// RetargetURLHandler меняет адрес, на который ведёт ссылка. Права — как на удаление.
+func RetargetURLHandler(ws *service.WorkspaceService, up service.UserProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req model.RetargetRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteJSONError(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			return
+		}
+
+		userID, ok := RequireUserID(w, r, up)
+		if !ok {
+			return
+		}
+
+		resp, err := ws.Retarget(r.Context(), userID, chi.URLParam(r, "id"), req.URL)
+		var conflict *service.ErrShortenerConflict
+		if errors.As(err, &conflict) {
+			utils.WriteJSON(w, http.StatusConflict, model.ShortenResponse{Result: conflict.ResultURL})
+			return
+		}
+		if err != nil {
+			writeWorkspaceError(w, err)
+			return
+		}
+
+		utils.WriteJSON(w, http.StatusOK, resp)
+	}
+}
+
+func writeWorkspaceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidWorkspace):
+		utils.WriteJSONError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrWorkspaceNotFound), errors.Is(err, service.ErrURLNotFound):
+		utils.WriteJSONError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrWorkspaceForbidden):
+		utils.WriteJSONError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrLastOwner):
+		utils.WriteJSONError(w, http.StatusConflict, err.Error())
+	default:
+		utils.WriteJSONError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+	}
+}
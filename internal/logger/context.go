@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+type requestFieldsKey struct{}
+
+// requestFields — поля, общие для всех строк лога одного запроса. Набор изменяемый:
+// user_id становится известен только в AuthMiddleware, а access-лог пишется снаружи него.
+type requestFields struct {
+	mu        sync.Mutex
+	requestID string
+	fields    []zap.Field
+}
+
+// WithRequestID заводит в контексте набор полей запроса
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestFieldsKey{}, &requestFields{
+		requestID: requestID,
+		fields:    []zap.Field{zap.String("request_id", requestID)},
+	})
+}
+
+func RequestID(ctx context.Context) string {
+	if rf, ok := ctx.Value(requestFieldsKey{}).(*requestFields); ok {
+		return rf.requestID
+	}
+	return ""
+}
+
+// AddFields дополняет поля запроса. Их увидят все, у кого контекст этого запроса, в том числе внешние middleware.
+// Без WithRequestID выше по цепочке ничего не делает.
+func AddFields(ctx context.Context, fields ...zap.Field) {
+	rf, ok := ctx.Value(requestFieldsKey{}).(*requestFields)
+	if !ok {
+		return
+	}
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	rf.fields = append(rf.fields, fields...)
+}
+
+// WithContext возвращает логгер с полями запроса из ctx
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	rf, ok := ctx.Value(requestFieldsKey{}).(*requestFields)
+	if !ok {
+		return l
+	}
+	rf.mu.Lock()
+	fields := slices.Clone(rf.fields)
+	rf.mu.Unlock()
+
+	return &Logger{Logger: l.Logger.With(fields...), level: l.level}
+}
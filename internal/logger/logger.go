@@ -4,10 +4,12 @@ import (
 	"fmt"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 type Logger struct {
 	*zap.Logger
+	level zap.AtomicLevel
 }
 
 func New(level string) (*Logger, error) {
@@ -24,11 +26,31 @@ func New(level string) (*Logger, error) {
 		return nil, fmt.Errorf("build zap logger: %w", err)
 	}
 
-	return &Logger{zl}, nil
+	return &Logger{Logger: zl, level: lvl}, nil
+}
+
+// NewWithCore собирает логгер поверх готового ядра. level должен управлять этим ядром,
+// иначе SetLevel ни на что не повлияет.
+func NewWithCore(core zapcore.Core, level zap.AtomicLevel) *Logger {
+	return &Logger{Logger: zap.New(core), level: level}
 }
 
 func NewNoOp() *Logger {
-	return &Logger{zap.NewNop()}
+	return &Logger{Logger: zap.NewNop(), level: zap.NewAtomicLevel()}
+}
+
+// SetLevel меняет уровень на лету для всех логгеров, порождённых от этого (With, Named).
+func (l *Logger) SetLevel(level string) error {
+	lvl, err := zap.ParseAtomicLevel(level)
+	if err != nil {
+		return fmt.Errorf("parse log level %q: %w", level, err)
+	}
+	l.level.SetLevel(lvl.Level())
+	return nil
+}
+
+func (l *Logger) Level() string {
+	return l.level.String()
 }
 
 func (l *Logger) Sync() error {
@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"github.com/kayumovtd/url-shortener/internal/repository"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CacheCollector отдаёт статистику кэша ссылок на момент сбора
+type CacheCollector struct {
+	stats func() repository.CacheStats
+
+	requests  *prometheus.Desc
+	evictions *prometheus.Desc
+	entries   *prometheus.Desc
+}
+
+func NewCacheCollector(stats func() repository.CacheStats) *CacheCollector {
+	name := func(n string) string { return prometheus.BuildFQName(namespace, "url_cache", n) }
+	return &CacheCollector{
+		stats: stats,
+		requests: prometheus.NewDesc(name("requests_total"),
+			"URL cache lookups by result: hit, negative_hit or miss.", []string{"result"}, nil),
+		evictions: prometheus.NewDesc(name("evictions_total"),
+			"URLs evicted from the cache to stay within its size.", nil, nil),
+		entries: prometheus.NewDesc(name("entries"),
+			"URLs currently in the cache, including negative entries.", nil, nil),
+	}
+}
+
+func (c *CacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.requests
+	ch <- c.evictions
+	ch <- c.entries
+}
+
+func (c *CacheCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stats()
+	ch <- prometheus.MustNewConstMetric(c.requests, prometheus.CounterValue, float64(s.Hits), "hit")
+	ch <- prometheus.MustNewConstMetric(c.requests, prometheus.CounterValue, float64(s.NegativeHits), "negative_hit")
+	ch <- prometheus.MustNewConstMetric(c.requests, prometheus.CounterValue, float64(s.Misses), "miss")
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(s.Evictions))
+	ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(s.Entries))
+}
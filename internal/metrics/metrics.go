@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "shortener"
+
+// Metrics собирает метрики приложения в собственный реестр, чтобы в /metrics
+// не попадало ничего, что зарегистрировали в глобальном реестре библиотеки.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	urlEvents *prometheus.CounterVec
+
+	deleteFlushSize     prometheus.Histogram
+	deleteFlushFailures prometheus.Counter
+
+	storeDuration *prometheus.HistogramVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route pattern, method and status.",
+		}, []string{"route", "method", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route pattern and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+
+		urlEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "url_events_total",
+			Help:      "Shortened, redirected, conflicting and deleted URLs.",
+		}, []string{"event"}),
+
+		deleteFlushSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "batch_deleter",
+			Name:      "flush_size",
+			Help:      "Number of URL ids sent to the store per flush.",
+			Buckets:   prometheus.ExponentialBuckets(1, 4, 6),
+		}),
+		deleteFlushFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "batch_deleter",
+			Name:      "failures_total",
+			Help:      "Failed store calls while flushing deletions.",
+		}),
+
+		storeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "store",
+			Name:      "operation_duration_seconds",
+			Help:      "Store operation latency by backend and operation.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"backend", "operation"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.urlEvents,
+		m.deleteFlushSize,
+		m.deleteFlushFailures,
+		m.storeDuration,
+	)
+
+	return m
+}
+
+// Register добавляет сборщики, которые зависят от собранного приложения: очередь удалений, пул БД
+func (m *Metrics) Register(cs ...prometheus.Collector) error {
+	for _, c := range cs {
+		if err := m.registry.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RegisterGaugeFunc — метрика, значение которой читается в момент сбора
+func (m *Metrics) RegisterGaugeFunc(subsystem, name, help string, value func() float64) error {
+	return m.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+	}, value))
+}
+
+// Handler отдаёт метрики в текстовом формате Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+func (m *Metrics) ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	m.httpRequests.WithLabelValues(route, method, strconv.Itoa(status)).Inc()
+	m.httpDuration.WithLabelValues(route, method).Observe(duration.Seconds())
+}
+
+func (m *Metrics) CountURLEvent(event string, n int) {
+	m.urlEvents.WithLabelValues(event).Add(float64(n))
+}
+
+func (m *Metrics) ObserveDeleteFlush(size, failures int) {
+	m.deleteFlushSize.Observe(float64(size))
+	m.deleteFlushFailures.Add(float64(failures))
+}
+
+func (m *Metrics) observeStore(backend, operation string, start time.Time) {
+	m.storeDuration.WithLabelValues(backend, operation).Observe(time.Since(start).Seconds())
+}
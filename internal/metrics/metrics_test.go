@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/kayumovtd/url-shortener/internal/logger"
+	"github.com/kayumovtd/url-shortener/internal/middleware"
+	"github.com/kayumovtd/url-shortener/internal/repository"
+	"github.com/kayumovtd/url-shortener/internal/service"
+)
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(w.Result().Body)
+	return string(body)
+}
+
+func TestHTTPMetricsUseRoutePattern(t *testing.T) {
+	m := New()
+
+	r := chi.NewRouter()
+	r.Use(middleware.MetricsMiddleware(m))
+	r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTemporaryRedirect)
+	})
+
+	for _, path := range []string{"/abc", "/def", "/ghi/jkl"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	out := scrape(t, m)
+	for _, want := range []string{
+		`shortener_http_requests_total{method="GET",route="/{id}",status="307"} 2`,
+		`shortener_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`shortener_http_request_duration_seconds_count{method="GET",route="/{id}"} 2`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics do not contain %s", want)
+		}
+	}
+}
+
+func TestServiceAndStoreMetrics(t *testing.T) {
+	m := New()
+	store := InstrumentStore(repository.NewInMemoryStore(), "memory", m)
+
+	svc := service.NewShortenerService(store, "http://localhost", nil)
+	svc.SetMetrics(m)
+
+	ctx := context.Background()
+	if _, err := svc.Shorten(ctx, "https://example.com", "user"); err != nil {
+		t.Fatalf("shorten failed: %v", err)
+	}
+	if _, err := svc.Shorten(ctx, "https://example.com", "user"); err == nil {
+		t.Fatal("expected conflict")
+	}
+
+	out := scrape(t, m)
+	for _, want := range []string{
+		`shortener_url_events_total{event="shorten"} 1`,
+		`shortener_url_events_total{event="conflict"} 1`,
+		`shortener_store_operation_duration_seconds_count{backend="memory",operation="SaveURL"} 2`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics do not contain %s", want)
+		}
+	}
+}
+
+func TestBatchDeleterMetrics(t *testing.T) {
+	m := New()
+	store := repository.NewInMemoryStore()
+	store.SaveURL(context.Background(), "abc", "https://example.com", "user")
+
+	// Метрики передаются при создании, пока горутины сброса ещё не запущены
+	bd := service.NewBatchDeleter(store, logger.NewNoOp(), service.WithDeleterMetrics(m))
+	if err := bd.Enqueue(context.Background(), "user", []string{"abc"}); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+	bd.Close()
+
+	out := scrape(t, m)
+	for _, want := range []string{
+		`shortener_batch_deleter_flush_size_count 1`,
+		`shortener_batch_deleter_failures_total 0`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics do not contain %s", want)
+		}
+	}
+}
+
+func TestCacheMetrics(t *testing.T) {
+	m := New()
+	cache := repository.NewCachedStore(repository.NewInMemoryStore(), repository.CacheConfig{Size: 10, NegativeTTL: time.Minute})
+	if err := m.Register(NewCacheCollector(cache.Stats)); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	ctx := context.Background()
+	cache.SaveURL(ctx, "abc", "https://example.com", "user")
+	cache.GetURL(ctx, "abc")
+	cache.GetURL(ctx, "abc")
+	cache.GetURL(ctx, "nope")
+	cache.GetURL(ctx, "nope")
+
+	out := scrape(t, m)
+	for _, want := range []string{
+		`shortener_url_cache_requests_total{result="hit"} 1`,
+		`shortener_url_cache_requests_total{result="negative_hit"} 1`,
+		`shortener_url_cache_requests_total{result="miss"} 2`,
+		`shortener_url_cache_entries 2`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics do not contain %s", want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type poolMetric struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	value     func(*pgxpool.Stat) float64
+}
+
+// PoolCollector снимает pgxpool.Stat на каждый сбор, своего состояния не держит
+type PoolCollector struct {
+	stat    func() *pgxpool.Stat
+	metrics []poolMetric
+}
+
+func NewPoolCollector(stat func() *pgxpool.Stat) *PoolCollector {
+	gauge := func(name, help string, value func(*pgxpool.Stat) float64) poolMetric {
+		return poolMetric{
+			desc:      prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil),
+			valueType: prometheus.GaugeValue,
+			value:     value,
+		}
+	}
+	counter := func(name, help string, value func(*pgxpool.Stat) float64) poolMetric {
+		m := gauge(name, help, value)
+		m.valueType = prometheus.CounterValue
+		return m
+	}
+
+	return &PoolCollector{
+		stat: stat,
+		metrics: []poolMetric{
+			gauge("acquired_conns", "Connections currently in use.",
+				func(s *pgxpool.Stat) float64 { return float64(s.AcquiredConns()) }),
+			gauge("idle_conns", "Idle connections in the pool.",
+				func(s *pgxpool.Stat) float64 { return float64(s.IdleConns()) }),
+			gauge("constructing_conns", "Connections being established.",
+				func(s *pgxpool.Stat) float64 { return float64(s.ConstructingConns()) }),
+			gauge("total_conns", "All connections in the pool.",
+				func(s *pgxpool.Stat) float64 { return float64(s.TotalConns()) }),
+			gauge("max_conns", "Maximum pool size.",
+				func(s *pgxpool.Stat) float64 { return float64(s.MaxConns()) }),
+			counter("acquires_total", "Successful connection acquires.",
+				func(s *pgxpool.Stat) float64 { return float64(s.AcquireCount()) }),
+			counter("acquire_duration_seconds_total", "Total time spent acquiring connections.",
+				func(s *pgxpool.Stat) float64 { return s.AcquireDuration().Seconds() }),
+			counter("canceled_acquires_total", "Acquires canceled by context.",
+				func(s *pgxpool.Stat) float64 { return float64(s.CanceledAcquireCount()) }),
+			counter("empty_acquires_total", "Acquires that had to wait for a connection.",
+				func(s *pgxpool.Stat) float64 { return float64(s.EmptyAcquireCount()) }),
+			counter("new_conns_total", "Connections opened.",
+				func(s *pgxpool.Stat) float64 { return float64(s.NewConnsCount()) }),
+			counter("max_lifetime_destroys_total", "Connections closed for exceeding max lifetime.",
+				func(s *pgxpool.Stat) float64 { return float64(s.MaxLifetimeDestroyCount()) }),
+			counter("max_idle_destroys_total", "Connections closed for exceeding max idle time.",
+				func(s *pgxpool.Stat) float64 { return float64(s.MaxIdleDestroyCount()) }),
+		},
+	}
+}
+
+func (c *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range c.metrics {
+		ch <- m.desc
+	}
+}
+
+func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.stat()
+	for _, m := range c.metrics {
+		ch <- prometheus.MustNewConstMetric(m.desc, m.valueType, m.value(stat))
+	}
+}
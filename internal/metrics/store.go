@@ -0,0 +1,170 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"github.com/kayumovtd/url-shortener/internal/repository"
+)
+
+// instrumentedStore меряет время каждой операции хранилища с меткой бэкенда
+type instrumentedStore struct {
+	repository.Store
+	backend string
+	m       *Metrics
+}
+
+func InstrumentStore(store repository.Store, backend string, m *Metrics) repository.Store {
+	return &instrumentedStore{Store: store, backend: backend, m: m}
+}
+
+func (s *instrumentedStore) SaveURL(ctx context.Context, shortURL, originalURL string, userID string) error {
+	defer s.m.observeStore(s.backend, "SaveURL", time.Now())
+	return s.Store.SaveURL(ctx, shortURL, originalURL, userID)
+}
+
+func (s *instrumentedStore) SaveURLs(ctx context.Context, urls map[string]string, userID string) error {
+	defer s.m.observeStore(s.backend, "SaveURLs", time.Now())
+	return s.Store.SaveURLs(ctx, urls, userID)
+}
+
+func (s *instrumentedStore) GetURL(ctx context.Context, shortURL string) (model.URLRecord, error) {
+	defer s.m.observeStore(s.backend, "GetURL", time.Now())
+	return s.Store.GetURL(ctx, shortURL)
+}
+
+func (s *instrumentedStore) GetUserURLs(ctx context.Context, userID string) ([]model.URLRecord, error) {
+	defer s.m.observeStore(s.backend, "GetUserURLs", time.Now())
+	return s.Store.GetUserURLs(ctx, userID)
+}
+
+func (s *instrumentedStore) MarkURLsDeleted(ctx context.Context, userID string, shortURLs []string) error {
+	defer s.m.observeStore(s.backend, "MarkURLsDeleted", time.Now())
+	return s.Store.MarkURLsDeleted(ctx, userID, shortURLs)
+}
+
+func (s *instrumentedStore) SaveAPIKey(ctx context.Context, key model.APIKey) error {
+	defer s.m.observeStore(s.backend, "SaveAPIKey", time.Now())
+	return s.Store.SaveAPIKey(ctx, key)
+}
+
+func (s *instrumentedStore) GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error) {
+	defer s.m.observeStore(s.backend, "GetAPIKeyByHash", time.Now())
+	return s.Store.GetAPIKeyByHash(ctx, hash)
+}
+
+func (s *instrumentedStore) GetUserAPIKeys(ctx context.Context, userID string) ([]model.APIKey, error) {
+	defer s.m.observeStore(s.backend, "GetUserAPIKeys", time.Now())
+	return s.Store.GetUserAPIKeys(ctx, userID)
+}
+
+func (s *instrumentedStore) RevokeAPIKey(ctx context.Context, userID, keyID string) error {
+	defer s.m.observeStore(s.backend, "RevokeAPIKey", time.Now())
+	return s.Store.RevokeAPIKey(ctx, userID, keyID)
+}
+
+func (s *instrumentedStore) SaveUser(ctx context.Context, user model.User) error {
+	defer s.m.observeStore(s.backend, "SaveUser", time.Now())
+	return s.Store.SaveUser(ctx, user)
+}
+
+func (s *instrumentedStore) GetUser(ctx context.Context, userID string) (model.User, error) {
+	defer s.m.observeStore(s.backend, "GetUser", time.Now())
+	return s.Store.GetUser(ctx, userID)
+}
+
+func (s *instrumentedStore) GetUserByLogin(ctx context.Context, login string) (model.User, error) {
+	defer s.m.observeStore(s.backend, "GetUserByLogin", time.Now())
+	return s.Store.GetUserByLogin(ctx, login)
+}
+
+func (s *instrumentedStore) MergeUserURLs(ctx context.Context, fromUserID, toUserID string) error {
+	defer s.m.observeStore(s.backend, "MergeUserURLs", time.Now())
+	return s.Store.MergeUserURLs(ctx, fromUserID, toUserID)
+}
+
+func (s *instrumentedStore) MergeUserAPIKeys(ctx context.Context, fromUserID, toUserID string) error {
+	defer s.m.observeStore(s.backend, "MergeUserAPIKeys", time.Now())
+	return s.Store.MergeUserAPIKeys(ctx, fromUserID, toUserID)
+}
+
+func (s *instrumentedStore) SaveWorkspace(ctx context.Context, ws model.Workspace, ownerID string) error {
+	defer s.m.observeStore(s.backend, "SaveWorkspace", time.Now())
+	return s.Store.SaveWorkspace(ctx, ws, ownerID)
+}
+
+func (s *instrumentedStore) GetWorkspace(ctx context.Context, workspaceID string) (model.Workspace, error) {
+	defer s.m.observeStore(s.backend, "GetWorkspace", time.Now())
+	return s.Store.GetWorkspace(ctx, workspaceID)
+}
+
+func (s *instrumentedStore) GetUserWorkspaces(ctx context.Context, userID string) ([]model.UserWorkspace, error) {
+	defer s.m.observeStore(s.backend, "GetUserWorkspaces", time.Now())
+	return s.Store.GetUserWorkspaces(ctx, userID)
+}
+
+func (s *instrumentedStore) GetWorkspaceMember(ctx context.Context, workspaceID, userID string) (model.WorkspaceMember, error) {
+	defer s.m.observeStore(s.backend, "GetWorkspaceMember", time.Now())
+	return s.Store.GetWorkspaceMember(ctx, workspaceID, userID)
+}
+
+func (s *instrumentedStore) GetWorkspaceMembers(ctx context.Context, workspaceID string) ([]model.WorkspaceMember, error) {
+	defer s.m.observeStore(s.backend, "GetWorkspaceMembers", time.Now())
+	return s.Store.GetWorkspaceMembers(ctx, workspaceID)
+}
+
+func (s *instrumentedStore) SaveWorkspaceMember(ctx context.Context, member model.WorkspaceMember) error {
+	defer s.m.observeStore(s.backend, "SaveWorkspaceMember", time.Now())
+	return s.Store.SaveWorkspaceMember(ctx, member)
+}
+
+func (s *instrumentedStore) DeleteWorkspaceMember(ctx context.Context, workspaceID, userID string) error {
+	defer s.m.observeStore(s.backend, "DeleteWorkspaceMember", time.Now())
+	return s.Store.DeleteWorkspaceMember(ctx, workspaceID, userID)
+}
+
+func (s *instrumentedStore) MoveURLsToWorkspace(ctx context.Context, userID, workspaceID string, shortURLs []string) error {
+	defer s.m.observeStore(s.backend, "MoveURLsToWorkspace", time.Now())
+	return s.Store.MoveURLsToWorkspace(ctx, userID, workspaceID, shortURLs)
+}
+
+func (s *instrumentedStore) GetWorkspaceURLs(ctx context.Context, workspaceID string) ([]model.URLRecord, error) {
+	defer s.m.observeStore(s.backend, "GetWorkspaceURLs", time.Now())
+	return s.Store.GetWorkspaceURLs(ctx, workspaceID)
+}
+
+func (s *instrumentedStore) GetStats(ctx context.Context) (model.Stats, error) {
+	defer s.m.observeStore(s.backend, "GetStats", time.Now())
+	return s.Store.GetStats(ctx)
+}
+
+func (s *instrumentedStore) SetOriginalURL(ctx context.Context, shortURL, originalURL string) error {
+	defer s.m.observeStore(s.backend, "SetOriginalURL", time.Now())
+	return s.Store.SetOriginalURL(ctx, shortURL, originalURL)
+}
+
+func (s *instrumentedStore) SetURLDisabled(ctx context.Context, shortURL string, disabled bool) error {
+	defer s.m.observeStore(s.backend, "SetURLDisabled", time.Now())
+	return s.Store.SetURLDisabled(ctx, shortURL, disabled)
+}
+
+func (s *instrumentedStore) SaveAbuseReport(ctx context.Context, report model.AbuseReport) error {
+	defer s.m.observeStore(s.backend, "SaveAbuseReport", time.Now())
+	return s.Store.SaveAbuseReport(ctx, report)
+}
+
+func (s *instrumentedStore) GetOpenAbuseReports(ctx context.Context) ([]model.AbuseReport, error) {
+	defer s.m.observeStore(s.backend, "GetOpenAbuseReports", time.Now())
+	return s.Store.GetOpenAbuseReports(ctx)
+}
+
+func (s *instrumentedStore) SetURLModeration(ctx context.Context, shortURL string, state model.ModerationState, reason string) error {
+	defer s.m.observeStore(s.backend, "SetURLModeration", time.Now())
+	return s.Store.SetURLModeration(ctx, shortURL, state, reason)
+}
+
+func (s *instrumentedStore) Ping(ctx context.Context) error {
+	defer s.m.observeStore(s.backend, "Ping", time.Now())
+	return s.Store.Ping(ctx)
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+	"net/netip"
+	"slices"
+
+	"github.com/kayumovtd/url-shortener/internal/service"
+	"github.com/kayumovtd/url-shortener/internal/utils"
+)
+
+// AdminAccessMiddleware пускает к админскому API запросы из trustedSubnet
+// и запросы пользователей с ролью администратора (adminIDs). Остальным — 403.
+// Невалидный trustedSubnet означает, что доступ по сети выключен.
+// Адрес берётся из RemoteAddr, поэтому за прокси нужен RealIPMiddleware.
+func AdminAccessMiddleware(trustedSubnet netip.Prefix, adminIDs []string, up service.UserProvider) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if trustedSubnet.IsValid() {
+				if ip, ok := parseIP(clientIP(r)); ok && trustedSubnet.Contains(ip) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			if userID, ok := up.GetUserID(r.Context()); ok && userID != "" && slices.Contains(adminIDs, userID) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			utils.WritePlainText(w, http.StatusForbidden, http.StatusText(http.StatusForbidden))
+		})
+	}
+}
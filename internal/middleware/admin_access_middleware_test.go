@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/kayumovtd/url-shortener/internal/service/mocks"
+)
+
+func TestAdminAccessMiddleware(t *testing.T) {
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	subnet := netip.MustParsePrefix("10.0.0.0/8")
+	proxies := []netip.Prefix{netip.MustParsePrefix("192.168.1.1/32")}
+
+	tests := []struct {
+		name       string
+		subnet     netip.Prefix
+		admins     []string
+		userID     string
+		remoteAddr string
+		realIP     string
+		wantStatus int
+	}{
+		{"trusted_subnet", subnet, nil, "", "10.1.2.3:5555", "", http.StatusOK},
+		{"outside_subnet", subnet, nil, "", "172.16.0.1:5555", "", http.StatusForbidden},
+		{"real_ip_from_trusted_proxy", subnet, nil, "", "192.168.1.1:5555", "10.1.2.3", http.StatusOK},
+		{"real_ip_from_untrusted_peer", subnet, nil, "", "172.16.0.1:5555", "10.1.2.3", http.StatusForbidden},
+		{"admin_user", subnet, []string{testUserID}, testUserID, "172.16.0.1:5555", "", http.StatusOK},
+		{"regular_user", subnet, []string{"admin"}, testUserID, "172.16.0.1:5555", "", http.StatusForbidden},
+		{"nothing_configured", netip.Prefix{}, nil, testUserID, "10.1.2.3:5555", "", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			up := mocks.NewMockUserProvider(tt.userID, tt.userID != "")
+			h := RealIPMiddleware(proxies)(AdminAccessMiddleware(tt.subnet, tt.admins, up)(okHandler))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/internal/stats", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRealIPMiddlewareForwardedFor(t *testing.T) {
+	proxies := []netip.Prefix{netip.MustParsePrefix("192.168.0.0/16")}
+
+	var got string
+	h := RealIPMiddleware(proxies)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = clientIP(r)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.168.0.2:5555"
+	// первый адрес клиент мог подставить сам, доверяем только добавленному нашим прокси
+	req.Header.Set("X-Forwarded-For", "10.0.0.1, 203.0.113.7, 192.168.0.1")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got != "203.0.113.7" {
+		t.Errorf("client ip = %q, want %q", got, "203.0.113.7")
+	}
+}
@@ -1,22 +1,50 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/kayumovtd/url-shortener/internal/logger"
 	"github.com/kayumovtd/url-shortener/internal/service"
+	"github.com/kayumovtd/url-shortener/internal/utils"
+	"go.uber.org/zap"
 )
 
-const cookieName = "auth_token"
+const (
+	cookieName   = "auth_token"
+	apiKeyHeader = "X-API-Key"
+)
 
-func AuthMiddleware(auth *service.AuthService) func(http.Handler) http.Handler {
+func AuthMiddleware(auth *service.AuthService, keys *service.APIKeyService) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Ключ API проверяем раньше куки: если клиент его прислал, куку ему не выдаём
+			if apiKey, ok := apiKeyFromRequest(r); ok {
+				userID, err := keys.Resolve(r.Context(), apiKey)
+				if errors.Is(err, service.ErrInvalidAPIKey) {
+					utils.WritePlainText(w, http.StatusUnauthorized, "invalid api key")
+					return
+				}
+				if err != nil {
+					utils.WritePlainText(w, http.StatusInternalServerError, "failed to check api key")
+					return
+				}
+
+				ctx := auth.WithUserID(r.Context(), userID)
+				logger.AddFields(ctx, zap.String("user_id", userID))
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
 			var userID string
+			var reissue, issued bool
 
 			cookie, err := r.Cookie(cookieName)
 			if err == nil {
-				userID, err = auth.ParseAndVerify(cookie.Value)
+				userID, reissue, err = auth.ParseAndVerify(cookie.Value)
 			}
 
 			if err != nil {
@@ -29,19 +57,99 @@ func AuthMiddleware(auth *service.AuthService) func(http.Handler) http.Handler {
 					http.Error(w, "failed to generate token", http.StatusInternalServerError)
 					return
 				}
-
-				http.SetCookie(w, &http.Cookie{
-					Name:     cookieName,
-					Value:    token,
-					Path:     "/",
-					HttpOnly: true,
-					Secure:   false, // true — если HTTPS
-					Expires:  time.Now().Add(365 * 24 * time.Hour),
-				})
+				SetAuthCookie(w, r, token)
+				issued = true
+			} else if reissue {
+				// Кука подписана старым ключом — тихо переподписываем активным, пользователь остаётся тем же
+				token, err := auth.GenerateToken(userID)
+				if err != nil {
+					http.Error(w, "failed to generate token", http.StatusInternalServerError)
+					return
+				}
+				SetAuthCookie(w, r, token)
 			}
 
 			ctx := auth.WithUserID(r.Context(), userID)
+			if issued {
+				ctx = context.WithValue(ctx, issuedUserKey{}, true)
+			}
+			logger.AddFields(ctx, zap.String("user_id", userID))
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+type issuedUserKey struct{}
+
+// isIssuedUser — пользователь заведён этим же запросом: клиент не прислал ни валидной куки,
+// ни ключа API, и на повторе получит другой ID
+func isIssuedUser(ctx context.Context) bool {
+	issued, _ := ctx.Value(issuedUserKey{}).(bool)
+	return issued
+}
+
+func SetAuthCookie(w http.ResponseWriter, r *http.Request, token string) {
+	SetCookie(w, r, &http.Cookie{
+		Name:     cookieName,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Expires:  time.Now().Add(365 * 24 * time.Hour),
+	})
+}
+
+// ClearAuthCookie удаляет куку, на следующем запросе пользователь получит новую анонимную.
+func ClearAuthCookie(w http.ResponseWriter, r *http.Request) {
+	SetCookie(w, r, &http.Cookie{
+		Name:     cookieName,
+		Value:    "",
+		Path:     "/",
+		HttpOnly: true,
+		MaxAge:   -1,
+	})
+}
+
+// SetCookie выставляет куку с Secure и SameSite=Lax, если клиент пришёл по HTTPS:
+// напрямую по TLS, через доверенный прокси или на сервис с https-адресом.
+// Lax, а не Strict: куки должны приходить после редиректа от OpenID-провайдера.
+func SetCookie(w http.ResponseWriter, r *http.Request, c *http.Cookie) {
+	if isHTTPS(r) {
+		c.Secure = true
+		if c.SameSite == 0 {
+			c.SameSite = http.SameSiteLaxMode
+		}
+	}
+	http.SetCookie(w, c)
+}
+
+type httpsKey struct{}
+
+// SecureCookiesMiddleware считает все запросы пришедшими по HTTPS. Нужен, когда публичный
+// адрес сервиса https, а TLS снимает балансировщик перед ним.
+func SecureCookiesMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, markHTTPS(r))
+	})
+}
+
+func markHTTPS(r *http.Request) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), httpsKey{}, true))
+}
+
+func isHTTPS(r *http.Request) bool {
+	https, _ := r.Context().Value(httpsKey{}).(bool)
+	return r.TLS != nil || https
+}
+
+func apiKeyFromRequest(r *http.Request) (string, bool) {
+	if v := r.Header.Get(apiKeyHeader); v != "" {
+		return v, true
+	}
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") && token != "" {
+		return strings.TrimSpace(token), true
+	}
+
+	return "", false
+}
@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/kayumovtd/url-shortener/internal/repository"
+	"github.com/kayumovtd/url-shortener/internal/service"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	auth := service.NewAuthService("secret")
+	keys := service.NewAPIKeyService(repository.NewMockStore())
+
+	plain, _, err := keys.Create(t.Context(), testUserID, "ci")
+	if err != nil {
+		t.Fatalf("failed to create api key: %v", err)
+	}
+
+	var gotUserID string
+	h := AuthMiddleware(auth, keys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUserID, _ = auth.GetUserID(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		header     string
+		value      string
+		wantStatus int
+		wantUserID string
+		wantCookie bool
+	}{
+		{"bearer", "Authorization", "Bearer " + plain, http.StatusOK, testUserID, false},
+		{"x_api_key", apiKeyHeader, plain, http.StatusOK, testUserID, false},
+		{"invalid_key", apiKeyHeader, "sk_invalid", http.StatusUnauthorized, "", false},
+		{"no_credentials", "", "", http.StatusOK, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotUserID = ""
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+
+			h.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", res.StatusCode, tt.wantStatus)
+			}
+			if tt.wantUserID != "" && gotUserID != tt.wantUserID {
+				t.Errorf("user id = %q, want %q", gotUserID, tt.wantUserID)
+			}
+			if hasCookie := len(res.Cookies()) > 0; hasCookie != tt.wantCookie {
+				t.Errorf("cookie issued = %v, want %v", hasCookie, tt.wantCookie)
+			}
+		})
+	}
+
+	t.Run("reissue_old_key_cookie", func(t *testing.T) {
+		oldToken, err := auth.GenerateToken(testUserID)
+		if err != nil {
+			t.Fatalf("failed to generate token: %v", err)
+		}
+
+		ring, err := service.ParseKeyRing("k2:new-secret", "", "secret")
+		if err != nil {
+			t.Fatalf("failed to build key ring: %v", err)
+		}
+		rotated := service.NewAuthServiceWithKeyRing(ring)
+		h := AuthMiddleware(rotated, keys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotUserID, _ = rotated.GetUserID(r.Context())
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: cookieName, Value: oldToken})
+		w := httptest.NewRecorder()
+
+		h.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		if gotUserID != testUserID {
+			t.Errorf("user id = %q, want %q", gotUserID, testUserID)
+		}
+
+		cookies := res.Cookies()
+		if len(cookies) != 1 {
+			t.Fatalf("expected reissued cookie")
+		}
+		userID, reissue, err := rotated.ParseAndVerify(cookies[0].Value)
+		if err != nil || reissue || userID != testUserID {
+			t.Errorf("reissued token: user id = %q, reissue = %v, err = %v", userID, reissue, err)
+		}
+	})
+}
+
+func TestSetAuthCookieTLS(t *testing.T) {
+	proxies := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name          string
+		tls           bool
+		remoteAddr    string
+		proto         string
+		secureCookies bool
+		wantSecure    bool
+		wantSameSite  http.SameSite
+	}{
+		{name: "plain_http", wantSecure: false, wantSameSite: 0},
+		{name: "tls", tls: true, wantSecure: true, wantSameSite: http.SameSiteLaxMode},
+		{name: "trusted_proxy_https", remoteAddr: "10.0.0.1:1234", proto: "https", wantSecure: true, wantSameSite: http.SameSiteLaxMode},
+		{name: "trusted_proxy_http", remoteAddr: "10.0.0.1:1234", proto: "http", wantSecure: false, wantSameSite: 0},
+		{name: "untrusted_proxy_https", remoteAddr: "192.0.2.1:1234", proto: "https", wantSecure: false, wantSameSite: 0},
+		{name: "https_base_url", secureCookies: true, wantSecure: true, wantSameSite: http.SameSiteLaxMode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				SetAuthCookie(w, r, "token")
+			})
+			if tt.secureCookies {
+				h = SecureCookiesMiddleware(h)
+			}
+			h = RealIPMiddleware(proxies)(h)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			if tt.remoteAddr != "" {
+				req.RemoteAddr = tt.remoteAddr
+			}
+			if tt.proto != "" {
+				req.Header.Set("X-Forwarded-Proto", tt.proto)
+			}
+			w := httptest.NewRecorder()
+
+			h.ServeHTTP(w, req)
+
+			cookies := w.Result().Cookies()
+			if len(cookies) != 1 {
+				t.Fatalf("got %d cookies, want 1", len(cookies))
+			}
+			if cookies[0].Secure != tt.wantSecure {
+				t.Errorf("Secure = %v, want %v", cookies[0].Secure, tt.wantSecure)
+			}
+			if cookies[0].SameSite != tt.wantSameSite {
+				t.Errorf("SameSite = %v, want %v", cookies[0].SameSite, tt.wantSameSite)
+			}
+		})
+	}
+}
@@ -0,0 +1,444 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	encodingGzip     = "gzip"
+	encodingDeflate  = "deflate"
+	encodingBrotli   = "br"
+	encodingZstd     = "zstd"
+	encodingIdentity = "identity"
+
+	// DefaultCompressMinSize — ответы меньше этого не сжимаем: заголовки и кадры кодека съедят выигрыш
+	DefaultCompressMinSize = 1024
+)
+
+// Порядок — предпочтение сервера, когда клиент даёт кодировкам одинаковый q
+var supportedEncodings = []string{encodingBrotli, encodingZstd, encodingGzip, encodingDeflate}
+
+var supportedContentTypes = []string{
+	"application/json",
+	"text/html",
+}
+
+var errUnsupportedEncoding = errors.New("unsupported content encoding")
+
+func isSupportedContentType(ct string) bool {
+	for _, t := range supportedContentTypes {
+		if strings.HasPrefix(ct, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateEncoding выбирает кодировку ответа по Accept-Encoding с учётом q-values.
+// identityOK == false — клиент запретил ответ без сжатия (identity;q=0 или *;q=0).
+func negotiateEncoding(header string) (encoding string, identityOK bool) {
+	if strings.TrimSpace(header) == "" {
+		return "", true
+	}
+
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if name == "x-gzip" {
+			name = encodingGzip
+		}
+
+		q := 1.0
+		for _, p := range strings.Split(params, ";") {
+			k, v, ok := strings.Cut(strings.TrimSpace(p), "=")
+			if !ok || strings.ToLower(strings.TrimSpace(k)) != "q" {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil || parsed < 0 || parsed > 1 {
+				parsed = 0
+			}
+			q = parsed
+		}
+		weights[name] = q
+	}
+
+	star, hasStar := weights["*"]
+	weight := func(enc string) float64 {
+		if q, ok := weights[enc]; ok {
+			return q
+		}
+		if hasStar {
+			return star
+		}
+		return 0
+	}
+
+	identityOK = true
+	if q, ok := weights[encodingIdentity]; ok {
+		identityOK = q > 0
+	} else if hasStar {
+		identityOK = star > 0
+	}
+
+	best := 0.0
+	for _, enc := range supportedEncodings {
+		if q := weight(enc); q > best {
+			encoding, best = enc, q
+		}
+	}
+	return encoding, identityOK
+}
+
+// encoder и decoder переиспользуются через Reset: аллокация кодека обходится
+// в десятки и сотни килобайт, а нужен он лишь малой части запросов.
+type encoder interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+type decoder interface {
+	io.Reader
+	Reset(r io.Reader) error
+}
+
+// zlibReader даёт zlib-читателю тот же Reset, что у остальных декодеров
+type zlibReader struct {
+	io.ReadCloser
+}
+
+func (z zlibReader) Reset(r io.Reader) error {
+	return z.ReadCloser.(zlib.Resetter).Reset(r, nil)
+}
+
+var (
+	encoderPools = map[string]*sync.Pool{
+		encodingGzip:    {},
+		encodingDeflate: {},
+		encodingBrotli:  {},
+		encodingZstd:    {},
+	}
+	decoderPools = map[string]*sync.Pool{
+		encodingGzip:    {},
+		encodingDeflate: {},
+		encodingBrotli:  {},
+		encodingZstd:    {},
+	}
+)
+
+func newEncoder(encoding string, w io.Writer) (encoder, error) {
+	switch encoding {
+	case encodingGzip:
+		return gzip.NewWriter(w), nil
+	case encodingDeflate:
+		// В HTTP "deflate" — это поток zlib, а не голый deflate
+		return zlib.NewWriter(w), nil
+	case encodingBrotli:
+		return brotli.NewWriter(w), nil
+	case encodingZstd:
+		return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+	}
+	return nil, fmt.Errorf("%w: %q", errUnsupportedEncoding, encoding)
+}
+
+func newDecoder(encoding string, r io.Reader) (decoder, error) {
+	switch encoding {
+	case encodingGzip:
+		return gzip.NewReader(r)
+	case encodingDeflate:
+		zr, err := zlib.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return zlibReader{zr}, nil
+	case encodingBrotli:
+		return brotli.NewReader(r), nil
+	case encodingZstd:
+		// С одним потоком декодер работает синхронно и не держит горутин — его можно хранить в пуле без Close
+		return zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	}
+	return nil, fmt.Errorf("%w: %q", errUnsupportedEncoding, encoding)
+}
+
+func acquireEncoder(encoding string, w io.Writer) (encoder, error) {
+	if pool, ok := encoderPools[encoding]; ok {
+		if enc, ok := pool.Get().(encoder); ok {
+			enc.Reset(w)
+			return enc, nil
+		}
+	}
+	return newEncoder(encoding, w)
+}
+
+// releaseEncoder возвращает кодек в пул, вызывать только после Close
+func releaseEncoder(encoding string, enc encoder) {
+	encoderPools[encoding].Put(enc)
+}
+
+func acquireDecoder(encoding string, r io.Reader) (decoder, error) {
+	pool, ok := decoderPools[encoding]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", errUnsupportedEncoding, encoding)
+	}
+	if dec, ok := pool.Get().(decoder); ok {
+		if err := dec.Reset(r); err != nil {
+			pool.Put(dec)
+			return nil, err
+		}
+		return dec, nil
+	}
+	return newDecoder(encoding, r)
+}
+
+func releaseDecoder(encoding string, dec decoder) {
+	decoderPools[encoding].Put(dec)
+}
+
+// Буфер крупнее этого в пул не возвращаем, чтобы один большой ответ не держал память навсегда
+const maxPooledBufSize = 64 << 10
+
+var compressWriterPool = sync.Pool{
+	New: func() any { return new(compressWriter) },
+}
+
+// compressWriter копит начало ответа, пока не станет ясно, стоит ли его сжимать:
+// до этого момента статус тоже не отправляется. Кодек берётся из пула только тогда,
+// когда ответ действительно будет сжат, — редиректы и мелкие ответы обходятся без него.
+type compressWriter struct {
+	http.ResponseWriter
+
+	encoding   string
+	identityOK bool
+	minSize    int
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     encoder
+}
+
+func (c *compressWriter) reset(w http.ResponseWriter, encoding string, identityOK bool, minSize int) {
+	buf := c.buf[:0]
+	if cap(buf) > maxPooledBufSize {
+		buf = nil
+	}
+	*c = compressWriter{
+		ResponseWriter: w,
+		encoding:       encoding,
+		identityOK:     identityOK,
+		minSize:        minSize,
+		buf:            buf,
+	}
+}
+
+func (c *compressWriter) WriteHeader(statusCode int) {
+	// Информационные ответы (103 Early Hints) уходят сразу и финальный статус не задают
+	if statusCode < http.StatusOK {
+		c.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	if c.status == 0 {
+		c.status = statusCode
+	}
+}
+
+func (c *compressWriter) Write(p []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	if c.decided {
+		if c.enc != nil {
+			return c.enc.Write(p)
+		}
+		return c.ResponseWriter.Write(p)
+	}
+
+	c.buf = append(c.buf, p...)
+	if len(c.buf) >= c.minSize {
+		if err := c.decide(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (c *compressWriter) shouldCompress() bool {
+	h := c.Header()
+	switch {
+	case c.encoding == "", len(c.buf) == 0:
+		return false
+	case c.status < http.StatusOK || c.status >= http.StatusMultipleChoices || c.status == http.StatusNoContent:
+		return false
+	case h.Get("Content-Encoding") != "" || !isSupportedContentType(h.Get("Content-Type")):
+		return false
+	}
+	// Маленькие ответы сжимаем, только если клиент не принимает их как есть
+	return len(c.buf) >= c.minSize || !c.identityOK
+}
+
+func (c *compressWriter) decide() error {
+	c.decided = true
+
+	if c.shouldCompress() {
+		enc, err := acquireEncoder(c.encoding, c.ResponseWriter)
+		if err != nil {
+			return err
+		}
+		c.enc = enc
+		c.Header().Set("Content-Encoding", c.encoding)
+		c.Header().Del("Content-Length")
+	}
+
+	c.ResponseWriter.WriteHeader(c.status)
+
+	// Буфер остаётся у писателя и вернётся с ним в пул
+	buffered := c.buf
+	c.buf = c.buf[:0]
+	if len(buffered) == 0 {
+		return nil
+	}
+	if c.enc != nil {
+		_, err := c.enc.Write(buffered)
+		return err
+	}
+	_, err := c.ResponseWriter.Write(buffered)
+	return err
+}
+
+func (c *compressWriter) Close() error {
+	if !c.decided {
+		// Обработчик ничего не записал — net/http сам ответит 200
+		if c.status == 0 {
+			return nil
+		}
+		if err := c.decide(); err != nil {
+			return err
+		}
+	}
+	if c.enc == nil {
+		return nil
+	}
+	err := c.enc.Close()
+	releaseEncoder(c.encoding, c.enc)
+	c.enc = nil
+	return err
+}
+
+func (c *compressWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+type pooledDecoder struct {
+	encoding string
+	dec      decoder
+}
+
+// decodedBody возвращает декодеры в пул и закрывает исходное тело запроса.
+// Close идемпотентен: его зовут и обработчик, и middleware, а дважды отданный в пул
+// декодер достался бы двум запросам сразу.
+type decodedBody struct {
+	io.Reader
+	body     io.ReadCloser
+	decoders []pooledDecoder
+	closed   bool
+}
+
+func (d *decodedBody) Close() error {
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+	for _, pd := range d.decoders {
+		releaseDecoder(pd.encoding, pd.dec)
+	}
+	d.decoders = nil
+	d.Reader = nil
+	return d.body.Close()
+}
+
+// decodeBody снимает кодировки в обратном порядке: в Content-Encoding они перечислены в порядке применения
+func decodeBody(body io.ReadCloser, contentEncoding string) (io.ReadCloser, error) {
+	var codings []string
+	for _, c := range strings.Split(contentEncoding, ",") {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if c == "x-gzip" {
+			c = encodingGzip
+		}
+		if c != "" && c != encodingIdentity {
+			codings = append(codings, c)
+		}
+	}
+
+	d := &decodedBody{Reader: body, body: body}
+	for _, c := range slices.Backward(codings) {
+		dec, err := acquireDecoder(c, d.Reader)
+		if err != nil {
+			d.Close()
+			return nil, err
+		}
+		d.Reader = dec
+		d.decoders = append(d.decoders, pooledDecoder{encoding: c, dec: dec})
+	}
+	return d, nil
+}
+
+// CompressMiddleware распаковывает тела запросов (gzip, deflate, br, zstd)
+// и сжимает ответы кодировкой, выбранной по Accept-Encoding.
+func CompressMiddleware(minSize int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ce := r.Header.Get("Content-Encoding"); ce != "" {
+				body, err := decodeBody(r.Body, ce)
+				if errors.Is(err, errUnsupportedEncoding) {
+					w.Header().Set("Accept-Encoding", strings.Join(supportedEncodings, ", "))
+					http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+					return
+				}
+				if err != nil {
+					http.Error(w, "invalid compressed body", http.StatusBadRequest)
+					return
+				}
+				r.Body = body
+				r.ContentLength = -1
+				r.Header.Del("Content-Encoding")
+				r.Header.Del("Content-Length")
+				defer body.Close()
+			}
+
+			// Ответ зависит от Accept-Encoding, даже если в этот раз его не сжали
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding, identityOK := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" && !identityOK {
+				http.Error(w, "no acceptable content encoding", http.StatusNotAcceptable)
+				return
+			}
+
+			cw := compressWriterPool.Get().(*compressWriter)
+			cw.reset(w, encoding, identityOK, minSize)
+			defer func() {
+				cw.Close()
+				cw.reset(nil, "", false, 0)
+				compressWriterPool.Put(cw)
+			}()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func mockHandler(w http.ResponseWriter, r *http.Request) {
+	data, _ := io.ReadAll(r.Body)
+	defer r.Body.Close()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"data":"` + string(data) + `"}`))
+}
+
+func encode(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	enc, err := newEncoder(encoding, &buf)
+	if err != nil {
+		t.Fatalf("encoder failed: %v", err)
+	}
+	if _, err := enc.Write(data); err != nil {
+		t.Fatalf("encode write failed: %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("encode close failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func decode(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+
+	dec, err := newDecoder(encoding, bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decoder failed: %v", err)
+	}
+
+	out, err := io.ReadAll(dec)
+	if err != nil {
+		t.Fatalf("decode read failed: %v", err)
+	}
+	return out
+}
+
+func serve(req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	CompressMiddleware(DefaultCompressMinSize)(http.HandlerFunc(mockHandler)).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCompressMiddleware(t *testing.T) {
+	largeBody := strings.Repeat("hello ", DefaultCompressMinSize)
+	largeResponse := `{"data":"` + largeBody + `"}`
+
+	for _, encoding := range supportedEncodings {
+		t.Run("request_"+encoding, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(encode(t, encoding, []byte(largeBody))))
+			req.Header.Set("Content-Encoding", encoding)
+
+			rec := serve(req)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("unexpected status: got %d, want %d", rec.Code, http.StatusOK)
+			}
+			if got := rec.Header().Get("Content-Encoding"); got != "" {
+				t.Fatalf("unexpected Content-Encoding: %q", got)
+			}
+			if rec.Body.String() != largeResponse {
+				t.Fatalf("unexpected body: %.60s", rec.Body.String())
+			}
+		})
+
+		t.Run("response_"+encoding, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(largeBody))
+			req.Header.Set("Accept-Encoding", encoding)
+
+			rec := serve(req)
+			if got := rec.Header().Get("Content-Encoding"); got != encoding {
+				t.Fatalf("unexpected Content-Encoding: got %q, want %q", got, encoding)
+			}
+			if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Fatalf("unexpected Vary: %q", got)
+			}
+			if data := decode(t, encoding, rec.Body.Bytes()); string(data) != largeResponse {
+				t.Fatalf("unexpected body: %.60s", data)
+			}
+		})
+	}
+
+	t.Run("stacked_request_encodings", func(t *testing.T) {
+		body := encode(t, encodingZstd, encode(t, encodingGzip, []byte(largeBody)))
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		req.Header.Set("Content-Encoding", "gzip, zstd")
+
+		rec := serve(req)
+		if rec.Body.String() != largeResponse {
+			t.Fatalf("unexpected body: %.60s", rec.Body.String())
+		}
+	})
+
+	t.Run("unsupported_request_encoding", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(largeBody))
+		req.Header.Set("Content-Encoding", "compress")
+
+		rec := serve(req)
+		if rec.Code != http.StatusUnsupportedMediaType {
+			t.Fatalf("unexpected status: got %d, want %d", rec.Code, http.StatusUnsupportedMediaType)
+		}
+		if rec.Header().Get("Accept-Encoding") == "" {
+			t.Fatal("expected Accept-Encoding with supported encodings")
+		}
+	})
+
+	t.Run("broken_request_body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("not gzip"))
+		req.Header.Set("Content-Encoding", "gzip")
+
+		rec := serve(req)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("unexpected status: got %d, want %d", rec.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("small_response_not_compressed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"msg":"hello"}`))
+		req.Header.Set("Accept-Encoding", "gzip")
+
+		rec := serve(req)
+		if got := rec.Header().Get("Content-Encoding"); got != "" {
+			t.Fatalf("unexpected Content-Encoding: %q", got)
+		}
+		if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+			t.Fatalf("unexpected Vary: %q", got)
+		}
+		if want := `{"data":"{"msg":"hello"}"}`; rec.Body.String() != want {
+			t.Fatalf("unexpected body:\ngot  %s\nwant %s", rec.Body.String(), want)
+		}
+	})
+
+	t.Run("small_response_identity_refused", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"msg":"hello"}`))
+		req.Header.Set("Accept-Encoding", "gzip, identity;q=0")
+
+		rec := serve(req)
+		if got := rec.Header().Get("Content-Encoding"); got != encodingGzip {
+			t.Fatalf("unexpected Content-Encoding: got %q, want %q", got, encodingGzip)
+		}
+	})
+
+	t.Run("redirect_not_compressed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+		req.Header.Set("Accept-Encoding", "gzip, identity;q=0")
+
+		rec := httptest.NewRecorder()
+		CompressMiddleware(DefaultCompressMinSize)(http.HandlerFunc(redirectHandler)).ServeHTTP(rec, req)
+		if rec.Code != http.StatusTemporaryRedirect {
+			t.Fatalf("unexpected status: got %d, want %d", rec.Code, http.StatusTemporaryRedirect)
+		}
+		if got := rec.Header().Get("Content-Encoding"); got != "" {
+			t.Fatalf("unexpected Content-Encoding: %q", got)
+		}
+	})
+
+	t.Run("pooled_codecs_reused", func(t *testing.T) {
+		// Несколько кругов подряд: кодеки из пула должны давать тот же результат, что и новые
+		for range 3 {
+			for _, encoding := range supportedEncodings {
+				req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(encode(t, encoding, []byte(largeBody))))
+				req.Header.Set("Content-Encoding", encoding)
+				req.Header.Set("Accept-Encoding", encoding)
+
+				rec := serve(req)
+				if data := decode(t, encoding, rec.Body.Bytes()); string(data) != largeResponse {
+					t.Fatalf("%s: unexpected body: %.60s", encoding, data)
+				}
+			}
+		}
+	})
+
+	t.Run("nothing_acceptable", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", "compress, *;q=0")
+
+		rec := serve(req)
+		if rec.Code != http.StatusNotAcceptable {
+			t.Fatalf("unexpected status: got %d, want %d", rec.Code, http.StatusNotAcceptable)
+		}
+	})
+}
+
+func TestDecodedBody_CloseIdempotent(t *testing.T) {
+	body, err := decodeBody(io.NopCloser(bytes.NewReader(encode(t, encodingGzip, []byte("hello")))), encodingGzip)
+	if err != nil {
+		t.Fatalf("decodeBody failed: %v", err)
+	}
+	if err := body.Close(); err != nil {
+		t.Fatalf("first close failed: %v", err)
+	}
+	if err := body.Close(); err != nil {
+		t.Fatalf("second close failed: %v", err)
+	}
+
+	// Декодер вернулся в пул один раз: два следующих запроса получают разные экземпляры
+	a, _ := acquireDecoder(encodingGzip, bytes.NewReader(encode(t, encodingGzip, []byte("a"))))
+	b, _ := acquireDecoder(encodingGzip, bytes.NewReader(encode(t, encodingGzip, []byte("b"))))
+	if a == b {
+		t.Fatal("decoder was released to the pool twice")
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		encoding   string
+		identityOK bool
+	}{
+		{name: "empty", header: "", encoding: "", identityOK: true},
+		{name: "single", header: "gzip", encoding: encodingGzip, identityOK: true},
+		{name: "server_preference", header: "gzip, deflate, br, zstd", encoding: encodingBrotli, identityOK: true},
+		{name: "q_values", header: "br;q=0.5, gzip;q=0.8, zstd;q=0.1", encoding: encodingGzip, identityOK: true},
+		{name: "excluded", header: "br;q=0, gzip", encoding: encodingGzip, identityOK: true},
+		{name: "wildcard", header: "*", encoding: encodingBrotli, identityOK: true},
+		{name: "wildcard_with_exclusion", header: "*;q=0.5, br;q=0", encoding: encodingZstd, identityOK: true},
+		{name: "identity_refused", header: "deflate, identity;q=0", encoding: encodingDeflate, identityOK: false},
+		{name: "wildcard_refused", header: "*;q=0", encoding: "", identityOK: false},
+		{name: "wildcard_refused_identity_allowed", header: "*;q=0, identity", encoding: "", identityOK: true},
+		{name: "unknown_only", header: "compress", encoding: "", identityOK: true},
+		{name: "x_gzip", header: "x-gzip", encoding: encodingGzip, identityOK: true},
+		{name: "case_and_spaces", header: " GZIP ; Q=0.3 , Deflate;q=0.2", encoding: encodingGzip, identityOK: true},
+		{name: "invalid_q", header: "br;q=abc, gzip", encoding: encodingGzip, identityOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoding, identityOK := negotiateEncoding(tt.header)
+			if encoding != tt.encoding || identityOK != tt.identityOK {
+				t.Fatalf("negotiateEncoding(%q) = (%q, %v), want (%q, %v)",
+					tt.header, encoding, identityOK, tt.encoding, tt.identityOK)
+			}
+		})
+	}
+}
+
+func redirectHandler(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, "https://example.com/some/long/original/url", http.StatusTemporaryRedirect)
+}
+
+func BenchmarkCompressMiddleware_Redirect(b *testing.B) {
+	handler := CompressMiddleware(DefaultCompressMinSize)(http.HandlerFunc(redirectHandler))
+	req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate, br, zstd")
+
+	b.ReportAllocs()
+	for b.Loop() {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
+
+func BenchmarkCompressMiddleware_JSON(b *testing.B) {
+	body := []byte(`{"data":"` + strings.Repeat("hello ", DefaultCompressMinSize) + `"}`)
+	handler := CompressMiddleware(DefaultCompressMinSize)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+
+	for _, encoding := range supportedEncodings {
+		b.Run(encoding, func(b *testing.B) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", encoding)
+
+			b.ReportAllocs()
+			for b.Loop() {
+				handler.ServeHTTP(httptest.NewRecorder(), req)
+			}
+		})
+	}
+}
+
+func BenchmarkCompressMiddleware_RequestBody(b *testing.B) {
+	handler := CompressMiddleware(DefaultCompressMinSize)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	for _, encoding := range supportedEncodings {
+		b.Run(encoding, func(b *testing.B) {
+			var buf bytes.Buffer
+			enc, _ := newEncoder(encoding, &buf)
+			enc.Write([]byte(strings.Repeat(`{"url":"https://example.com"}`, 100)))
+			enc.Close()
+			payload := buf.Bytes()
+
+			b.ReportAllocs()
+			for b.Loop() {
+				req := httptest.NewRequest(http.MethodPost, "/api/shorten", bytes.NewReader(payload))
+				req.Header.Set("Content-Encoding", encoding)
+				handler.ServeHTTP(httptest.NewRecorder(), req)
+			}
+		})
+	}
+}
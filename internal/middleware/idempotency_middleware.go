@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/kayumovtd/url-shortener/internal/logger"
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"github.com/kayumovtd/url-shortener/internal/repository"
+	"github.com/kayumovtd/url-shortener/internal/service"
+	"github.com/kayumovtd/url-shortener/internal/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	// maxIdempotentBodySize — тело читается в память целиком ради отпечатка, поэтому ограничено
+	maxIdempotentBodySize = 10 << 20
+)
+
+// Заголовки ответа, которые сохраняем и отдаём при повторе.
+// Set-Cookie и прочее, что выставляют внешние middleware, сюда попадать не должно.
+var replayedHeaders = []string{"Content-Type", "Location"}
+
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	header     http.Header
+	body       bytes.Buffer
+}
+
+func (rw *recordingResponseWriter) WriteHeader(code int) {
+	if rw.statusCode != 0 {
+		return
+	}
+	rw.statusCode = code
+	rw.header = make(http.Header)
+	for _, h := range replayedHeaders {
+		if v := rw.ResponseWriter.Header().Values(h); len(v) > 0 {
+			rw.header[h] = append([]string(nil), v...)
+		}
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingResponseWriter) Write(b []byte) (int, error) {
+	if rw.statusCode == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+type Idempotency struct {
+	store repository.IdempotencyStore
+	ttl   time.Duration
+	up    service.UserProvider
+	log   *logger.Logger
+}
+
+func NewIdempotency(
+	store repository.IdempotencyStore,
+	ttl time.Duration,
+	up service.UserProvider,
+	l *logger.Logger,
+) *Idempotency {
+	return &Idempotency{
+		store: store,
+		ttl:   ttl,
+		up:    up,
+		log:   l,
+	}
+}
+
+// Middleware запоминает ответ на запрос с Idempotency-Key и отдаёт его же на повторы.
+// Ключи живут в пространстве пользователя, так что одинаковые ключи разных клиентов не пересекаются.
+// Клиент без куки получает нового пользователя на каждый запрос, его ключи живут в пространстве IP.
+func (i *Idempotency) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			utils.WritePlainText(w, http.StatusBadRequest, "idempotency key is too long")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodySize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			utils.WritePlainText(w, http.StatusRequestEntityTooLarge, http.StatusText(http.StatusRequestEntityTooLarge))
+			return
+		}
+		if err != nil {
+			utils.WritePlainText(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		storeKey := i.scope(r) + ":" + key
+		fingerprint := requestFingerprint(r, body)
+
+		rec, reserved, err := i.store.Reserve(r.Context(), storeKey, fingerprint, i.ttl)
+		if err != nil {
+			// Без хранилища идемпотентность не гарантировать, но и отказывать в обработке незачем
+			i.log.WithContext(r.Context()).Error("idempotency reserve failed", zap.String("key", key), zap.Error(err))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !reserved {
+			switch {
+			case rec.Fingerprint != fingerprint:
+				utils.WritePlainText(w, http.StatusUnprocessableEntity, "idempotency key reused with a different request")
+			case !rec.Completed:
+				utils.WritePlainText(w, http.StatusConflict, "request with this idempotency key is in progress")
+			default:
+				replay(w, rec)
+			}
+			return
+		}
+
+		// Клиент мог уже отвалиться, а результат сохранить всё равно нужно
+		ctx := context.WithoutCancel(r.Context())
+
+		// Ключ освобождаем при любом исходе, кроме сохранённого ответа, в том числе при панике
+		// обработчика: иначе до конца TTL на повторы будет ответ «в процессе»
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := i.store.Release(ctx, storeKey); err != nil {
+				i.log.WithContext(r.Context()).Error("idempotency release failed", zap.String("key", key), zap.Error(err))
+			}
+		}()
+
+		rw := &recordingResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(rw, r)
+
+		// Серверные ошибки не запоминаем, чтобы клиент мог повторить запрос
+		if rw.statusCode == 0 || rw.statusCode >= http.StatusInternalServerError {
+			return
+		}
+
+		rec = model.IdempotencyRecord{
+			Fingerprint: fingerprint,
+			StatusCode:  rw.statusCode,
+			Header:      rw.header,
+			Body:        rw.body.Bytes(),
+		}
+		if err := i.store.Complete(ctx, storeKey, rec, i.ttl); err != nil {
+			i.log.WithContext(r.Context()).Error("idempotency complete failed", zap.String("key", key), zap.Error(err))
+			return
+		}
+		completed = true
+	})
+}
+
+// scope — пространство ключей клиента: пользователь, а для только что выданного
+// анонимного пользователя — IP, иначе повтор без куки никогда не совпадёт с оригиналом
+func (i *Idempotency) scope(r *http.Request) string {
+	userID, ok := i.up.GetUserID(r.Context())
+	if !ok || userID == "" || isIssuedUser(r.Context()) {
+		return "ip:" + clientIP(r)
+	}
+	return "user:" + userID
+}
+
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.Path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func replay(w http.ResponseWriter, rec model.IdempotencyRecord) {
+	for k, v := range rec.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set(idempotencyReplayedHeader, "true")
+	w.WriteHeader(rec.StatusCode)
+	w.Write(rec.Body)
+}
@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kayumovtd/url-shortener/internal/logger"
+	"github.com/kayumovtd/url-shortener/internal/repository"
+	"github.com/kayumovtd/url-shortener/internal/service"
+	"github.com/kayumovtd/url-shortener/internal/service/mocks"
+)
+
+func TestIdempotency(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(string(body) + strings.Repeat("!", int(n))))
+	})
+
+	up := mocks.NewMockUserProvider(testUserID, true)
+	idem := NewIdempotency(repository.NewInMemoryIdempotencyStore(), time.Hour, up, logger.NewNoOp())
+	h := idem.Middleware(handler)
+
+	doRequest := func(key, body string) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(idempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	readBody := func(res *http.Response) string {
+		defer res.Body.Close()
+		data, _ := io.ReadAll(res.Body)
+		return string(data)
+	}
+
+	first := doRequest("key-1", "hello")
+	firstBody := readBody(first)
+	if first.StatusCode != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", first.StatusCode, http.StatusCreated)
+	}
+
+	t.Run("replays_response", func(t *testing.T) {
+		res := doRequest("key-1", "hello")
+		body := readBody(res)
+
+		if res.StatusCode != http.StatusCreated {
+			t.Errorf("status = %d, want %d", res.StatusCode, http.StatusCreated)
+		}
+		if body != firstBody {
+			t.Errorf("body = %q, want %q", body, firstBody)
+		}
+		if res.Header.Get(idempotencyReplayedHeader) != "true" {
+			t.Errorf("expected %s header", idempotencyReplayedHeader)
+		}
+		if res.Header.Get("Content-Type") != "text/plain" {
+			t.Errorf("content type = %q, want %q", res.Header.Get("Content-Type"), "text/plain")
+		}
+		if calls.Load() != 1 {
+			t.Errorf("handler called %d times, want 1", calls.Load())
+		}
+	})
+
+	t.Run("rejects_different_body", func(t *testing.T) {
+		res := doRequest("key-1", "other")
+		readBody(res)
+
+		if res.StatusCode != http.StatusUnprocessableEntity {
+			t.Errorf("status = %d, want %d", res.StatusCode, http.StatusUnprocessableEntity)
+		}
+	})
+
+	t.Run("without_key", func(t *testing.T) {
+		before := calls.Load()
+		readBody(doRequest("", "hello"))
+		readBody(doRequest("", "hello"))
+
+		if calls.Load() != before+2 {
+			t.Errorf("handler called %d times, want %d", calls.Load()-before, 2)
+		}
+	})
+}
+
+func TestIdempotency_AnonymousRetry(t *testing.T) {
+	auth := service.NewAuthService("secret")
+	keys := service.NewAPIKeyService(repository.NewMockStore())
+	idem := NewIdempotency(repository.NewInMemoryIdempotencyStore(), time.Hour, auth, logger.NewNoOp())
+
+	var calls atomic.Int32
+	h := AuthMiddleware(auth, keys)(idem.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusCreated)
+	})))
+
+	// Ответ потерялся вместе с кукой: повтор приходит снова без неё и получает другого пользователя
+	doRequest := func(remoteAddr string) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello"))
+		req.RemoteAddr = remoteAddr
+		req.Header.Set(idempotencyKeyHeader, "key-1")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	doRequest("192.0.2.1:1234")
+	res := doRequest("192.0.2.1:5678")
+	if calls.Load() != 1 || res.Header.Get(idempotencyReplayedHeader) != "true" {
+		t.Errorf("anonymous retry was not replayed: handler called %d times", calls.Load())
+	}
+
+	doRequest("192.0.2.2:1234")
+	if calls.Load() != 2 {
+		t.Errorf("same key from another client was replayed: handler called %d times", calls.Load())
+	}
+}
+
+func TestIdempotency_PanicReleasesKey(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			panic("boom")
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	up := mocks.NewMockUserProvider(testUserID, true)
+	h := NewIdempotency(repository.NewInMemoryIdempotencyStore(), time.Hour, up, logger.NewNoOp()).Middleware(handler)
+
+	doRequest := func() *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("hello"))
+		req.Header.Set(idempotencyKeyHeader, "key-1")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("expected the handler panic to propagate")
+			}
+		}()
+		doRequest()
+	}()
+
+	if res := doRequest(); res.StatusCode != http.StatusCreated {
+		t.Errorf("retry after panic: status = %d, want %d", res.StatusCode, http.StatusCreated)
+	}
+}
+
+func TestIdempotency_BodyTooLarge(t *testing.T) {
+	up := mocks.NewMockUserProvider(testUserID, true)
+	h := NewIdempotency(repository.NewInMemoryIdempotencyStore(), time.Hour, up, logger.NewNoOp()).
+		Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Error("handler must not be called")
+		}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("a", maxIdempotentBodySize+1)))
+	req.Header.Set(idempotencyKeyHeader, "key-1")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/kayumovtd/url-shortener/internal/logger"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -36,13 +37,23 @@ func LoggingMiddleware(l *logger.Logger) func(http.Handler) http.Handler {
 
 			duration := time.Since(start)
 
-			l.Info("request",
+			fields := []zap.Field{
 				zap.String("method", r.Method),
 				zap.String("uri", r.RequestURI),
 				zap.Duration("duration", duration),
 				zap.Int("status", lrw.statusCode),
 				zap.Int("size", lrw.size),
-			)
+			}
+			// Спан открывает TracingMiddleware выше по цепочке
+			if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+				fields = append(fields,
+					zap.String("trace_id", sc.TraceID().String()),
+					zap.String("span_id", sc.SpanID().String()),
+				)
+			}
+
+			// user_id к этому моменту добавил AuthMiddleware
+			l.WithContext(r.Context()).Info("request", fields...)
 		})
 	}
 }
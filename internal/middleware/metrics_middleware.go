@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Запросы, не попавшие ни в один маршрут, сводим в одну метку, чтобы сканеры не раздували число серий
+const unmatchedRoute = "unmatched"
+
+type HTTPMetrics interface {
+	ObserveHTTPRequest(method, route string, status int, duration time.Duration)
+}
+
+// MetricsMiddleware считает запросы по шаблону маршрута chi, а не по URL:
+// иначе каждая короткая ссылка стала бы отдельной серией.
+func MetricsMiddleware(m HTTPMetrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			lrw := &loggingResponseWriter{ResponseWriter: w}
+
+			next.ServeHTTP(lrw, r)
+
+			// Шаблон известен только после роутинга
+			route := unmatchedRoute
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+			status := lrw.statusCode
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			m.ObserveHTTPRequest(r.Method, route, status, time.Since(start))
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/kayumovtd/url-shortener/internal/logger"
+	"github.com/kayumovtd/url-shortener/internal/repository"
+	"github.com/kayumovtd/url-shortener/internal/service"
+	"github.com/kayumovtd/url-shortener/internal/utils"
+	"go.uber.org/zap"
+)
+
+type RateLimitGroup string
+
+const (
+	RateLimitCreate   RateLimitGroup = "create"
+	RateLimitDelete   RateLimitGroup = "delete"
+	RateLimitRedirect RateLimitGroup = "redirect"
+)
+
+// RateLimit — не больше Limit запросов за Period, Limit же служит размером корзины.
+// Нулевое значение означает, что лимит выключен.
+type RateLimit struct {
+	Limit  int
+	Period time.Duration
+}
+
+func (rl RateLimit) Enabled() bool {
+	return rl.Limit > 0 && rl.Period > 0
+}
+
+// ParseRateLimit разбирает строку вида "100/m" (s, m, h).
+// Пустая строка или "0" — лимит выключен.
+func ParseRateLimit(s string) (RateLimit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return RateLimit{}, nil
+	}
+
+	countStr, unit, ok := strings.Cut(s, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q: expected <count>/<s|m|h>", s)
+	}
+
+	count, err := strconv.Atoi(countStr)
+	if err != nil || count < 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit count %q", countStr)
+	}
+
+	var period time.Duration
+	switch unit {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		return RateLimit{}, fmt.Errorf("invalid rate limit unit %q", unit)
+	}
+
+	return RateLimit{Limit: count, Period: period}, nil
+}
+
+type RateLimits struct {
+	Create   RateLimit
+	Delete   RateLimit
+	Redirect RateLimit
+}
+
+func (rls RateLimits) get(group RateLimitGroup) RateLimit {
+	switch group {
+	case RateLimitCreate:
+		return rls.Create
+	case RateLimitDelete:
+		return rls.Delete
+	case RateLimitRedirect:
+		return rls.Redirect
+	}
+	return RateLimit{}
+}
+
+type RateLimiter struct {
+	store  repository.RateLimitStore
+	limits atomic.Pointer[RateLimits]
+	up     service.UserProvider
+	log    *logger.Logger
+}
+
+func NewRateLimiter(
+	store repository.RateLimitStore,
+	limits RateLimits,
+	up service.UserProvider,
+	l *logger.Logger,
+) *RateLimiter {
+	rl := &RateLimiter{
+		store: store,
+		up:    up,
+		log:   l,
+	}
+	rl.SetLimits(limits)
+	return rl
+}
+
+// SetLimits подменяет лимиты для всех групп сразу, уже накопленные счётчики сохраняются.
+func (rl *RateLimiter) SetLimits(limits RateLimits) {
+	rl.limits.Store(&limits)
+}
+
+// Middleware ограничивает запросы группы отдельно по пользователю и по IP клиента.
+// Лимит по IP нужен потому, что без куки AuthMiddleware на каждый запрос выдаёт нового пользователя.
+func (rl *RateLimiter) Middleware(group RateLimitGroup) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := rl.limits.Load().get(group)
+			if !limit.Enabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			keys := []string{"ip:" + clientIP(r)}
+			if userID, ok := rl.up.GetUserID(r.Context()); ok && userID != "" {
+				keys = append(keys, "user:"+userID)
+			}
+
+			for _, key := range keys {
+				allowed, retryAfter, err := rl.store.Take(r.Context(), string(group)+":"+key, limit.Limit, limit.Period)
+				if err != nil {
+					// Лучше пропустить запрос, чем уронить сервис из-за недоступного хранилища лимитов
+					rl.log.WithContext(r.Context()).Error("rate limit check failed", zap.String("key", key), zap.Error(err))
+					continue
+				}
+				if !allowed {
+					seconds := int(math.Ceil(retryAfter.Seconds()))
+					w.Header().Set("Retry-After", strconv.Itoa(max(seconds, 1)))
+					utils.WritePlainText(w, http.StatusTooManyRequests, http.StatusText(http.StatusTooManyRequests))
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
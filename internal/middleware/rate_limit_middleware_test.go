@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kayumovtd/url-shortener/internal/logger"
+	"github.com/kayumovtd/url-shortener/internal/repository"
+	"github.com/kayumovtd/url-shortener/internal/service/mocks"
+)
+
+const testUserID = "test_user_id"
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		want      RateLimit
+		shouldErr bool
+	}{
+		{"empty", "", RateLimit{}, false},
+		{"zero", "0", RateLimit{}, false},
+		{"per_second", "10/s", RateLimit{Limit: 10, Period: time.Second}, false},
+		{"per_minute", "100/m", RateLimit{Limit: 100, Period: time.Minute}, false},
+		{"per_hour", "5/h", RateLimit{Limit: 5, Period: time.Hour}, false},
+		{"no_unit", "100", RateLimit{}, true},
+		{"bad_unit", "100/d", RateLimit{}, true},
+		{"bad_count", "abc/s", RateLimit{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRateLimit(tt.input)
+			if tt.shouldErr {
+				if err == nil {
+					t.Errorf("expected error, got nil (result=%v)", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	doRequest := func(h http.Handler, remoteAddr string) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	limits := RateLimits{Create: RateLimit{Limit: 2, Period: time.Minute}}
+
+	t.Run("limits_by_ip", func(t *testing.T) {
+		up := mocks.NewMockUserProvider("", false)
+		rl := NewRateLimiter(repository.NewInMemoryRateLimitStore(), limits, up, logger.NewNoOp())
+		h := rl.Middleware(RateLimitCreate)(okHandler)
+
+		for i := range 2 {
+			res := doRequest(h, "10.0.0.1:1234")
+			res.Body.Close()
+			if res.StatusCode != http.StatusOK {
+				t.Fatalf("request %d: status = %d, want %d", i, res.StatusCode, http.StatusOK)
+			}
+		}
+
+		res := doRequest(h, "10.0.0.1:1234")
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusTooManyRequests {
+			t.Fatalf("status = %d, want %d", res.StatusCode, http.StatusTooManyRequests)
+		}
+		if res.Header.Get("Retry-After") == "" {
+			t.Errorf("expected Retry-After header")
+		}
+
+		// другой IP тратит свой бюджет
+		other := doRequest(h, "10.0.0.2:1234")
+		defer other.Body.Close()
+		if other.StatusCode != http.StatusOK {
+			t.Errorf("other ip: status = %d, want %d", other.StatusCode, http.StatusOK)
+		}
+	})
+
+	t.Run("limits_by_user", func(t *testing.T) {
+		up := mocks.NewMockUserProvider(testUserID, true)
+		rl := NewRateLimiter(repository.NewInMemoryRateLimitStore(), limits, up, logger.NewNoOp())
+		h := rl.Middleware(RateLimitCreate)(okHandler)
+
+		// пользователь один и тот же, IP разные
+		for i, addr := range []string{"10.0.0.1:1", "10.0.0.2:1"} {
+			res := doRequest(h, addr)
+			res.Body.Close()
+			if res.StatusCode != http.StatusOK {
+				t.Fatalf("request %d: status = %d, want %d", i, res.StatusCode, http.StatusOK)
+			}
+		}
+
+		res := doRequest(h, "10.0.0.3:1")
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusTooManyRequests {
+			t.Fatalf("status = %d, want %d", res.StatusCode, http.StatusTooManyRequests)
+		}
+	})
+
+	t.Run("disabled_group", func(t *testing.T) {
+		up := mocks.NewMockUserProvider(testUserID, true)
+		rl := NewRateLimiter(repository.NewInMemoryRateLimitStore(), limits, up, logger.NewNoOp())
+		h := rl.Middleware(RateLimitRedirect)(okHandler)
+
+		for i := range 10 {
+			res := doRequest(h, "10.0.0.1:1")
+			res.Body.Close()
+			if res.StatusCode != http.StatusOK {
+				t.Fatalf("request %d: status = %d, want %d", i, res.StatusCode, http.StatusOK)
+			}
+		}
+	})
+
+	t.Run("set_limits", func(t *testing.T) {
+		up := mocks.NewMockUserProvider("", false)
+		rl := NewRateLimiter(repository.NewInMemoryRateLimitStore(), RateLimits{}, up, logger.NewNoOp())
+		h := rl.Middleware(RateLimitCreate)(okHandler)
+
+		res := doRequest(h, "10.0.0.1:1")
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, want %d", res.StatusCode, http.StatusOK)
+		}
+
+		// новые лимиты действуют на уже собранный обработчик
+		rl.SetLimits(RateLimits{Create: RateLimit{Limit: 1, Period: time.Minute}})
+		for i, want := range []int{http.StatusOK, http.StatusTooManyRequests} {
+			res := doRequest(h, "10.0.0.1:1")
+			res.Body.Close()
+			if res.StatusCode != want {
+				t.Fatalf("request %d: status = %d, want %d", i, res.StatusCode, want)
+			}
+		}
+	})
+}
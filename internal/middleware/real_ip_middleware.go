@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// RealIPMiddleware подставляет в RemoteAddr адрес клиента из X-Real-IP или X-Forwarded-For,
+// а X-Forwarded-Proto: https учитывает для кук, но только если запрос пришёл от одного
+// из trustedProxies. Иначе заголовки может подделать кто угодно.
+func RealIPMiddleware(trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peer, ok := parseIP(clientIP(r))
+			if ok && containsIP(trustedProxies, peer) {
+				if ip, ok := forwardedIP(r, trustedProxies); ok {
+					r.RemoteAddr = net.JoinHostPort(ip.String(), "0")
+				}
+				if strings.EqualFold(strings.TrimSpace(r.Header.Get("X-Forwarded-Proto")), "https") {
+					r = markHTTPS(r)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func forwardedIP(r *http.Request, trustedProxies []netip.Prefix) (netip.Addr, bool) {
+	if ip, ok := parseIP(r.Header.Get("X-Real-IP")); ok {
+		return ip, true
+	}
+
+	// Справа налево: последний адрес, добавленный не нашим прокси, и есть клиент
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip, ok := parseIP(hops[i])
+		if !ok {
+			break
+		}
+		if !containsIP(trustedProxies, ip) || i == 0 {
+			return ip, true
+		}
+	}
+
+	return netip.Addr{}, false
+}
+
+func parseIP(s string) (netip.Addr, bool) {
+	ip, err := netip.ParseAddr(strings.TrimSpace(s))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ip.Unmap(), true
+}
+
+func containsIP(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
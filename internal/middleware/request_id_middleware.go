@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/kayumovtd/url-shortener/internal/logger"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	// Чужой ID принимаем, только если он короткий и печатный: он попадает в логи и заголовок ответа
+	maxRequestIDLen = 128
+)
+
+// RequestIDMiddleware берёт X-Request-ID клиента или прокси либо генерирует новый,
+// кладёт его в контекст для логов и возвращает в ответе.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logger.WithRequestID(r.Context(), id)))
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := range len(id) {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
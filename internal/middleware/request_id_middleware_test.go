@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kayumovtd/url-shortener/internal/logger"
+	"github.com/kayumovtd/url-shortener/internal/repository"
+	"github.com/kayumovtd/url-shortener/internal/service"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var gotID string
+	h := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID = logger.RequestID(r.Context())
+	}))
+
+	tests := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{name: "accepts_client_id", header: "req-123", keep: true},
+		{name: "generates_when_missing", header: ""},
+		{name: "replaces_too_long", header: strings.Repeat("a", maxRequestIDLen+1)},
+		{name: "replaces_non_printable", header: "req 123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(requestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			respID := w.Header().Get(requestIDHeader)
+			if respID == "" || respID != gotID {
+				t.Fatalf("response id %q, context id %q", respID, gotID)
+			}
+			if (respID == tt.header) != tt.keep {
+				t.Errorf("id = %q, header %q kept = %v, want %v", respID, tt.header, respID == tt.header, tt.keep)
+			}
+		})
+	}
+}
+
+func TestAccessLogHasRequestAndUserID(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	l := &logger.Logger{Logger: zap.New(core)}
+
+	auth := service.NewAuthService("secret")
+	keys := service.NewAPIKeyService(repository.NewMockStore())
+
+	var userID string
+	h := RequestIDMiddleware(LoggingMiddleware(l)(AuthMiddleware(auth, keys)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, _ = auth.GetUserID(r.Context())
+		}),
+	)))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(requestIDHeader, "req-42")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := logs.FilterMessage("request").All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d access log entries, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["request_id"] != "req-42" {
+		t.Errorf("request_id = %v, want req-42", fields["request_id"])
+	}
+	if userID == "" || fields["user_id"] != userID {
+		t.Errorf("user_id = %v, want %q", fields["user_id"], userID)
+	}
+}
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type ShortenRequest struct {
 	URL string `json:"url"`
 }
@@ -21,9 +23,92 @@ type ShortenBatchResponseItem struct {
 type UserURLsResponseItem struct {
 	ShortURL    string `json:"short_url"`
 	OriginalURL string `json:"original_url"`
+	WorkspaceID string `json:"workspace_id,omitempty"`
 }
 
 type ErrorResponse struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
 }
+
+type CreateAPIKeyRequest struct {
+	Name string `json:"name"`
+}
+
+type CreateAPIKeyResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Key       string    `json:"key"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type APIKeyResponseItem struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type CredentialsRequest struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+type UserResponse struct {
+	ID    string `json:"id"`
+	Login string `json:"login"`
+}
+
+type CreateWorkspaceRequest struct {
+	Name string `json:"name"`
+}
+
+type WorkspaceResponseItem struct {
+	ID        string        `json:"id"`
+	Name      string        `json:"name"`
+	Role      WorkspaceRole `json:"role"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type SetWorkspaceMemberRequest struct {
+	Role WorkspaceRole `json:"role"`
+}
+
+type RetargetRequest struct {
+	URL string `json:"url"`
+}
+
+type AdminURLResponse struct {
+	ShortURL    string `json:"short_url"`
+	OriginalURL string `json:"original_url"`
+	UserID      string `json:"user_id"`
+	WorkspaceID string `json:"workspace_id,omitempty"`
+	IsDeleted   bool   `json:"is_deleted"`
+	IsDisabled  bool   `json:"is_disabled"`
+
+	Moderation       ModerationState `json:"moderation"`
+	ModerationReason string          `json:"moderation_reason,omitempty"`
+}
+
+type ReportRequest struct {
+	Reason string `json:"reason"`
+}
+
+type ModerateRequest struct {
+	State  ModerationState `json:"state"`
+	Reason string          `json:"reason"`
+}
+
+type ModerationReportItem struct {
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ModerationQueueItem — ссылка с открытыми жалобами
+type ModerationQueueItem struct {
+	ShortURL    string                 `json:"short_url"`
+	OriginalURL string                 `json:"original_url"`
+	State       ModerationState        `json:"state"`
+	Reports     []ModerationReportItem `json:"reports"`
+}
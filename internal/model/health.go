@@ -0,0 +1,21 @@
+package model
+
+type HealthStatus string
+
+const (
+	HealthOK       HealthStatus = "ok"
+	HealthFail     HealthStatus = "fail"
+	HealthDraining HealthStatus = "draining"
+)
+
+type ComponentHealth struct {
+	Name      string       `json:"name"`
+	Status    HealthStatus `json:"status"`
+	LatencyMS float64      `json:"latency_ms"`
+	Error     string       `json:"error,omitempty"`
+}
+
+type HealthReport struct {
+	Status HealthStatus      `json:"status"`
+	Checks []ComponentHealth `json:"checks,omitempty"`
+}
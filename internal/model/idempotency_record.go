@@ -0,0 +1,9 @@
+package model
+
+type IdempotencyRecord struct {
+	Fingerprint string              `json:"fingerprint"`
+	Completed   bool                `json:"completed"`
+	StatusCode  int                 `json:"status_code"`
+	Header      map[string][]string `json:"header"`
+	Body        []byte              `json:"body"`
+}
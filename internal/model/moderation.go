@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+type ModerationState string
+
+const (
+	ModerationActive ModerationState = "active"
+	// ModerationWarned — перед редиректом показываем предупреждение
+	ModerationWarned  ModerationState = "warned"
+	ModerationBlocked ModerationState = "blocked"
+)
+
+func (s ModerationState) Valid() bool {
+	return s == ModerationActive || s == ModerationWarned || s == ModerationBlocked
+}
+
+// AbuseReport — жалоба на ссылку. Открыта, пока модератор не вынес решение по ссылке.
+type AbuseReport struct {
+	ID         string     `json:"id"`
+	ShortURL   string     `json:"short_url"`
+	Reason     string     `json:"reason"`
+	ReporterID string     `json:"reporter_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
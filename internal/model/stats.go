@@ -0,0 +1,7 @@
+package model
+
+// Stats — общие счётчики сервиса для админского API
+type Stats struct {
+	URLs  int `json:"urls"`
+	Users int `json:"users"`
+}
@@ -6,4 +6,11 @@ type URLRecord struct {
 	ShortURL    string `json:"short_url"`
 	OriginalURL string `json:"original_url"`
 	IsDeleted   bool   `json:"is_deleted"`
+	// Непустой WorkspaceID — ссылка принадлежит рабочему пространству, а UserID остаётся её автором
+	WorkspaceID string `json:"workspace_id,omitempty"`
+	// IsDisabled — ссылку выключил администратор, редирект не работает, пока её не включат обратно
+	IsDisabled bool `json:"is_disabled,omitempty"`
+	// Пустое состояние модерации равносильно active
+	Moderation       ModerationState `json:"moderation,omitempty"`
+	ModerationReason string          `json:"moderation_reason,omitempty"`
 }
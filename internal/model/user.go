@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+type User struct {
+	ID           string    `json:"id"`
+	Login        string    `json:"login"`
+	PasswordHash string    `json:"password_hash"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+type WorkspaceRole string
+
+const (
+	RoleOwner  WorkspaceRole = "owner"
+	RoleEditor WorkspaceRole = "editor"
+	RoleViewer WorkspaceRole = "viewer"
+)
+
+// CanEdit — может добавлять и удалять ссылки рабочего пространства.
+func (r WorkspaceRole) CanEdit() bool {
+	return r == RoleOwner || r == RoleEditor
+}
+
+func (r WorkspaceRole) Valid() bool {
+	return r == RoleOwner || r == RoleEditor || r == RoleViewer
+}
+
+type Workspace struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WorkspaceMember struct {
+	WorkspaceID string        `json:"workspace_id"`
+	UserID      string        `json:"user_id"`
+	Role        WorkspaceRole `json:"role"`
+}
+
+// UserWorkspace — рабочее пространство глазами участника.
+type UserWorkspace struct {
+	Workspace
+	Role WorkspaceRole `json:"role"`
+}
@@ -0,0 +1,758 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kayumovtd/url-shortener/internal/model"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Бакеты BoltStore. Записи лежат в JSON, индексы — пустые значения под составными
+// ключами "<владелец>\x00<id>", чтобы выбирать их сканированием по префиксу.
+var (
+	bucketURLs           = []byte("urls")
+	bucketURLsByOriginal = []byte("urls_by_original") // original_url -> short
+	bucketUserURLs       = []byte("user_urls")        // user \x00 short
+	bucketWorkspaceURLs  = []byte("workspace_urls")   // ws \x00 short
+
+	bucketAPIKeys       = []byte("api_keys")
+	bucketAPIKeysByHash = []byte("api_keys_by_hash") // hash -> id
+	bucketUserAPIKeys   = []byte("user_api_keys")    // user \x00 id
+
+	bucketUsers        = []byte("users")
+	bucketUsersByLogin = []byte("users_by_login") // login -> id
+
+	bucketWorkspaces     = []byte("workspaces")
+	bucketMembers        = []byte("workspace_members") // ws \x00 user -> role
+	bucketUserWorkspaces = []byte("user_workspaces")   // user \x00 ws
+
+	bucketReports     = []byte("abuse_reports")
+	bucketOpenReports = []byte("open_abuse_reports") // short \x00 id
+)
+
+var boltBuckets = [][]byte{
+	bucketURLs, bucketURLsByOriginal, bucketUserURLs, bucketWorkspaceURLs,
+	bucketAPIKeys, bucketAPIKeysByHash, bucketUserAPIKeys,
+	bucketUsers, bucketUsersByLogin,
+	bucketWorkspaces, bucketMembers, bucketUserWorkspaces,
+	bucketReports, bucketOpenReports,
+}
+
+const boltIndexSep = "\x00"
+
+func boltIndexKey(owner, id string) []byte {
+	return []byte(owner + boltIndexSep + id)
+}
+
+// boltScan вызывает fn для id всех записей индекса с владельцем owner
+func boltScan(b *bolt.Bucket, owner string, fn func(id string, v []byte) error) error {
+	prefix := []byte(owner + boltIndexSep)
+	c := b.Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		if err := fn(string(k[len(prefix):]), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func boltGet[T any](b *bolt.Bucket, key string) (T, error) {
+	var v T
+	data := b.Get([]byte(key))
+	if data == nil {
+		return v, ErrStoreNotFound
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return v, fmt.Errorf("decode %q: %w", key, err)
+	}
+	return v, nil
+}
+
+func boltPut(b *bolt.Bucket, key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(key), data)
+}
+
+// BoltStore — встроенное хранилище на bbolt для одного узла: каждая запись —
+// транзакция с fsync, файл не переписывается целиком, как у FileStore.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+func (s *BoltStore) SaveURL(ctx context.Context, shortURL, originalURL, userID string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if short := tx.Bucket(bucketURLsByOriginal).Get([]byte(originalURL)); short != nil {
+			return NewErrStoreConflict(string(short), originalURL, nil)
+		}
+		if tx.Bucket(bucketURLs).Get([]byte(shortURL)) != nil {
+			return NewErrStoreConflict(shortURL, originalURL, nil)
+		}
+		return insertURL(tx, shortURL, originalURL, userID)
+	})
+}
+
+// SaveURLs сохраняет пачку в одной транзакции: при ошибке не сохраняется ничего.
+// Существующий short обновляется, как ON CONFLICT в DBStore.
+func (s *BoltStore) SaveURLs(ctx context.Context, urls map[string]string, userID string) error {
+	if len(urls) == 0 {
+		return nil
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		byOriginal := tx.Bucket(bucketURLsByOriginal)
+		seen := make(map[string]string, len(urls))
+		for short, orig := range urls {
+			owner, ok := seen[orig]
+			if b := byOriginal.Get([]byte(orig)); b != nil {
+				owner, ok = string(b), true
+			}
+			if ok && owner != short {
+				return NewErrStoreConflict(owner, orig, nil)
+			}
+			seen[orig] = short
+		}
+
+		for short, orig := range urls {
+			rec, err := boltGet[model.URLRecord](tx.Bucket(bucketURLs), short)
+			switch {
+			case errors.Is(err, ErrStoreNotFound):
+				if err := insertURL(tx, short, orig, userID); err != nil {
+					return err
+				}
+			case err != nil:
+				return err
+			case rec.OriginalURL != orig:
+				if err := byOriginal.Delete([]byte(rec.OriginalURL)); err != nil {
+					return err
+				}
+				rec.OriginalURL = orig
+				if err := boltPut(tx.Bucket(bucketURLs), short, rec); err != nil {
+					return err
+				}
+				if err := byOriginal.Put([]byte(orig), []byte(short)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func insertURL(tx *bolt.Tx, shortURL, originalURL, userID string) error {
+	rec := model.URLRecord{
+		ID:          uuid.NewString(),
+		UserID:      userID,
+		ShortURL:    shortURL,
+		OriginalURL: originalURL,
+	}
+	if err := boltPut(tx.Bucket(bucketURLs), shortURL, rec); err != nil {
+		return err
+	}
+	if err := tx.Bucket(bucketURLsByOriginal).Put([]byte(originalURL), []byte(shortURL)); err != nil {
+		return err
+	}
+	return tx.Bucket(bucketUserURLs).Put(boltIndexKey(userID, shortURL), nil)
+}
+
+func (s *BoltStore) GetURL(ctx context.Context, shortURL string) (model.URLRecord, error) {
+	var rec model.URLRecord
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		rec, err = boltGet[model.URLRecord](tx.Bucket(bucketURLs), shortURL)
+		return err
+	})
+	return rec, err
+}
+
+func (s *BoltStore) urlsByIndex(index []byte, owner string) ([]model.URLRecord, error) {
+	urls := []model.URLRecord{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		records := tx.Bucket(bucketURLs)
+		return boltScan(tx.Bucket(index), owner, func(short string, _ []byte) error {
+			rec, err := boltGet[model.URLRecord](records, short)
+			if err != nil {
+				return err
+			}
+			urls = append(urls, rec)
+			return nil
+		})
+	})
+	return urls, err
+}
+
+func (s *BoltStore) GetUserURLs(ctx context.Context, userID string) ([]model.URLRecord, error) {
+	urls, err := s.urlsByIndex(bucketUserURLs, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user urls: %w", err)
+	}
+	return urls, nil
+}
+
+func (s *BoltStore) MarkURLsDeleted(ctx context.Context, userID string, shortURLs []string) error {
+	if len(shortURLs) == 0 {
+		return nil
+	}
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		records := tx.Bucket(bucketURLs)
+		for _, short := range shortURLs {
+			rec, err := boltGet[model.URLRecord](records, short)
+			if errors.Is(err, ErrStoreNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			var members []model.WorkspaceMember
+			if role := tx.Bucket(bucketMembers).Get(boltIndexKey(rec.WorkspaceID, userID)); role != nil {
+				members = append(members, model.WorkspaceMember{WorkspaceID: rec.WorkspaceID, UserID: userID, Role: model.WorkspaceRole(role)})
+			}
+			if rec.IsDeleted || !canDeleteURL(rec, userID, members) {
+				continue
+			}
+
+			rec.IsDeleted = true
+			if err := boltPut(records, short, rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark urls deleted: %w", err)
+	}
+	return nil
+}
+
+func (s *BoltStore) SaveAPIKey(ctx context.Context, key model.APIKey) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := boltPut(tx.Bucket(bucketAPIKeys), key.ID, key); err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketAPIKeysByHash).Put([]byte(key.Hash), []byte(key.ID)); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketUserAPIKeys).Put(boltIndexKey(key.UserID, key.ID), nil)
+	})
+}
+
+func (s *BoltStore) GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error) {
+	var key model.APIKey
+	err := s.db.View(func(tx *bolt.Tx) error {
+		id := tx.Bucket(bucketAPIKeysByHash).Get([]byte(hash))
+		if id == nil {
+			return ErrStoreNotFound
+		}
+		var err error
+		key, err = boltGet[model.APIKey](tx.Bucket(bucketAPIKeys), string(id))
+		return err
+	})
+	return key, err
+}
+
+func (s *BoltStore) GetUserAPIKeys(ctx context.Context, userID string) ([]model.APIKey, error) {
+	keys := []model.APIKey{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		apiKeys := tx.Bucket(bucketAPIKeys)
+		return boltScan(tx.Bucket(bucketUserAPIKeys), userID, func(id string, _ []byte) error {
+			key, err := boltGet[model.APIKey](apiKeys, id)
+			if err != nil {
+				return err
+			}
+			keys = append(keys, key)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user api keys: %w", err)
+	}
+	slices.SortFunc(keys, func(a, b model.APIKey) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return keys, nil
+}
+
+func (s *BoltStore) RevokeAPIKey(ctx context.Context, userID, keyID string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		apiKeys := tx.Bucket(bucketAPIKeys)
+		key, err := boltGet[model.APIKey](apiKeys, keyID)
+		if err != nil {
+			return err
+		}
+		if key.UserID != userID {
+			return ErrStoreNotFound
+		}
+		if key.RevokedAt != nil {
+			return nil
+		}
+		now := time.Now()
+		key.RevokedAt = &now
+		return boltPut(apiKeys, keyID, key)
+	})
+}
+
+func (s *BoltStore) SaveUser(ctx context.Context, user model.User) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		users, byLogin := tx.Bucket(bucketUsers), tx.Bucket(bucketUsersByLogin)
+		if users.Get([]byte(user.ID)) != nil || byLogin.Get([]byte(user.Login)) != nil {
+			return ErrStoreAlreadyExists
+		}
+		if err := boltPut(users, user.ID, user); err != nil {
+			return err
+		}
+		return byLogin.Put([]byte(user.Login), []byte(user.ID))
+	})
+}
+
+func (s *BoltStore) GetUser(ctx context.Context, userID string) (model.User, error) {
+	var user model.User
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		user, err = boltGet[model.User](tx.Bucket(bucketUsers), userID)
+		return err
+	})
+	return user, err
+}
+
+func (s *BoltStore) GetUserByLogin(ctx context.Context, login string) (model.User, error) {
+	var user model.User
+	err := s.db.View(func(tx *bolt.Tx) error {
+		id := tx.Bucket(bucketUsersByLogin).Get([]byte(login))
+		if id == nil {
+			return ErrStoreNotFound
+		}
+		var err error
+		user, err = boltGet[model.User](tx.Bucket(bucketUsers), string(id))
+		return err
+	})
+	return user, err
+}
+
+func (s *BoltStore) MergeUserURLs(ctx context.Context, fromUserID, toUserID string) error {
+	if fromUserID == toUserID {
+		return nil
+	}
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		records, index := tx.Bucket(bucketURLs), tx.Bucket(bucketUserURLs)
+
+		// Курсор нельзя вести по бакету, который меняем, — сначала собираем ссылки
+		var shorts []string
+		if err := boltScan(index, fromUserID, func(short string, _ []byte) error {
+			shorts = append(shorts, short)
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		for _, short := range shorts {
+			rec, err := boltGet[model.URLRecord](records, short)
+			if err != nil {
+				return err
+			}
+			rec.UserID = toUserID
+			if err := boltPut(records, short, rec); err != nil {
+				return err
+			}
+			if err := index.Delete(boltIndexKey(fromUserID, short)); err != nil {
+				return err
+			}
+			if err := index.Put(boltIndexKey(toUserID, short), nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to merge user urls: %w", err)
+	}
+	return nil
+}
+
+func (s *BoltStore) MergeUserAPIKeys(ctx context.Context, fromUserID, toUserID string) error {
+	if fromUserID == toUserID {
+		return nil
+	}
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		keys, index := tx.Bucket(bucketAPIKeys), tx.Bucket(bucketUserAPIKeys)
+
+		var ids []string
+		if err := boltScan(index, fromUserID, func(id string, _ []byte) error {
+			ids = append(ids, id)
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			key, err := boltGet[model.APIKey](keys, id)
+			if err != nil {
+				return err
+			}
+			key.UserID = toUserID
+			if err := boltPut(keys, id, key); err != nil {
+				return err
+			}
+			if err := index.Delete(boltIndexKey(fromUserID, id)); err != nil {
+				return err
+			}
+			if err := index.Put(boltIndexKey(toUserID, id), nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to merge user api keys: %w", err)
+	}
+	return nil
+}
+
+func (s *BoltStore) SaveWorkspace(ctx context.Context, ws model.Workspace, ownerID string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		workspaces := tx.Bucket(bucketWorkspaces)
+		if workspaces.Get([]byte(ws.ID)) != nil {
+			return ErrStoreAlreadyExists
+		}
+		if err := boltPut(workspaces, ws.ID, ws); err != nil {
+			return err
+		}
+		return putMember(tx, model.WorkspaceMember{WorkspaceID: ws.ID, UserID: ownerID, Role: model.RoleOwner})
+	})
+}
+
+func putMember(tx *bolt.Tx, m model.WorkspaceMember) error {
+	if err := tx.Bucket(bucketMembers).Put(boltIndexKey(m.WorkspaceID, m.UserID), []byte(m.Role)); err != nil {
+		return err
+	}
+	return tx.Bucket(bucketUserWorkspaces).Put(boltIndexKey(m.UserID, m.WorkspaceID), nil)
+}
+
+func (s *BoltStore) GetWorkspace(ctx context.Context, workspaceID string) (model.Workspace, error) {
+	var ws model.Workspace
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		ws, err = boltGet[model.Workspace](tx.Bucket(bucketWorkspaces), workspaceID)
+		return err
+	})
+	return ws, err
+}
+
+func (s *BoltStore) GetUserWorkspaces(ctx context.Context, userID string) ([]model.UserWorkspace, error) {
+	result := []model.UserWorkspace{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		workspaces, members := tx.Bucket(bucketWorkspaces), tx.Bucket(bucketMembers)
+		return boltScan(tx.Bucket(bucketUserWorkspaces), userID, func(wsID string, _ []byte) error {
+			ws, err := boltGet[model.Workspace](workspaces, wsID)
+			if err != nil {
+				return err
+			}
+			role := members.Get(boltIndexKey(wsID, userID))
+			result = append(result, model.UserWorkspace{Workspace: ws, Role: model.WorkspaceRole(role)})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user workspaces: %w", err)
+	}
+	slices.SortFunc(result, func(a, b model.UserWorkspace) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return result, nil
+}
+
+func (s *BoltStore) GetWorkspaceMember(ctx context.Context, workspaceID, userID string) (model.WorkspaceMember, error) {
+	var member model.WorkspaceMember
+	err := s.db.View(func(tx *bolt.Tx) error {
+		role := tx.Bucket(bucketMembers).Get(boltIndexKey(workspaceID, userID))
+		if role == nil {
+			return ErrStoreNotFound
+		}
+		member = model.WorkspaceMember{WorkspaceID: workspaceID, UserID: userID, Role: model.WorkspaceRole(role)}
+		return nil
+	})
+	return member, err
+}
+
+func (s *BoltStore) GetWorkspaceMembers(ctx context.Context, workspaceID string) ([]model.WorkspaceMember, error) {
+	members := []model.WorkspaceMember{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return boltScan(tx.Bucket(bucketMembers), workspaceID, func(userID string, role []byte) error {
+			members = append(members, model.WorkspaceMember{WorkspaceID: workspaceID, UserID: userID, Role: model.WorkspaceRole(role)})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace members: %w", err)
+	}
+	return members, nil
+}
+
+func (s *BoltStore) SaveWorkspaceMember(ctx context.Context, member model.WorkspaceMember) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := boltCheckOwner(tx, member.WorkspaceID, member.UserID, member.Role); err != nil {
+			return err
+		}
+		return putMember(tx, member)
+	})
+}
+
+func (s *BoltStore) DeleteWorkspaceMember(ctx context.Context, workspaceID, userID string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		members := tx.Bucket(bucketMembers)
+		key := boltIndexKey(workspaceID, userID)
+		if members.Get(key) == nil {
+			return ErrStoreNotFound
+		}
+		if err := boltCheckOwner(tx, workspaceID, userID, ""); err != nil {
+			return err
+		}
+		if err := members.Delete(key); err != nil {
+			return err
+		}
+		return tx.Bucket(bucketUserWorkspaces).Delete(boltIndexKey(userID, workspaceID))
+	})
+}
+
+// boltCheckOwner — leavesNoOwner внутри транзакции записи, которая и так единственная
+func boltCheckOwner(tx *bolt.Tx, workspaceID, userID string, role model.WorkspaceRole) error {
+	var members []model.WorkspaceMember
+	err := boltScan(tx.Bucket(bucketMembers), workspaceID, func(id string, r []byte) error {
+		members = append(members, model.WorkspaceMember{WorkspaceID: workspaceID, UserID: id, Role: model.WorkspaceRole(r)})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if leavesNoOwner(members, workspaceID, userID, role) {
+		return ErrStoreLastOwner
+	}
+	return nil
+}
+
+func (s *BoltStore) MoveURLsToWorkspace(ctx context.Context, userID, workspaceID string, shortURLs []string) error {
+	if len(shortURLs) == 0 {
+		return nil
+	}
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		records := tx.Bucket(bucketURLs)
+		for _, short := range shortURLs {
+			rec, err := boltGet[model.URLRecord](records, short)
+			if errors.Is(err, ErrStoreNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if rec.UserID != userID || rec.WorkspaceID != "" {
+				continue
+			}
+
+			rec.WorkspaceID = workspaceID
+			if err := boltPut(records, short, rec); err != nil {
+				return err
+			}
+			if err := tx.Bucket(bucketWorkspaceURLs).Put(boltIndexKey(workspaceID, short), nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to move urls: %w", err)
+	}
+	return nil
+}
+
+func (s *BoltStore) GetWorkspaceURLs(ctx context.Context, workspaceID string) ([]model.URLRecord, error) {
+	urls, err := s.urlsByIndex(bucketWorkspaceURLs, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace urls: %w", err)
+	}
+	return urls, nil
+}
+
+// GetStats проходит по всем ссылкам — это админский запрос, отдельные счётчики ради него не ведём
+func (s *BoltStore) GetStats(ctx context.Context) (model.Stats, error) {
+	var stats model.Stats
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var records []model.URLRecord
+		err := tx.Bucket(bucketURLs).ForEach(func(_, v []byte) error {
+			var rec model.URLRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return err
+			}
+			records = append(records, rec)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		var users []model.User
+		err = tx.Bucket(bucketUsers).ForEach(func(k, _ []byte) error {
+			users = append(users, model.User{ID: string(k)})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		stats = countStats(records, users)
+		return nil
+	})
+	if err != nil {
+		return model.Stats{}, fmt.Errorf("failed to get stats: %w", err)
+	}
+	return stats, nil
+}
+
+// updateURL меняет ссылку в транзакции, ErrStoreNotFound — если её нет
+func (s *BoltStore) updateURL(tx *bolt.Tx, shortURL string, update func(*model.URLRecord)) error {
+	records := tx.Bucket(bucketURLs)
+	rec, err := boltGet[model.URLRecord](records, shortURL)
+	if err != nil {
+		return err
+	}
+	update(&rec)
+	return boltPut(records, shortURL, rec)
+}
+
+func (s *BoltStore) SetOriginalURL(ctx context.Context, shortURL, originalURL string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		byOriginal := tx.Bucket(bucketURLsByOriginal)
+		if b := byOriginal.Get([]byte(originalURL)); b != nil && string(b) != shortURL {
+			return NewErrStoreConflict(string(b), originalURL, nil)
+		}
+
+		var old string
+		err := s.updateURL(tx, shortURL, func(rec *model.URLRecord) {
+			old, rec.OriginalURL = rec.OriginalURL, originalURL
+		})
+		if err != nil {
+			return err
+		}
+		if err := byOriginal.Delete([]byte(old)); err != nil {
+			return err
+		}
+		return byOriginal.Put([]byte(originalURL), []byte(shortURL))
+	})
+}
+
+func (s *BoltStore) SetURLDisabled(ctx context.Context, shortURL string, disabled bool) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return s.updateURL(tx, shortURL, func(rec *model.URLRecord) { rec.IsDisabled = disabled })
+	})
+}
+
+func (s *BoltStore) SaveAbuseReport(ctx context.Context, report model.AbuseReport) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := boltPut(tx.Bucket(bucketReports), report.ID, report); err != nil {
+			return err
+		}
+		if report.ResolvedAt != nil {
+			return nil
+		}
+		return tx.Bucket(bucketOpenReports).Put(boltIndexKey(report.ShortURL, report.ID), nil)
+	})
+}
+
+func (s *BoltStore) GetOpenAbuseReports(ctx context.Context) ([]model.AbuseReport, error) {
+	reports := []model.AbuseReport{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		all := tx.Bucket(bucketReports)
+		return tx.Bucket(bucketOpenReports).ForEach(func(k, _ []byte) error {
+			_, id, _ := strings.Cut(string(k), boltIndexSep)
+			report, err := boltGet[model.AbuseReport](all, id)
+			if err != nil {
+				return err
+			}
+			reports = append(reports, report)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get abuse reports: %w", err)
+	}
+	slices.SortFunc(reports, func(a, b model.AbuseReport) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return reports, nil
+}
+
+func (s *BoltStore) SetURLModeration(ctx context.Context, shortURL string, state model.ModerationState, reason string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		err := s.updateURL(tx, shortURL, func(rec *model.URLRecord) {
+			rec.Moderation = state
+			rec.ModerationReason = reason
+		})
+		if err != nil {
+			return err
+		}
+
+		open, all := tx.Bucket(bucketOpenReports), tx.Bucket(bucketReports)
+		var ids []string
+		if err := boltScan(open, shortURL, func(id string, _ []byte) error {
+			ids = append(ids, id)
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, id := range ids {
+			report, err := boltGet[model.AbuseReport](all, id)
+			if err != nil {
+				return err
+			}
+			report.ResolvedAt = &now
+			if err := boltPut(all, id, report); err != nil {
+				return err
+			}
+			if err := open.Delete(boltIndexKey(shortURL, id)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Ping проверяет, что база открыта и читается
+func (s *BoltStore) Ping(ctx context.Context) error {
+	return s.db.View(func(*bolt.Tx) error { return nil })
+}
+
+func (s *BoltStore) Close() {
+	s.db.Close()
+}
+
+func NewBoltStore(path string) (*BoltStore, error) {
+	// Файл блокируется на время работы: второй процесс на той же базе не запустится, а не будет ждать вечно
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bolt db: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range boltBuckets {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create bolt buckets: %w", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
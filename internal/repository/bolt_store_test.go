@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func newTestBoltStore(t *testing.T) *BoltStore {
+	t.Helper()
+
+	store, err := NewBoltStore(filepath.Join(t.TempDir(), "urls.db"))
+	if err != nil {
+		t.Fatalf("failed to create bolt store: %v", err)
+	}
+	t.Cleanup(store.Close)
+	return store
+}
+
+func TestBoltStore(t *testing.T) {
+	runStoreSuite(t, func(t *testing.T) Store { return newTestBoltStore(t) })
+}
+
+func TestBoltStore_Reopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "urls.db")
+
+	s, err := NewBoltStore(path)
+	if err != nil {
+		t.Fatalf("failed to create bolt store: %v", err)
+	}
+	s.SaveURL(ctx, "abc", "https://example.com", "u1")
+	s.Close()
+
+	s, err = NewBoltStore(path)
+	if err != nil {
+		t.Fatalf("failed to reopen bolt store: %v", err)
+	}
+	defer s.Close()
+	if recs, _ := s.GetUserURLs(ctx, "u1"); len(recs) != 1 || recs[0].ShortURL != "abc" {
+		t.Errorf("data lost after reopen: %+v", recs)
+	}
+}
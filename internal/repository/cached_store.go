@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kayumovtd/url-shortener/internal/model"
+)
+
+type CacheConfig struct {
+	// Size — сколько ссылок держать в памяти, лишние вытесняются по LRU
+	Size int
+	// TTL == 0 — запись живёт, пока её не вытеснят или не инвалидируют
+	TTL time.Duration
+	// NegativeTTL — сколько помнить, что ссылки нет; 0 — не помнить
+	NegativeTTL time.Duration
+}
+
+type CacheStats struct {
+	Hits         uint64
+	NegativeHits uint64
+	Misses       uint64
+	Evictions    uint64
+	Entries      int
+}
+
+type cacheEntry struct {
+	shortURL string
+	rec      model.URLRecord
+	notFound bool
+	expires  time.Time
+}
+
+// CachedStore кэширует GetURL — его зовёт каждый редирект. Остальные методы идут в хранилище
+// напрямую, а те, что меняют ссылки, сбрасывают их из кэша. Кэш у каждого процесса свой:
+// изменения, сделанные другими инстансами через общую БД, видны только после TTL.
+type CachedStore struct {
+	Store
+	cfg CacheConfig
+	now func() time.Time
+
+	mu    sync.Mutex
+	lru   *list.List
+	items map[string]*list.Element
+	// gen растёт при каждой инвалидации: ответ хранилища, прочитанный до неё, в кэш не попадает
+	gen uint64
+
+	hits, negativeHits, misses, evictions atomic.Uint64
+}
+
+func NewCachedStore(store Store, cfg CacheConfig) *CachedStore {
+	return &CachedStore{
+		Store: store,
+		cfg:   cfg,
+		now:   time.Now,
+		lru:   list.New(),
+		items: make(map[string]*list.Element, cfg.Size),
+	}
+}
+
+// Unwrap возвращает хранилище под кэшем
+func (s *CachedStore) Unwrap() Store {
+	return s.Store
+}
+
+func (s *CachedStore) Stats() CacheStats {
+	s.mu.Lock()
+	entries := s.lru.Len()
+	s.mu.Unlock()
+
+	return CacheStats{
+		Hits:         s.hits.Load(),
+		NegativeHits: s.negativeHits.Load(),
+		Misses:       s.misses.Load(),
+		Evictions:    s.evictions.Load(),
+		Entries:      entries,
+	}
+}
+
+func (s *CachedStore) GetURL(ctx context.Context, shortURL string) (model.URLRecord, error) {
+	s.mu.Lock()
+	if el, ok := s.items[shortURL]; ok {
+		e := el.Value.(*cacheEntry)
+		if e.expires.IsZero() || s.now().Before(e.expires) {
+			s.lru.MoveToFront(el)
+			s.mu.Unlock()
+
+			if e.notFound {
+				s.negativeHits.Add(1)
+				return model.URLRecord{}, ErrStoreNotFound
+			}
+			s.hits.Add(1)
+			return e.rec, nil
+		}
+		s.remove(el)
+	}
+	gen := s.gen
+	s.mu.Unlock()
+
+	s.misses.Add(1)
+	rec, err := s.Store.GetURL(ctx, shortURL)
+	switch {
+	case err == nil:
+		s.put(gen, &cacheEntry{shortURL: shortURL, rec: rec}, s.cfg.TTL)
+	case errors.Is(err, ErrStoreNotFound) && s.cfg.NegativeTTL > 0:
+		s.put(gen, &cacheEntry{shortURL: shortURL, notFound: true}, s.cfg.NegativeTTL)
+	}
+	return rec, err
+}
+
+func (s *CachedStore) put(gen uint64, e *cacheEntry, ttl time.Duration) {
+	if ttl > 0 {
+		e.expires = s.now().Add(ttl)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if gen != s.gen {
+		return
+	}
+	if el, ok := s.items[e.shortURL]; ok {
+		el.Value = e
+		s.lru.MoveToFront(el)
+		return
+	}
+	s.items[e.shortURL] = s.lru.PushFront(e)
+	for s.lru.Len() > s.cfg.Size {
+		s.remove(s.lru.Back())
+		s.evictions.Add(1)
+	}
+}
+
+func (s *CachedStore) remove(el *list.Element) {
+	s.lru.Remove(el)
+	delete(s.items, el.Value.(*cacheEntry).shortURL)
+}
+
+// invalidate сбрасывает ссылки из кэша. Зовётся после записи в хранилище, даже неудачной:
+// часть изменений могла примениться.
+func (s *CachedStore) invalidate(shortURLs ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.gen++
+	for _, u := range shortURLs {
+		if el, ok := s.items[u]; ok {
+			s.remove(el)
+		}
+	}
+}
+
+func (s *CachedStore) purge() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.gen++
+	s.lru.Init()
+	clear(s.items)
+}
+
+func (s *CachedStore) SaveURL(ctx context.Context, shortURL, originalURL string, userID string) error {
+	// Сбрасываем отрицательную запись, иначе новая ссылка не откроется до конца NegativeTTL
+	defer s.invalidate(shortURL)
+	return s.Store.SaveURL(ctx, shortURL, originalURL, userID)
+}
+
+func (s *CachedStore) SaveURLs(ctx context.Context, urls map[string]string, userID string) error {
+	defer func() {
+		shortURLs := make([]string, 0, len(urls))
+		for u := range urls {
+			shortURLs = append(shortURLs, u)
+		}
+		s.invalidate(shortURLs...)
+	}()
+	return s.Store.SaveURLs(ctx, urls, userID)
+}
+
+func (s *CachedStore) MarkURLsDeleted(ctx context.Context, userID string, shortURLs []string) error {
+	defer s.invalidate(shortURLs...)
+	return s.Store.MarkURLsDeleted(ctx, userID, shortURLs)
+}
+
+func (s *CachedStore) MoveURLsToWorkspace(ctx context.Context, userID, workspaceID string, shortURLs []string) error {
+	defer s.invalidate(shortURLs...)
+	return s.Store.MoveURLsToWorkspace(ctx, userID, workspaceID, shortURLs)
+}
+
+// MergeUserURLs меняет автора у заранее неизвестного набора ссылок — проще сбросить весь кэш
+func (s *CachedStore) MergeUserURLs(ctx context.Context, fromUserID, toUserID string) error {
+	defer s.purge()
+	return s.Store.MergeUserURLs(ctx, fromUserID, toUserID)
+}
+
+func (s *CachedStore) SetOriginalURL(ctx context.Context, shortURL, originalURL string) error {
+	defer s.invalidate(shortURL)
+	return s.Store.SetOriginalURL(ctx, shortURL, originalURL)
+}
+
+func (s *CachedStore) SetURLDisabled(ctx context.Context, shortURL string, disabled bool) error {
+	defer s.invalidate(shortURL)
+	return s.Store.SetURLDisabled(ctx, shortURL, disabled)
+}
+
+func (s *CachedStore) SetURLModeration(ctx context.Context, shortURL string, state model.ModerationState, reason string) error {
+	defer s.invalidate(shortURL)
+	return s.Store.SetURLModeration(ctx, shortURL, state, reason)
+}
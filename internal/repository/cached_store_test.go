@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kayumovtd/url-shortener/internal/model"
+)
+
+// countingStore считает обращения к GetURL, мимо которых кэш должен пропускать запросы
+type countingStore struct {
+	Store
+	gets int
+}
+
+func (s *countingStore) GetURL(ctx context.Context, shortURL string) (model.URLRecord, error) {
+	s.gets++
+	return s.Store.GetURL(ctx, shortURL)
+}
+
+func newTestCache(t *testing.T, cfg CacheConfig) (*CachedStore, *countingStore, *time.Time) {
+	t.Helper()
+
+	inner := &countingStore{Store: NewInMemoryStore()}
+	cache := NewCachedStore(inner, cfg)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+	return cache, inner, &now
+}
+
+func TestCachedStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("hit", func(t *testing.T) {
+		cache, inner, _ := newTestCache(t, CacheConfig{Size: 10, TTL: time.Minute})
+		if err := cache.SaveURL(ctx, "abc", "https://example.com", "u1"); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+
+		for range 3 {
+			rec, err := cache.GetURL(ctx, "abc")
+			if err != nil || rec.OriginalURL != "https://example.com" {
+				t.Fatalf("unexpected result: %+v, %v", rec, err)
+			}
+		}
+		if inner.gets != 1 {
+			t.Errorf("store was called %d times, want 1", inner.gets)
+		}
+		if s := cache.Stats(); s.Hits != 2 || s.Misses != 1 || s.Entries != 1 {
+			t.Errorf("unexpected stats: %+v", s)
+		}
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		cache, inner, now := newTestCache(t, CacheConfig{Size: 10, TTL: time.Minute})
+		cache.SaveURL(ctx, "abc", "https://example.com", "u1")
+
+		cache.GetURL(ctx, "abc")
+		*now = now.Add(time.Minute)
+		cache.GetURL(ctx, "abc")
+		if inner.gets != 2 {
+			t.Errorf("expired entry was served from cache: %d store calls", inner.gets)
+		}
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		cache, inner, now := newTestCache(t, CacheConfig{Size: 10, TTL: time.Minute, NegativeTTL: 10 * time.Second})
+
+		for range 2 {
+			if _, err := cache.GetURL(ctx, "nope"); !errors.Is(err, ErrStoreNotFound) {
+				t.Fatalf("expected not found, got %v", err)
+			}
+		}
+		if inner.gets != 1 || cache.Stats().NegativeHits != 1 {
+			t.Errorf("unknown id was not cached: %d store calls, %+v", inner.gets, cache.Stats())
+		}
+
+		*now = now.Add(10 * time.Second)
+		cache.GetURL(ctx, "nope")
+		if inner.gets != 2 {
+			t.Errorf("expired negative entry was served from cache")
+		}
+	})
+
+	t.Run("negative_disabled", func(t *testing.T) {
+		cache, inner, _ := newTestCache(t, CacheConfig{Size: 10, TTL: time.Minute})
+		cache.GetURL(ctx, "nope")
+		cache.GetURL(ctx, "nope")
+		if inner.gets != 2 {
+			t.Errorf("unknown id was cached without NegativeTTL")
+		}
+	})
+
+	t.Run("save_clears_negative_entry", func(t *testing.T) {
+		cache, _, _ := newTestCache(t, CacheConfig{Size: 10, TTL: time.Minute, NegativeTTL: time.Hour})
+		cache.GetURL(ctx, "abc")
+		cache.SaveURLs(ctx, map[string]string{"abc": "https://example.com"}, "u1")
+
+		if rec, err := cache.GetURL(ctx, "abc"); err != nil || rec.OriginalURL != "https://example.com" {
+			t.Fatalf("new URL is hidden by a negative entry: %+v, %v", rec, err)
+		}
+	})
+
+	t.Run("lru_eviction", func(t *testing.T) {
+		cache, inner, _ := newTestCache(t, CacheConfig{Size: 2})
+		cache.SaveURLs(ctx, map[string]string{"a": "https://a.com", "b": "https://b.com", "c": "https://c.com"}, "u1")
+
+		cache.GetURL(ctx, "a")
+		cache.GetURL(ctx, "b")
+		cache.GetURL(ctx, "a") // b становится самой старой
+		cache.GetURL(ctx, "c") // вытесняет b
+
+		inner.gets = 0
+		cache.GetURL(ctx, "a")
+		cache.GetURL(ctx, "b")
+		if inner.gets != 1 {
+			t.Errorf("expected only b to be evicted, got %d store calls", inner.gets)
+		}
+		if s := cache.Stats(); s.Evictions != 2 || s.Entries != 2 {
+			t.Errorf("unexpected stats: %+v", s)
+		}
+	})
+
+	t.Run("invalidation", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			mutate func(*CachedStore)
+			check  func(model.URLRecord) bool
+		}{
+			{
+				name:   "mark_deleted",
+				mutate: func(s *CachedStore) { s.MarkURLsDeleted(ctx, "u1", []string{"abc"}) },
+				check:  func(r model.URLRecord) bool { return r.IsDeleted },
+			},
+			{
+				name:   "disabled",
+				mutate: func(s *CachedStore) { s.SetURLDisabled(ctx, "abc", true) },
+				check:  func(r model.URLRecord) bool { return r.IsDisabled },
+			},
+			{
+				name:   "moderation",
+				mutate: func(s *CachedStore) { s.SetURLModeration(ctx, "abc", model.ModerationBlocked, "spam") },
+				check:  func(r model.URLRecord) bool { return r.Moderation == model.ModerationBlocked },
+			},
+			{
+				name:   "merge_users",
+				mutate: func(s *CachedStore) { s.MergeUserURLs(ctx, "u1", "u2") },
+				check:  func(r model.URLRecord) bool { return r.UserID == "u2" },
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				cache, _, _ := newTestCache(t, CacheConfig{Size: 10, TTL: time.Hour})
+				cache.SaveURL(ctx, "abc", "https://example.com", "u1")
+				cache.GetURL(ctx, "abc")
+
+				tt.mutate(cache)
+
+				rec, err := cache.GetURL(ctx, "abc")
+				if err != nil || !tt.check(rec) {
+					t.Errorf("stale record after %s: %+v, %v", tt.name, rec, err)
+				}
+			})
+		}
+	})
+
+	t.Run("unwrap", func(t *testing.T) {
+		cache := NewCachedStore(NewInMemoryStore(), CacheConfig{Size: 10})
+		if _, ok := Unwrap(cache).(*InMemoryStore); !ok {
+			t.Errorf("Unwrap returned %T", Unwrap(cache))
+		}
+		if got := BackendName(cache); got != "memory" {
+			t.Errorf("BackendName = %q, want memory", got)
+		}
+	})
+}
@@ -93,7 +93,8 @@ func (s *DBStore) SaveURLs(ctx context.Context, urls map[string]string, userID s
 func (s *DBStore) GetURL(ctx context.Context, shortURL string) (model.URLRecord, error) {
 	var result model.URLRecord
 	err := s.pool.QueryRow(ctx,
-		`SELECT id, user_id, short_url, original_url, is_deleted FROM urls WHERE short_url = $1`,
+		`SELECT id, user_id, short_url, original_url, is_deleted, workspace_id, is_disabled, moderation_state, moderation_reason
+		 FROM urls WHERE short_url = $1`,
 		shortURL,
 	).Scan(
 		&result.ID,
@@ -101,8 +102,15 @@ func (s *DBStore) GetURL(ctx context.Context, shortURL string) (model.URLRecord,
 		&result.ShortURL,
 		&result.OriginalURL,
 		&result.IsDeleted,
+		&result.WorkspaceID,
+		&result.IsDisabled,
+		&result.Moderation,
+		&result.ModerationReason,
 	)
 
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.URLRecord{}, ErrStoreNotFound
+	}
 	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 		return model.URLRecord{}, fmt.Errorf("request canceled or timed out: %w", err)
 	}
@@ -114,7 +122,8 @@ func (s *DBStore) GetURL(ctx context.Context, shortURL string) (model.URLRecord,
 
 func (s *DBStore) GetUserURLs(ctx context.Context, userID string) ([]model.URLRecord, error) {
 	rows, err := s.pool.Query(ctx,
-		`SELECT id, short_url, original_url FROM urls WHERE user_id = $1`,
+		`SELECT id, user_id, short_url, original_url, is_deleted, workspace_id, is_disabled, moderation_state, moderation_reason
+		 FROM urls WHERE user_id = $1`,
 		userID,
 	)
 
@@ -126,7 +135,17 @@ func (s *DBStore) GetUserURLs(ctx context.Context, userID string) ([]model.URLRe
 	urls := []model.URLRecord{}
 	for rows.Next() {
 		var record model.URLRecord
-		if err := rows.Scan(&record.ID, &record.ShortURL, &record.OriginalURL); err != nil {
+		if err := rows.Scan(
+			&record.ID,
+			&record.UserID,
+			&record.ShortURL,
+			&record.OriginalURL,
+			&record.IsDeleted,
+			&record.WorkspaceID,
+			&record.IsDisabled,
+			&record.Moderation,
+			&record.ModerationReason,
+		); err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 		urls = append(urls, record)
@@ -147,7 +166,13 @@ func (s *DBStore) MarkURLsDeleted(ctx context.Context, userID string, shortURLs
 	query := `
         UPDATE urls
         SET is_deleted = TRUE
-        WHERE user_id = $1 AND short_url = ANY($2)
+        WHERE short_url = ANY($2) AND (
+            (workspace_id = '' AND user_id = $1)
+            OR workspace_id IN (
+                SELECT workspace_id FROM workspace_members
+                WHERE user_id = $1 AND role IN ('owner', 'editor')
+            )
+        )
     `
 	_, err := s.pool.Exec(ctx, query, userID, shortURLs)
 	if err != nil {
@@ -157,6 +182,485 @@ func (s *DBStore) MarkURLsDeleted(ctx context.Context, userID string, shortURLs
 	return nil
 }
 
+func (s *DBStore) SaveAPIKey(ctx context.Context, key model.APIKey) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO api_keys (id, user_id, name, prefix, key_hash, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)`,
+		key.ID, key.UserID, key.Name, key.Prefix, key.Hash, key.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save api key: %w", err)
+	}
+	return nil
+}
+
+func (s *DBStore) GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error) {
+	var key model.APIKey
+	err := s.pool.QueryRow(ctx,
+		`SELECT id, user_id, name, prefix, key_hash, created_at, revoked_at FROM api_keys WHERE key_hash = $1`,
+		hash,
+	).Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.Hash, &key.CreatedAt, &key.RevokedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.APIKey{}, ErrStoreNotFound
+	}
+	if err != nil {
+		return model.APIKey{}, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return key, nil
+}
+
+func (s *DBStore) GetUserAPIKeys(ctx context.Context, userID string) ([]model.APIKey, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, user_id, name, prefix, key_hash, created_at, revoked_at
+		 FROM api_keys WHERE user_id = $1 ORDER BY created_at`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []model.APIKey{}
+	for rows.Next() {
+		var key model.APIKey
+		if err := rows.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.Hash, &key.CreatedAt, &key.RevokedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (s *DBStore) RevokeAPIKey(ctx context.Context, userID, keyID string) error {
+	tag, err := s.pool.Exec(ctx,
+		`UPDATE api_keys SET revoked_at = COALESCE(revoked_at, now()) WHERE user_id = $1 AND id = $2`,
+		userID, keyID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStoreNotFound
+	}
+	return nil
+}
+
+func (s *DBStore) SaveUser(ctx context.Context, user model.User) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO users (id, login, password_hash, created_at) VALUES ($1, $2, $3, $4)`,
+		user.ID, user.Login, user.PasswordHash, user.CreatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return ErrStoreAlreadyExists
+		}
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+	return nil
+}
+
+func (s *DBStore) GetUser(ctx context.Context, userID string) (model.User, error) {
+	return s.getUser(ctx, `SELECT id, login, password_hash, created_at FROM users WHERE id = $1`, userID)
+}
+
+func (s *DBStore) GetUserByLogin(ctx context.Context, login string) (model.User, error) {
+	return s.getUser(ctx, `SELECT id, login, password_hash, created_at FROM users WHERE login = $1`, login)
+}
+
+func (s *DBStore) getUser(ctx context.Context, query string, arg string) (model.User, error) {
+	var user model.User
+	err := s.pool.QueryRow(ctx, query, arg).Scan(&user.ID, &user.Login, &user.PasswordHash, &user.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.User{}, ErrStoreNotFound
+	}
+	if err != nil {
+		return model.User{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+func (s *DBStore) MergeUserURLs(ctx context.Context, fromUserID, toUserID string) error {
+	_, err := s.pool.Exec(ctx, `UPDATE urls SET user_id = $2 WHERE user_id = $1`, fromUserID, toUserID)
+	if err != nil {
+		return fmt.Errorf("failed to merge user urls: %w", err)
+	}
+	return nil
+}
+
+func (s *DBStore) MergeUserAPIKeys(ctx context.Context, fromUserID, toUserID string) error {
+	_, err := s.pool.Exec(ctx, `UPDATE api_keys SET user_id = $2 WHERE user_id = $1`, fromUserID, toUserID)
+	if err != nil {
+		return fmt.Errorf("failed to merge user api keys: %w", err)
+	}
+	return nil
+}
+
+func (s *DBStore) SaveWorkspace(ctx context.Context, ws model.Workspace, ownerID string) error {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO workspaces (id, name, created_at) VALUES ($1, $2, $3)`,
+		ws.ID, ws.Name, ws.CreatedAt,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return ErrStoreAlreadyExists
+		}
+		return fmt.Errorf("failed to save workspace: %w", err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, $3)`,
+		ws.ID, ownerID, model.RoleOwner,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save workspace owner: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+	return nil
+}
+
+func (s *DBStore) GetWorkspace(ctx context.Context, workspaceID string) (model.Workspace, error) {
+	var ws model.Workspace
+	err := s.pool.QueryRow(ctx,
+		`SELECT id, name, created_at FROM workspaces WHERE id = $1`,
+		workspaceID,
+	).Scan(&ws.ID, &ws.Name, &ws.CreatedAt)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.Workspace{}, ErrStoreNotFound
+	}
+	if err != nil {
+		return model.Workspace{}, fmt.Errorf("failed to get workspace: %w", err)
+	}
+	return ws, nil
+}
+
+func (s *DBStore) GetUserWorkspaces(ctx context.Context, userID string) ([]model.UserWorkspace, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT w.id, w.name, w.created_at, m.role
+		 FROM workspaces w JOIN workspace_members m ON m.workspace_id = w.id
+		 WHERE m.user_id = $1 ORDER BY w.created_at`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workspaces: %w", err)
+	}
+	defer rows.Close()
+
+	result := []model.UserWorkspace{}
+	for rows.Next() {
+		var uw model.UserWorkspace
+		if err := rows.Scan(&uw.ID, &uw.Name, &uw.CreatedAt, &uw.Role); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		result = append(result, uw)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return result, nil
+}
+
+func (s *DBStore) GetWorkspaceMember(ctx context.Context, workspaceID, userID string) (model.WorkspaceMember, error) {
+	m := model.WorkspaceMember{WorkspaceID: workspaceID, UserID: userID}
+	err := s.pool.QueryRow(ctx,
+		`SELECT role FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`,
+		workspaceID, userID,
+	).Scan(&m.Role)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return model.WorkspaceMember{}, ErrStoreNotFound
+	}
+	if err != nil {
+		return model.WorkspaceMember{}, fmt.Errorf("failed to get workspace member: %w", err)
+	}
+	return m, nil
+}
+
+func (s *DBStore) GetWorkspaceMembers(ctx context.Context, workspaceID string) ([]model.WorkspaceMember, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT workspace_id, user_id, role FROM workspace_members WHERE workspace_id = $1`,
+		workspaceID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workspace members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []model.WorkspaceMember{}
+	for rows.Next() {
+		var m model.WorkspaceMember
+		if err := rows.Scan(&m.WorkspaceID, &m.UserID, &m.Role); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		members = append(members, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return members, nil
+}
+
+func (s *DBStore) SaveWorkspaceMember(ctx context.Context, member model.WorkspaceMember) error {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := dbCheckOwner(ctx, tx, member.WorkspaceID, member.UserID, member.Role); err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx,
+		`INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, $3)
+		 ON CONFLICT (workspace_id, user_id) DO UPDATE SET role = EXCLUDED.role`,
+		member.WorkspaceID, member.UserID, member.Role,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save workspace member: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+	return nil
+}
+
+func (s *DBStore) DeleteWorkspaceMember(ctx context.Context, workspaceID, userID string) error {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := dbCheckOwner(ctx, tx, workspaceID, userID, ""); err != nil {
+		return err
+	}
+	tag, err := tx.Exec(ctx,
+		`DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`,
+		workspaceID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete workspace member: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStoreNotFound
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+	return nil
+}
+
+// dbCheckOwner не даёт оставить пространство без владельца. Строка пространства
+// блокируется до конца транзакции, поэтому два понижения разных владельцев идут по очереди
+// и второе видит результат первого.
+func dbCheckOwner(ctx context.Context, tx pgx.Tx, workspaceID, userID string, role model.WorkspaceRole) error {
+	if role == model.RoleOwner {
+		return nil
+	}
+
+	if _, err := tx.Exec(ctx, `SELECT 1 FROM workspaces WHERE id = $1 FOR UPDATE`, workspaceID); err != nil {
+		return fmt.Errorf("failed to lock workspace: %w", err)
+	}
+
+	var others, self int
+	err := tx.QueryRow(ctx,
+		`SELECT count(*) FILTER (WHERE user_id <> $2), count(*) FILTER (WHERE user_id = $2)
+		 FROM workspace_members WHERE workspace_id = $1 AND role = $3`,
+		workspaceID, userID, model.RoleOwner,
+	).Scan(&others, &self)
+	if err != nil {
+		return fmt.Errorf("failed to count workspace owners: %w", err)
+	}
+	if self > 0 && others == 0 {
+		return ErrStoreLastOwner
+	}
+	return nil
+}
+
+func (s *DBStore) MoveURLsToWorkspace(ctx context.Context, userID, workspaceID string, shortURLs []string) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE urls SET workspace_id = $2
+		 WHERE user_id = $1 AND workspace_id = '' AND short_url = ANY($3)`,
+		userID, workspaceID, shortURLs,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to move urls to workspace: %w", err)
+	}
+	return nil
+}
+
+func (s *DBStore) GetWorkspaceURLs(ctx context.Context, workspaceID string) ([]model.URLRecord, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, user_id, short_url, original_url, is_deleted, workspace_id, is_disabled, moderation_state, moderation_reason
+		 FROM urls WHERE workspace_id = $1`,
+		workspaceID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workspace urls: %w", err)
+	}
+	defer rows.Close()
+
+	urls := []model.URLRecord{}
+	for rows.Next() {
+		var record model.URLRecord
+		if err := rows.Scan(
+			&record.ID,
+			&record.UserID,
+			&record.ShortURL,
+			&record.OriginalURL,
+			&record.IsDeleted,
+			&record.WorkspaceID,
+			&record.IsDisabled,
+			&record.Moderation,
+			&record.ModerationReason,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		urls = append(urls, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return urls, nil
+}
+
+func (s *DBStore) GetStats(ctx context.Context) (model.Stats, error) {
+	var stats model.Stats
+	err := s.pool.QueryRow(ctx, `
+		SELECT
+			(SELECT count(*) FROM urls WHERE NOT is_deleted),
+			(SELECT count(*) FROM (SELECT user_id FROM urls UNION SELECT id FROM users) AS u)
+	`).Scan(&stats.URLs, &stats.Users)
+	if err != nil {
+		return model.Stats{}, fmt.Errorf("failed to get stats: %w", err)
+	}
+	return stats, nil
+}
+
+func (s *DBStore) SetOriginalURL(ctx context.Context, shortURL, originalURL string) error {
+	tag, err := s.pool.Exec(ctx, `UPDATE urls SET original_url = $2 WHERE short_url = $1`, shortURL, originalURL)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			var existing string
+			if s.pool.QueryRow(ctx, `SELECT short_url FROM urls WHERE original_url = $1`, originalURL).Scan(&existing) == nil {
+				shortURL = existing
+			}
+			return NewErrStoreConflict(shortURL, originalURL, err)
+		}
+		return fmt.Errorf("failed to update url: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStoreNotFound
+	}
+	return nil
+}
+
+func (s *DBStore) SetURLDisabled(ctx context.Context, shortURL string, disabled bool) error {
+	tag, err := s.pool.Exec(ctx, `UPDATE urls SET is_disabled = $2 WHERE short_url = $1`, shortURL, disabled)
+	if err != nil {
+		return fmt.Errorf("failed to update url: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStoreNotFound
+	}
+	return nil
+}
+
+func (s *DBStore) SaveAbuseReport(ctx context.Context, report model.AbuseReport) error {
+	_, err := s.pool.Exec(ctx,
+		`INSERT INTO abuse_reports (id, short_url, reason, reporter_id, created_at) VALUES ($1, $2, $3, $4, $5)`,
+		report.ID, report.ShortURL, report.Reason, report.ReporterID, report.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save abuse report: %w", err)
+	}
+	return nil
+}
+
+func (s *DBStore) GetOpenAbuseReports(ctx context.Context) ([]model.AbuseReport, error) {
+	rows, err := s.pool.Query(ctx,
+		`SELECT id, short_url, reason, reporter_id, created_at
+		 FROM abuse_reports WHERE resolved_at IS NULL ORDER BY created_at`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query abuse reports: %w", err)
+	}
+	defer rows.Close()
+
+	reports := []model.AbuseReport{}
+	for rows.Next() {
+		var r model.AbuseReport
+		if err := rows.Scan(&r.ID, &r.ShortURL, &r.Reason, &r.ReporterID, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		reports = append(reports, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	return reports, nil
+}
+
+func (s *DBStore) SetURLModeration(ctx context.Context, shortURL string, state model.ModerationState, reason string) error {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE urls SET moderation_state = $2, moderation_reason = $3 WHERE short_url = $1`,
+		shortURL, state, reason,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update url moderation: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrStoreNotFound
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE abuse_reports SET resolved_at = now() WHERE short_url = $1 AND resolved_at IS NULL`,
+		shortURL,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to resolve abuse reports: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+	return nil
+}
+
 func (s *DBStore) Ping(ctx context.Context) error {
 	return s.pool.Ping(ctx)
 }
@@ -165,6 +669,33 @@ func (s *DBStore) Close() {
 	s.pool.Close()
 }
 
+// CheckSchemaVersion сверяет версию схемы, которую записал golang-migrate, с ожидаемой.
+// Более новая схема не ошибка: её могла накатить следующая версия сервиса во время выкатки.
+func (s *DBStore) CheckSchemaVersion(ctx context.Context, expected uint) error {
+	var version int64
+	var dirty bool
+	err := s.pool.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errors.New("no migrations applied")
+	}
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		return fmt.Errorf("migration %d failed and left the schema dirty", version)
+	}
+	if version < int64(expected) {
+		return fmt.Errorf("schema version %d, expected %d", version, expected)
+	}
+	return nil
+}
+
+// PoolStat — состояние пула соединений для метрик
+func (s *DBStore) PoolStat() *pgxpool.Stat {
+	return s.pool.Stat()
+}
+
 func NewDBStore(dsn string) (*DBStore, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+// TestDBStore гоняет общий набор на настоящем PostgreSQL, база очищается перед каждым подтестом:
+//
+//	TEST_DATABASE_DSN=postgres://... go test ./internal/repository -run TestDBStore
+func TestDBStore(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+	// ApplyMigrations читает каталог migrations относительно рабочей директории
+	t.Chdir("../..")
+
+	runStoreSuite(t, func(t *testing.T) Store {
+		store, err := NewDBStore(dsn)
+		if err != nil {
+			t.Fatalf("failed to create db store: %v", err)
+		}
+		t.Cleanup(store.Close)
+
+		_, err = store.pool.Exec(context.Background(),
+			`TRUNCATE urls, api_keys, users, workspaces, workspace_members, abuse_reports`)
+		if err != nil {
+			t.Fatalf("failed to clean db: %v", err)
+		}
+		return store
+	})
+}
@@ -1,30 +1,105 @@
 package repository
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/kayumovtd/url-shortener/internal/logger"
 	"github.com/kayumovtd/url-shortener/internal/model"
+	"go.uber.org/zap"
 )
 
+// FileStore держит данные в памяти, а на диск пишет журнал событий (JSON Lines): каждое
+// изменение дописывается в конец файла и сбрасывается fsync. При старте журнал проигрывается,
+// а когда разрастается — сжимается в один снимок, который атомарно подменяет файл.
 type FileStore struct {
-	mu      *sync.Mutex
-	records []model.URLRecord
-	path    string
+	mu      *sync.RWMutex
+	urls    *urlTable
+	apiKeys []model.APIKey
+	users   []model.User
+
+	workspaces []model.Workspace
+	members    []model.WorkspaceMember
+	reports    []model.AbuseReport
+	path       string
+	logger     *logger.Logger
+
+	log       logFile // открывается при первой записи
+	logSize   int64   // длина журнала из целых строк, до неё файл обрезается после сбоя записи
+	logEvents int     // событий в журнале после последнего снимка
+}
+
+// logFile — то, что журналу нужно от открытого файла
+type logFile interface {
+	Write(p []byte) (int, error)
+	Sync() error
+	Truncate(size int64) error
+	Close() error
+}
+
+// fileCompactMinEvents — журнал короче этого не сжимаем, даже если данных мало
+const fileCompactMinEvents = 1000
+
+// Операции журнала. Записи передаются целиком и при проигрывании заменяют прежние с тем же ключом;
+// перенос ссылок пользователя пишется одним событием, без списка ссылок.
+const (
+	opSnapshot     = "snapshot"
+	opURLs         = "urls"
+	opDeleteURLs   = "delete_urls"
+	opMergeUser    = "merge_user"
+	opAPIKey       = "api_key"
+	opUser         = "user"
+	opWorkspace    = "workspace"
+	opMember       = "member"
+	opDeleteMember = "delete_member"
+	opReports      = "reports"
+)
+
+// fileEvent — одна строка журнала, заполнено только поле для своей операции
+type fileEvent struct {
+	Op        string                 `json:"op"`
+	Snapshot  *fileData              `json:"snapshot,omitempty"`
+	URLs      []model.URLRecord      `json:"urls,omitempty"`
+	ShortURLs []string               `json:"short_urls,omitempty"`
+	FromUser  string                 `json:"from_user,omitempty"`
+	ToUser    string                 `json:"to_user,omitempty"`
+	APIKey    *model.APIKey          `json:"api_key,omitempty"`
+	User      *model.User            `json:"user,omitempty"`
+	Workspace *model.Workspace       `json:"workspace,omitempty"`
+	Member    *model.WorkspaceMember `json:"member,omitempty"`
+	Reports   []model.AbuseReport    `json:"reports,omitempty"`
+}
+
+// fileData — снимок всего хранилища. Так же, одним JSON-документом, выглядел файл до журнала,
+// а ещё раньше в нём лежал просто массив URLRecord — оба формата переводятся в журнал при старте.
+type fileData struct {
+	URLs    []model.URLRecord `json:"urls"`
+	APIKeys []model.APIKey    `json:"api_keys"`
+	Users   []model.User      `json:"users"`
+
+	Workspaces []model.Workspace       `json:"workspaces"`
+	Members    []model.WorkspaceMember `json:"workspace_members"`
+	Reports    []model.AbuseReport     `json:"abuse_reports"`
 }
 
 func (s *FileStore) SaveURL(ctx context.Context, shortURL, originalURL, userID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	for _, rec := range s.records {
-		if rec.OriginalURL == originalURL {
-			return NewErrStoreConflict(rec.ShortURL, rec.OriginalURL, nil)
-		}
+	if rec, ok := s.urls.getByOriginal(originalURL); ok {
+		return NewErrStoreConflict(rec.ShortURL, rec.OriginalURL, nil)
+	}
+	if _, ok := s.urls.get(shortURL); ok {
+		return NewErrStoreConflict(shortURL, originalURL, nil)
 	}
 
 	record := model.URLRecord{
@@ -34,16 +109,29 @@ func (s *FileStore) SaveURL(ctx context.Context, shortURL, originalURL, userID s
 		OriginalURL: originalURL,
 	}
 
-	s.records = append(s.records, record)
-	return s.save()
+	return s.commit(fileEvent{Op: opURLs, URLs: []model.URLRecord{record}})
 }
 
 func (s *FileStore) SaveURLs(ctx context.Context, urls map[string]string, userID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if len(urls) == 0 {
+		return nil
+	}
+	if err := s.urls.batchConflict(urls); err != nil {
+		return err
+	}
+
+	records := make([]model.URLRecord, 0, len(urls))
 	for short, original := range urls {
-		s.records = append(s.records, model.URLRecord{
+		// Существующий short обновляется, как ON CONFLICT в DBStore
+		if rec, ok := s.urls.get(short); ok {
+			rec.OriginalURL = original
+			records = append(records, rec)
+			continue
+		}
+		records = append(records, model.URLRecord{
 			ID:          uuid.NewString(),
 			UserID:      userID,
 			ShortURL:    short,
@@ -51,83 +139,714 @@ func (s *FileStore) SaveURLs(ctx context.Context, urls map[string]string, userID
 		})
 	}
 
-	return s.save()
+	return s.commit(fileEvent{Op: opURLs, URLs: records})
 }
 
 func (s *FileStore) GetURL(ctx context.Context, shortURL string) (model.URLRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if rec, ok := s.urls.get(shortURL); ok {
+		return rec, nil
+	}
+
+	return model.URLRecord{}, ErrStoreNotFound
+}
+
+func (s *FileStore) GetUserURLs(ctx context.Context, userID string) ([]model.URLRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.urls.userURLs(userID), nil
+}
+
+func (s *FileStore) MarkURLsDeleted(ctx context.Context, userID string, shortURLs []string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	for _, rec := range s.records {
-		if rec.ShortURL == shortURL {
-			return rec, nil
+	var deleted []string
+	for _, short := range shortURLs {
+		rec, ok := s.urls.get(short)
+		if ok && !rec.IsDeleted && canDeleteURL(rec, userID, s.members) {
+			deleted = append(deleted, short)
 		}
 	}
 
-	return model.URLRecord{}, errors.New("key not found")
+	if len(deleted) == 0 {
+		return nil
+	}
+	return s.commit(fileEvent{Op: opDeleteURLs, ShortURLs: deleted})
 }
 
-func (s *FileStore) GetUserURLs(ctx context.Context, userID string) ([]model.URLRecord, error) {
+func (s *FileStore) SaveAPIKey(ctx context.Context, key model.APIKey) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	urls := []model.URLRecord{}
-	for _, rec := range s.records {
-		if rec.UserID == userID {
-			urls = append(urls, rec)
+	return s.commit(fileEvent{Op: opAPIKey, APIKey: &key})
+}
+
+func (s *FileStore) GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, key := range s.apiKeys {
+		if key.Hash == hash {
+			return key, nil
 		}
 	}
 
-	return urls, nil
+	return model.APIKey{}, ErrStoreNotFound
 }
 
-func (s *FileStore) MarkURLsDeleted(ctx context.Context, userID string, shortURLs []string) error {
+func (s *FileStore) GetUserAPIKeys(ctx context.Context, userID string) ([]model.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := []model.APIKey{}
+	for _, key := range s.apiKeys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+func (s *FileStore) RevokeAPIKey(ctx context.Context, userID, keyID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	for i, rec := range s.records {
-		for _, shortURL := range shortURLs {
-			if rec.UserID == userID && rec.ShortURL == shortURL {
-				s.records[i].IsDeleted = true
+	for _, key := range s.apiKeys {
+		if key.UserID == userID && key.ID == keyID {
+			if key.RevokedAt != nil {
+				return nil
 			}
+			now := time.Now()
+			key.RevokedAt = &now
+			return s.commit(fileEvent{Op: opAPIKey, APIKey: &key})
 		}
 	}
 
-	return s.save()
+	return ErrStoreNotFound
 }
 
-func (s *FileStore) save() error {
-	data, err := json.MarshalIndent(s.records, "", "  ")
+func (s *FileStore) SaveUser(ctx context.Context, user model.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.Login == user.Login || u.ID == user.ID {
+			return ErrStoreAlreadyExists
+		}
+	}
+
+	return s.commit(fileEvent{Op: opUser, User: &user})
+}
+
+func (s *FileStore) GetUser(ctx context.Context, userID string) (model.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.ID == userID {
+			return u, nil
+		}
+	}
+
+	return model.User{}, ErrStoreNotFound
+}
+
+func (s *FileStore) GetUserByLogin(ctx context.Context, login string) (model.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.Login == login {
+			return u, nil
+		}
+	}
+
+	return model.User{}, ErrStoreNotFound
+}
+
+func (s *FileStore) MergeUserURLs(ctx context.Context, fromUserID, toUserID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if fromUserID == toUserID || len(s.urls.byUser[fromUserID]) == 0 {
+		return nil
+	}
+	return s.commit(fileEvent{Op: opMergeUser, FromUser: fromUserID, ToUser: toUserID})
+}
+
+func (s *FileStore) MergeUserAPIKeys(ctx context.Context, fromUserID, toUserID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if fromUserID == toUserID {
+		return nil
+	}
+	var events []fileEvent
+	for _, key := range s.apiKeys {
+		if key.UserID == fromUserID {
+			key.UserID = toUserID
+			events = append(events, fileEvent{Op: opAPIKey, APIKey: &key})
+		}
+	}
+	if len(events) == 0 {
+		return nil
+	}
+	return s.commit(events...)
+}
+
+func (s *FileStore) SaveWorkspace(ctx context.Context, ws model.Workspace, ownerID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, w := range s.workspaces {
+		if w.ID == ws.ID {
+			return ErrStoreAlreadyExists
+		}
+	}
+
+	return s.commit(
+		fileEvent{Op: opWorkspace, Workspace: &ws},
+		fileEvent{Op: opMember, Member: &model.WorkspaceMember{WorkspaceID: ws.ID, UserID: ownerID, Role: model.RoleOwner}},
+	)
+}
+
+func (s *FileStore) GetWorkspace(ctx context.Context, workspaceID string) (model.Workspace, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, ws := range s.workspaces {
+		if ws.ID == workspaceID {
+			return ws, nil
+		}
+	}
+
+	return model.Workspace{}, ErrStoreNotFound
+}
+
+func (s *FileStore) GetUserWorkspaces(ctx context.Context, userID string) ([]model.UserWorkspace, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []model.UserWorkspace{}
+	for _, m := range s.members {
+		if m.UserID != userID {
+			continue
+		}
+		for _, ws := range s.workspaces {
+			if ws.ID == m.WorkspaceID {
+				result = append(result, model.UserWorkspace{Workspace: ws, Role: m.Role})
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (s *FileStore) GetWorkspaceMember(ctx context.Context, workspaceID, userID string) (model.WorkspaceMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, m := range s.members {
+		if m.WorkspaceID == workspaceID && m.UserID == userID {
+			return m, nil
+		}
+	}
+
+	return model.WorkspaceMember{}, ErrStoreNotFound
+}
+
+func (s *FileStore) GetWorkspaceMembers(ctx context.Context, workspaceID string) ([]model.WorkspaceMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	members := []model.WorkspaceMember{}
+	for _, m := range s.members {
+		if m.WorkspaceID == workspaceID {
+			members = append(members, m)
+		}
+	}
+
+	return members, nil
+}
+
+func (s *FileStore) SaveWorkspaceMember(ctx context.Context, member model.WorkspaceMember) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if leavesNoOwner(s.members, member.WorkspaceID, member.UserID, member.Role) {
+		return ErrStoreLastOwner
+	}
+	return s.commit(fileEvent{Op: opMember, Member: &member})
+}
+
+func (s *FileStore) DeleteWorkspaceMember(ctx context.Context, workspaceID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, m := range s.members {
+		if m.WorkspaceID == workspaceID && m.UserID == userID {
+			if leavesNoOwner(s.members, workspaceID, userID, "") {
+				return ErrStoreLastOwner
+			}
+			return s.commit(fileEvent{Op: opDeleteMember, Member: &m})
+		}
+	}
+
+	return ErrStoreNotFound
+}
+
+func (s *FileStore) MoveURLsToWorkspace(ctx context.Context, userID, workspaceID string, shortURLs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var moved []model.URLRecord
+	for _, short := range shortURLs {
+		rec, ok := s.urls.get(short)
+		if ok && rec.UserID == userID && rec.WorkspaceID == "" {
+			rec.WorkspaceID = workspaceID
+			moved = append(moved, rec)
+		}
+	}
+
+	if len(moved) == 0 {
+		return nil
+	}
+	return s.commit(fileEvent{Op: opURLs, URLs: moved})
+}
+
+func (s *FileStore) GetWorkspaceURLs(ctx context.Context, workspaceID string) ([]model.URLRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.urls.workspaceURLs(workspaceID), nil
+}
+
+func (s *FileStore) GetStats(ctx context.Context) (model.Stats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return countStats(s.urls.records, s.users), nil
+}
+
+func (s *FileStore) SetOriginalURL(ctx context.Context, shortURL, originalURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, err := s.urls.retarget(shortURL, originalURL)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(s.path, data, 0644)
+	return s.commit(fileEvent{Op: opURLs, URLs: []model.URLRecord{rec}})
+}
+
+func (s *FileStore) SetURLDisabled(ctx context.Context, shortURL string, disabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.urls.get(shortURL)
+	if !ok {
+		return ErrStoreNotFound
+	}
+
+	rec.IsDisabled = disabled
+	return s.commit(fileEvent{Op: opURLs, URLs: []model.URLRecord{rec}})
+}
+
+func (s *FileStore) SaveAbuseReport(ctx context.Context, report model.AbuseReport) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.commit(fileEvent{Op: opReports, Reports: []model.AbuseReport{report}})
+}
+
+func (s *FileStore) GetOpenAbuseReports(ctx context.Context) ([]model.AbuseReport, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reports := []model.AbuseReport{}
+	for _, r := range s.reports {
+		if r.ResolvedAt == nil {
+			reports = append(reports, r)
+		}
+	}
+
+	return reports, nil
+}
+
+func (s *FileStore) SetURLModeration(ctx context.Context, shortURL string, state model.ModerationState, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.urls.get(shortURL)
+	if !ok {
+		return ErrStoreNotFound
+	}
+
+	rec.Moderation = state
+	rec.ModerationReason = reason
+
+	// Закрываем жалобы на копии, чтобы при ошибке записи память не разошлась с журналом
+	reports := slices.Clone(s.reports)
+	resolveReports(reports, shortURL, time.Now())
+	var resolved []model.AbuseReport
+	for j, r := range reports {
+		if r.ResolvedAt != nil && s.reports[j].ResolvedAt == nil {
+			resolved = append(resolved, r)
+		}
+	}
+
+	events := []fileEvent{{Op: opURLs, URLs: []model.URLRecord{rec}}}
+	if len(resolved) > 0 {
+		events = append(events, fileEvent{Op: opReports, Reports: resolved})
+	}
+	return s.commit(events...)
 }
 
 func (s *FileStore) Ping(ctx context.Context) error {
 	return nil
 }
 
-func (s *FileStore) Close() {}
+func (s *FileStore) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.log != nil {
+		s.log.Close()
+		s.log = nil
+	}
+}
+
+// CheckWritable проверяет, что журнал можно дописывать: сам файл, а пока его нет — каталог.
+// Файл не меняется.
+func (s *FileStore) CheckWritable() error {
+	f, err := os.OpenFile(s.path, os.O_WRONLY, 0)
+	if err == nil {
+		return f.Close()
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
 
-func NewFileStore(path string) (*FileStore, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".writable-*")
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	return os.Remove(tmp.Name())
+}
+
+func NewFileStore(path string, l *logger.Logger) (*FileStore, error) {
 	fs := &FileStore{
-		mu:      &sync.Mutex{},
-		records: []model.URLRecord{},
-		path:    path,
+		mu:      &sync.RWMutex{},
+		urls:    newURLTable(nil),
+		apiKeys: []model.APIKey{},
+		users:   []model.User{},
+
+		workspaces: []model.Workspace{},
+		members:    []model.WorkspaceMember{},
+		reports:    []model.AbuseReport{},
+		path:       path,
+		logger:     l,
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if legacy, ok := parseLegacyFile(data); ok {
+		fs.applySnapshot(legacy)
+		if err := fs.compact(); err != nil {
+			return nil, fmt.Errorf("failed to migrate %s to log format: %w", path, err)
+		}
+		return fs, nil
+	}
+
+	size, err := fs.replay(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay %s: %w", path, err)
+	}
+	fs.logSize = size
+	return fs, nil
+}
+
+// parseLegacyFile разбирает файл в формате до журнала: массив записей или один JSON-документ.
+// Журнал из нескольких строк не валиден как один документ, а однострочный отличается полем op.
+func parseLegacyFile(data []byte) (*fileData, bool) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || !json.Valid(data) {
+		return nil, false
+	}
+
+	if data[0] == '[' {
+		var records []model.URLRecord
+		if json.Unmarshal(data, &records) != nil {
+			return nil, false
+		}
+		return &fileData{URLs: records}, true
+	}
+
+	var probe struct {
+		Op string `json:"op"`
+	}
+	if json.Unmarshal(data, &probe) != nil || probe.Op != "" {
+		return nil, false
+	}
+	var fd fileData
+	if json.Unmarshal(data, &fd) != nil {
+		return nil, false
+	}
+	return &fd, true
+}
+
+// replay проигрывает журнал. Недописанная последняя строка — след падения посреди записи:
+// её отрезаем, а битая строка в середине — ошибка. Возвращает длину журнала после обрезки.
+func (s *FileStore) replay(data []byte) (int64, error) {
+	offset := 0
+	for line := 1; offset < len(data); line++ {
+		end := bytes.IndexByte(data[offset:], '\n')
+		if end < 0 {
+			return int64(offset), os.Truncate(s.path, int64(offset))
+		}
+
+		raw := bytes.TrimSpace(data[offset : offset+end])
+		offset += end + 1
+		if len(raw) == 0 {
+			continue
+		}
+
+		var ev fileEvent
+		if err := json.Unmarshal(raw, &ev); err != nil {
+			return 0, fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := s.apply(ev); err != nil {
+			return 0, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	return int64(offset), nil
+}
+
+// commit дописывает события в журнал и только после fsync применяет их к памяти
+func (s *FileStore) commit(events ...fileEvent) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, ev := range events {
+		if err := enc.Encode(ev); err != nil {
+			return err
+		}
 	}
 
-	if _, err := os.Stat(path); err == nil {
-		data, err := os.ReadFile(path)
+	if s.log == nil {
+		f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		if len(data) > 0 {
-			if err := json.Unmarshal(data, &fs.records); err != nil {
-				return nil, err
-			}
+		// Хвост от прошлой неудачной записи, если его не удалось отрезать сразу
+		if err := f.Truncate(s.logSize); err != nil {
+			f.Close()
+			return err
 		}
+		s.log = f
+	}
+	// Одна запись на все события: частичная строка в конце отрежется при старте
+	if _, err := s.log.Write(buf.Bytes()); err != nil {
+		s.discardTail()
+		return err
 	}
+	if err := s.log.Sync(); err != nil {
+		s.discardTail()
+		return err
+	}
+	s.logSize += int64(buf.Len())
 
-	return fs, nil
+	for _, ev := range events {
+		if err := s.apply(ev); err != nil {
+			return err
+		}
+	}
+
+	if s.logEvents > max(fileCompactMinEvents, len(s.urls.records)) {
+		// Запись уже на диске, неудачное сжатие её не отменяет. Повторим, когда журнал
+		// снова дорастёт до порога, а не на каждой записи
+		if err := s.compact(); err != nil {
+			s.logger.Error("failed to compact file store log", zap.String("path", s.path),
+				zap.Int("events", s.logEvents), zap.Error(err))
+			s.logEvents = 0
+		}
+	}
+	return nil
+}
+
+// discardTail отрезает то, что успело записаться при неудачной записи: иначе следующая порция
+// допишется к обрывку, и битая строка окажется посреди журнала. Если обрезать не вышло,
+// файл закрывается и обрезается при следующем открытии.
+func (s *FileStore) discardTail() {
+	if err := s.log.Truncate(s.logSize); err != nil {
+		s.log.Close()
+		s.log = nil
+	}
+}
+
+func (s *FileStore) apply(ev fileEvent) error {
+	s.logEvents++
+
+	switch ev.Op {
+	case opSnapshot:
+		if ev.Snapshot == nil {
+			return errors.New("snapshot event without data")
+		}
+		s.applySnapshot(ev.Snapshot)
+		s.logEvents = 0
+	case opURLs:
+		for _, rec := range ev.URLs {
+			s.urls.put(rec)
+		}
+	case opDeleteURLs:
+		for _, short := range ev.ShortURLs {
+			s.urls.update(short, func(r *model.URLRecord) { r.IsDeleted = true })
+		}
+	case opMergeUser:
+		s.urls.mergeUser(ev.FromUser, ev.ToUser)
+	case opAPIKey:
+		if ev.APIKey == nil {
+			return errors.New("api_key event without key")
+		}
+		s.apiKeys = upsert(s.apiKeys, *ev.APIKey, func(k model.APIKey) bool { return k.ID == ev.APIKey.ID })
+	case opUser:
+		if ev.User == nil {
+			return errors.New("user event without user")
+		}
+		s.users = upsert(s.users, *ev.User, func(u model.User) bool { return u.ID == ev.User.ID })
+	case opWorkspace:
+		if ev.Workspace == nil {
+			return errors.New("workspace event without workspace")
+		}
+		s.workspaces = upsert(s.workspaces, *ev.Workspace, func(w model.Workspace) bool { return w.ID == ev.Workspace.ID })
+	case opMember, opDeleteMember:
+		if ev.Member == nil {
+			return fmt.Errorf("%s event without member", ev.Op)
+		}
+		m := *ev.Member
+		same := func(o model.WorkspaceMember) bool { return o.WorkspaceID == m.WorkspaceID && o.UserID == m.UserID }
+		if ev.Op == opDeleteMember {
+			s.members = slices.DeleteFunc(s.members, same)
+		} else {
+			s.members = upsert(s.members, m, same)
+		}
+	case opReports:
+		for _, r := range ev.Reports {
+			s.reports = upsert(s.reports, r, func(o model.AbuseReport) bool { return o.ID == r.ID })
+		}
+	default:
+		return fmt.Errorf("unknown op %q", ev.Op)
+	}
+	return nil
+}
+
+// upsert заменяет первый элемент, для которого same вернула true, или добавляет v в конец
+func upsert[T any](items []T, v T, same func(T) bool) []T {
+	if i := slices.IndexFunc(items, same); i >= 0 {
+		items[i] = v
+		return items
+	}
+	return append(items, v)
+}
+
+func (s *FileStore) applySnapshot(fd *fileData) {
+	s.urls = newURLTable(fd.URLs)
+	s.apiKeys = orEmpty(fd.APIKeys)
+	s.users = orEmpty(fd.Users)
+	s.workspaces = orEmpty(fd.Workspaces)
+	s.members = orEmpty(fd.Members)
+	s.reports = orEmpty(fd.Reports)
+
+}
+
+func orEmpty[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+// Compact сжимает журнал в один снимок. Сам FileStore делает это, когда журнал становится
+// заметно длиннее данных; снаружи — например, перед резервным копированием файла.
+func (s *FileStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.compact()
+}
+
+// compact пишет снимок во временный файл рядом и подменяет им журнал: после падения на диске
+// остаётся либо старый журнал, либо новый снимок целиком
+func (s *FileStore) compact() error {
+	var buf bytes.Buffer
+	err := json.NewEncoder(&buf).Encode(fileEvent{Op: opSnapshot, Snapshot: &fileData{
+		URLs:    s.urls.records,
+		APIKeys: s.apiKeys,
+		Users:   s.users,
+
+		Workspaces: s.workspaces,
+		Members:    s.members,
+		Reports:    s.reports,
+	}})
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(s.path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".compact-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+	syncDir(dir)
+
+	// Старый дескриптор смотрит на заменённый файл, следующая запись откроет новый
+	if s.log != nil {
+		s.log.Close()
+		s.log = nil
+	}
+	s.logSize = int64(buf.Len())
+	s.logEvents = 0
+	return nil
+}
+
+// syncDir сбрасывает на диск запись каталога после rename. Не везде поддерживается, поэтому
+// ошибки не считаются: сам rename уже атомарен.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
 }
@@ -0,0 +1,293 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/kayumovtd/url-shortener/internal/logger"
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func openTestFileStore(t *testing.T, path string) *FileStore {
+	t.Helper()
+
+	store, err := NewFileStore(path, logger.NewNoOp())
+	if err != nil {
+		t.Fatalf("failed to open file store: %v", err)
+	}
+	t.Cleanup(store.Close)
+	return store
+}
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	return bytes.Count(data, []byte("\n"))
+}
+
+func TestFileStore(t *testing.T) {
+	runStoreSuite(t, func(t *testing.T) Store {
+		return openTestFileStore(t, filepath.Join(t.TempDir(), "storage.json"))
+	})
+}
+
+func TestFileStore_Replay(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "storage.json")
+
+	s := openTestFileStore(t, path)
+	s.SaveURLs(ctx, map[string]string{"a": "https://a.com", "b": "https://b.com"}, "anon")
+	s.MarkURLsDeleted(ctx, "anon", []string{"a"})
+	s.MergeUserURLs(ctx, "anon", "user")
+	s.SaveUser(ctx, model.User{ID: "user", Login: "alice"})
+	s.SaveWorkspace(ctx, model.Workspace{ID: "ws1", Name: "Team"}, "user")
+	s.SaveWorkspaceMember(ctx, model.WorkspaceMember{WorkspaceID: "ws1", UserID: "bob", Role: model.RoleEditor})
+	s.DeleteWorkspaceMember(ctx, "ws1", "bob")
+	s.SaveAbuseReport(ctx, model.AbuseReport{ID: "r1", ShortURL: "b", Reason: "spam"})
+	s.SetURLModeration(ctx, "b", model.ModerationBlocked, "phishing")
+	s.Close()
+
+	// Создание пространства и модерация пишут по два события
+	if n := countLines(t, path); n != 11 {
+		t.Errorf("expected 11 log lines, got %d", n)
+	}
+
+	r := openTestFileStore(t, path)
+	if rec, _ := r.GetURL(ctx, "a"); !rec.IsDeleted || rec.UserID != "user" {
+		t.Errorf("unexpected record a: %+v", rec)
+	}
+	if rec, _ := r.GetURL(ctx, "b"); rec.IsDeleted || rec.Moderation != model.ModerationBlocked {
+		t.Errorf("unexpected record b: %+v", rec)
+	}
+	if members, _ := r.GetWorkspaceMembers(ctx, "ws1"); len(members) != 1 || members[0].UserID != "user" {
+		t.Errorf("unexpected members: %+v", members)
+	}
+	if reports, _ := r.GetOpenAbuseReports(ctx); len(reports) != 0 {
+		t.Errorf("resolved report is still open: %+v", reports)
+	}
+	if stats, _ := r.GetStats(ctx); stats.URLs != 1 || stats.Users != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestFileStore_TornTail(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "storage.json")
+
+	s := openTestFileStore(t, path)
+	s.SaveURL(ctx, "a", "https://a.com", "u1")
+	s.Close()
+
+	// Падение посреди записи второй ссылки
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	f.WriteString(`{"op":"urls","urls":[{"short_url":"b","orig`)
+	f.Close()
+
+	r := openTestFileStore(t, path)
+	if _, err := r.GetURL(ctx, "a"); err != nil {
+		t.Fatalf("committed url lost: %v", err)
+	}
+	if _, err := r.GetURL(ctx, "b"); !errors.Is(err, ErrStoreNotFound) {
+		t.Errorf("torn record was replayed: %v", err)
+	}
+
+	r.SaveURL(ctx, "c", "https://c.com", "u1")
+	r.Close()
+	if _, err := NewFileStore(path, logger.NewNoOp()); err != nil {
+		t.Errorf("log is broken after appending past a torn tail: %v", err)
+	}
+}
+
+// failingLog дописывает половину порции и возвращает ошибку, как при нехватке места
+type failingLog struct {
+	logFile
+	failTruncate bool
+}
+
+func (f *failingLog) Write(p []byte) (int, error) {
+	n, _ := f.logFile.Write(p[:len(p)/2])
+	return n, syscall.ENOSPC
+}
+
+func (f *failingLog) Truncate(size int64) error {
+	if f.failTruncate {
+		return syscall.EIO
+	}
+	return f.logFile.Truncate(size)
+}
+
+func TestFileStore_FailedWrite(t *testing.T) {
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name         string
+		failTruncate bool
+	}{
+		{name: "truncate", failTruncate: false},
+		{name: "reopen", failTruncate: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "storage.json")
+
+			s := openTestFileStore(t, path)
+			s.SaveURL(ctx, "a", "https://a.com", "u1")
+
+			file := s.log
+			s.log = &failingLog{logFile: file, failTruncate: tc.failTruncate}
+			if err := s.SaveURL(ctx, "b", "https://b.com", "u1"); !errors.Is(err, syscall.ENOSPC) {
+				t.Fatalf("expected ENOSPC, got %v", err)
+			}
+			if _, err := s.GetURL(ctx, "b"); !errors.Is(err, ErrStoreNotFound) {
+				t.Errorf("failed write was applied: %v", err)
+			}
+			if s.log != nil {
+				s.log = file
+			}
+
+			if err := s.SaveURL(ctx, "c", "https://c.com", "u1"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			s.Close()
+
+			r := openTestFileStore(t, path)
+			for short, want := range map[string]error{"a": nil, "b": ErrStoreNotFound, "c": nil} {
+				if _, err := r.GetURL(ctx, short); !errors.Is(err, want) {
+					t.Errorf("GetURL(%q) = %v, want %v", short, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFileStore_CorruptedLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "storage.json")
+	os.WriteFile(path, []byte("{\"op\":\"urls\"}\nnot json\n{\"op\":\"urls\"}\n"), 0644)
+
+	if _, err := NewFileStore(path, logger.NewNoOp()); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected error pointing at line 2, got %v", err)
+	}
+}
+
+func TestFileStore_MigratesLegacyFormat(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{
+			name: "array",
+			data: `[{"id":"1","short_url":"abc","original_url":"https://example.com","user_id":"u1"}]`,
+		},
+		{
+			name: "document",
+			data: "{\n  \"urls\": [\n    {\"id\": \"1\", \"short_url\": \"abc\", \"original_url\": \"https://example.com\", \"user_id\": \"u1\"}\n  ],\n  \"users\": [{\"id\": \"u1\", \"login\": \"alice\"}]\n}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "storage.json")
+			os.WriteFile(path, []byte(tt.data), 0644)
+
+			s := openTestFileStore(t, path)
+			if rec, err := s.GetURL(ctx, "abc"); err != nil || rec.OriginalURL != "https://example.com" {
+				t.Fatalf("legacy record lost: %+v, %v", rec, err)
+			}
+			s.SaveURL(ctx, "xyz", "https://xyz.com", "u1")
+			s.Close()
+
+			if n := countLines(t, path); n != 2 {
+				t.Errorf("expected snapshot and one event, got %d lines", n)
+			}
+			r := openTestFileStore(t, path)
+			if urls, _ := r.GetUserURLs(ctx, "u1"); len(urls) != 2 {
+				t.Errorf("unexpected urls after migration: %+v", urls)
+			}
+		})
+	}
+}
+
+func TestFileStore_Compact(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "storage.json")
+
+	s := openTestFileStore(t, path)
+	s.SaveURL(ctx, "abc", "https://example.com", "u1")
+	for i := range fileCompactMinEvents {
+		s.SetURLDisabled(ctx, "abc", i%2 == 0)
+	}
+	if n := countLines(t, path); n > fileCompactMinEvents {
+		t.Errorf("log was not compacted automatically: %d lines", n)
+	}
+
+	s.SaveAPIKey(ctx, model.APIKey{ID: "k1", UserID: "u1", Hash: "h1", CreatedAt: time.Now()})
+	if err := s.Compact(); err != nil {
+		t.Fatalf("compact failed: %v", err)
+	}
+	if n := countLines(t, path); n != 1 {
+		t.Errorf("expected a single snapshot line, got %d", n)
+	}
+	if matches, _ := filepath.Glob(path + ".compact-*"); len(matches) != 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+
+	s.SaveUser(ctx, model.User{ID: "u1", Login: "alice"})
+	s.Close()
+
+	r := openTestFileStore(t, path)
+	if rec, _ := r.GetURL(ctx, "abc"); rec.IsDisabled {
+		t.Errorf("unexpected record after compaction: %+v", rec)
+	}
+	if _, err := r.GetAPIKeyByHash(ctx, "h1"); err != nil {
+		t.Errorf("api key lost after compaction: %v", err)
+	}
+	if _, err := r.GetUser(ctx, "u1"); err != nil {
+		t.Errorf("write after compaction lost: %v", err)
+	}
+}
+
+func TestFileStore_CompactFailureIsLogged(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "storage.json")
+
+	core, logs := observer.New(zap.ErrorLevel)
+	s, err := NewFileStore(path, &logger.Logger{Logger: zap.New(core)})
+	if err != nil {
+		t.Fatalf("failed to open file store: %v", err)
+	}
+	defer s.Close()
+	s.SaveURL(ctx, "abc", "https://example.com", "u1")
+
+	// Журнал уже открыт и пишется дальше, а подменить его снимком не даст непустой каталог на его месте
+	os.Remove(path)
+	os.Mkdir(path, 0755)
+	os.WriteFile(filepath.Join(path, "busy"), nil, 0644)
+
+	for i := range fileCompactMinEvents {
+		if err := s.SetURLDisabled(ctx, "abc", i%2 == 0); err != nil {
+			t.Fatalf("write failed because of compaction: %v", err)
+		}
+	}
+
+	entries := logs.FilterMessage("failed to compact file store log").All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d compaction error log entries, want 1", len(entries))
+	}
+	if entries[0].ContextMap()["path"] != path {
+		t.Errorf("path = %v, want %s", entries[0].ContextMap()["path"], path)
+	}
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kayumovtd/url-shortener/internal/model"
+)
+
+// IdempotencyStore хранит ответы на запросы с заголовком Idempotency-Key.
+// Reserve атомарно занимает ключ незавершённой записью с отпечатком запроса;
+// если ключ уже занят, возвращает существующую запись и false.
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (model.IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, key string, rec model.IdempotencyRecord, ttl time.Duration) error
+	Release(ctx context.Context, key string) error
+}
+
+type idempotencyEntry struct {
+	rec       model.IdempotencyRecord
+	expiresAt time.Time
+}
+
+type InMemoryIdempotencyStore struct {
+	mu        *sync.Mutex
+	entries   map[string]idempotencyEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewInMemoryIdempotencyStore() *InMemoryIdempotencyStore {
+	return &InMemoryIdempotencyStore{
+		mu:        &sync.Mutex{},
+		entries:   make(map[string]idempotencyEntry),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+func (s *InMemoryIdempotencyStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (model.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	if e, ok := s.entries[key]; ok && now.Before(e.expiresAt) {
+		return e.rec, false, nil
+	}
+
+	rec := model.IdempotencyRecord{Fingerprint: fingerprint}
+	s.entries[key] = idempotencyEntry{rec: rec, expiresAt: now.Add(ttl)}
+	return rec, true, nil
+}
+
+func (s *InMemoryIdempotencyStore) Complete(ctx context.Context, key string, rec model.IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec.Completed = true
+	s.entries[key] = idempotencyEntry{rec: rec, expiresAt: s.now().Add(ttl)}
+	return nil
+}
+
+func (s *InMemoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *InMemoryIdempotencyStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	for key, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	s.lastSweep = now
+}
@@ -2,29 +2,38 @@ package repository
 
 import (
 	"context"
-	"errors"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/kayumovtd/url-shortener/internal/model"
 )
 
+// InMemoryStore ищет ссылки по хеш-индексам urlTable, а чтение идёт под RLock:
+// редиректы не выстраиваются в очередь друг за другом, ждут только записи.
 type InMemoryStore struct {
-	mu      *sync.Mutex
-	records []model.URLRecord
+	mu      *sync.RWMutex
+	urls    *urlTable
+	apiKeys []model.APIKey
+	users   []model.User
+
+	workspaces []model.Workspace
+	members    []model.WorkspaceMember
+	reports    []model.AbuseReport
 }
 
 func (s *InMemoryStore) SaveURL(ctx context.Context, shortURL, originalURL, userID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	for _, rec := range s.records {
-		if rec.OriginalURL == originalURL {
-			return NewErrStoreConflict(rec.ShortURL, rec.OriginalURL, nil)
-		}
+	if rec, ok := s.urls.getByOriginal(originalURL); ok {
+		return NewErrStoreConflict(rec.ShortURL, rec.OriginalURL, nil)
+	}
+	if _, ok := s.urls.get(shortURL); ok {
+		return NewErrStoreConflict(shortURL, originalURL, nil)
 	}
 
-	s.records = append(s.records, model.URLRecord{
+	s.urls.put(model.URLRecord{
 		ID:          uuid.NewString(),
 		UserID:      userID,
 		ShortURL:    shortURL,
@@ -38,8 +47,17 @@ func (s *InMemoryStore) SaveURLs(ctx context.Context, urls map[string]string, us
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if err := s.urls.batchConflict(urls); err != nil {
+		return err
+	}
 	for short, original := range urls {
-		s.records = append(s.records, model.URLRecord{
+		// Существующий short обновляется, как ON CONFLICT в DBStore
+		if rec, ok := s.urls.get(short); ok {
+			rec.OriginalURL = original
+			s.urls.put(rec)
+			continue
+		}
+		s.urls.put(model.URLRecord{
 			ID:          uuid.NewString(),
 			UserID:      userID,
 			ShortURL:    short,
@@ -51,47 +69,346 @@ func (s *InMemoryStore) SaveURLs(ctx context.Context, urls map[string]string, us
 }
 
 func (s *InMemoryStore) GetURL(ctx context.Context, shortURL string) (model.URLRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if rec, ok := s.urls.get(shortURL); ok {
+		return rec, nil
+	}
+
+	return model.URLRecord{}, ErrStoreNotFound
+}
+
+func (s *InMemoryStore) GetUserURLs(ctx context.Context, userID string) ([]model.URLRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.urls.userURLs(userID), nil
+}
+
+func (s *InMemoryStore) MarkURLsDeleted(ctx context.Context, userID string, shortURLs []string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	for _, rec := range s.records {
-		if rec.ShortURL == shortURL {
-			return rec, nil
+	for _, short := range shortURLs {
+		rec, ok := s.urls.get(short)
+		if ok && canDeleteURL(rec, userID, s.members) {
+			s.urls.update(short, func(r *model.URLRecord) { r.IsDeleted = true })
 		}
 	}
 
-	return model.URLRecord{}, errors.New("key not found")
+	return nil
 }
 
-func (s *InMemoryStore) GetUserURLs(ctx context.Context, userID string) ([]model.URLRecord, error) {
+func (s *InMemoryStore) SaveAPIKey(ctx context.Context, key model.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.apiKeys = append(s.apiKeys, key)
+	return nil
+}
+
+func (s *InMemoryStore) GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, key := range s.apiKeys {
+		if key.Hash == hash {
+			return key, nil
+		}
+	}
+
+	return model.APIKey{}, ErrStoreNotFound
+}
+
+func (s *InMemoryStore) GetUserAPIKeys(ctx context.Context, userID string) ([]model.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := []model.APIKey{}
+	for _, key := range s.apiKeys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+func (s *InMemoryStore) RevokeAPIKey(ctx context.Context, userID, keyID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	urls := []model.URLRecord{}
-	for _, rec := range s.records {
-		if rec.UserID == userID {
-			urls = append(urls, rec)
+	for i, key := range s.apiKeys {
+		if key.UserID == userID && key.ID == keyID {
+			if key.RevokedAt == nil {
+				now := time.Now()
+				s.apiKeys[i].RevokedAt = &now
+			}
+			return nil
 		}
 	}
 
-	return urls, nil
+	return ErrStoreNotFound
 }
 
-func (s *InMemoryStore) MarkURLsDeleted(ctx context.Context, userID string, shortURLs []string) error {
+func (s *InMemoryStore) SaveUser(ctx context.Context, user model.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range s.users {
+		if u.Login == user.Login || u.ID == user.ID {
+			return ErrStoreAlreadyExists
+		}
+	}
+
+	s.users = append(s.users, user)
+	return nil
+}
+
+func (s *InMemoryStore) GetUser(ctx context.Context, userID string) (model.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.ID == userID {
+			return u, nil
+		}
+	}
+
+	return model.User{}, ErrStoreNotFound
+}
+
+func (s *InMemoryStore) GetUserByLogin(ctx context.Context, login string) (model.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.Login == login {
+			return u, nil
+		}
+	}
+
+	return model.User{}, ErrStoreNotFound
+}
+
+func (s *InMemoryStore) MergeUserURLs(ctx context.Context, fromUserID, toUserID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.urls.mergeUser(fromUserID, toUserID)
+	return nil
+}
+
+func (s *InMemoryStore) MergeUserAPIKeys(ctx context.Context, fromUserID, toUserID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, key := range s.apiKeys {
+		if key.UserID == fromUserID {
+			s.apiKeys[i].UserID = toUserID
+		}
+	}
+	return nil
+}
+
+func (s *InMemoryStore) SaveWorkspace(ctx context.Context, ws model.Workspace, ownerID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, w := range s.workspaces {
+		if w.ID == ws.ID {
+			return ErrStoreAlreadyExists
+		}
+	}
+
+	s.workspaces = append(s.workspaces, ws)
+	s.members = append(s.members, model.WorkspaceMember{WorkspaceID: ws.ID, UserID: ownerID, Role: model.RoleOwner})
+	return nil
+}
+
+func (s *InMemoryStore) GetWorkspace(ctx context.Context, workspaceID string) (model.Workspace, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, ws := range s.workspaces {
+		if ws.ID == workspaceID {
+			return ws, nil
+		}
+	}
+
+	return model.Workspace{}, ErrStoreNotFound
+}
+
+func (s *InMemoryStore) GetUserWorkspaces(ctx context.Context, userID string) ([]model.UserWorkspace, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []model.UserWorkspace{}
+	for _, m := range s.members {
+		if m.UserID != userID {
+			continue
+		}
+		for _, ws := range s.workspaces {
+			if ws.ID == m.WorkspaceID {
+				result = append(result, model.UserWorkspace{Workspace: ws, Role: m.Role})
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (s *InMemoryStore) GetWorkspaceMember(ctx context.Context, workspaceID, userID string) (model.WorkspaceMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, m := range s.members {
+		if m.WorkspaceID == workspaceID && m.UserID == userID {
+			return m, nil
+		}
+	}
+
+	return model.WorkspaceMember{}, ErrStoreNotFound
+}
+
+func (s *InMemoryStore) GetWorkspaceMembers(ctx context.Context, workspaceID string) ([]model.WorkspaceMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	members := []model.WorkspaceMember{}
+	for _, m := range s.members {
+		if m.WorkspaceID == workspaceID {
+			members = append(members, m)
+		}
+	}
+
+	return members, nil
+}
+
+func (s *InMemoryStore) SaveWorkspaceMember(ctx context.Context, member model.WorkspaceMember) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	for i, rec := range s.records {
-		for _, shortURL := range shortURLs {
-			if rec.UserID == userID && rec.ShortURL == shortURL {
-				s.records[i].IsDeleted = true
+	if leavesNoOwner(s.members, member.WorkspaceID, member.UserID, member.Role) {
+		return ErrStoreLastOwner
+	}
+	for i, m := range s.members {
+		if m.WorkspaceID == member.WorkspaceID && m.UserID == member.UserID {
+			s.members[i].Role = member.Role
+			return nil
+		}
+	}
+
+	s.members = append(s.members, member)
+	return nil
+}
+
+func (s *InMemoryStore) DeleteWorkspaceMember(ctx context.Context, workspaceID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, m := range s.members {
+		if m.WorkspaceID == workspaceID && m.UserID == userID {
+			if leavesNoOwner(s.members, workspaceID, userID, "") {
+				return ErrStoreLastOwner
 			}
+			s.members = append(s.members[:i], s.members[i+1:]...)
+			return nil
+		}
+	}
+
+	return ErrStoreNotFound
+}
+
+func (s *InMemoryStore) MoveURLsToWorkspace(ctx context.Context, userID, workspaceID string, shortURLs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, short := range shortURLs {
+		rec, ok := s.urls.get(short)
+		if ok && rec.UserID == userID && rec.WorkspaceID == "" {
+			rec.WorkspaceID = workspaceID
+			s.urls.put(rec)
 		}
 	}
 
 	return nil
 }
 
+func (s *InMemoryStore) GetWorkspaceURLs(ctx context.Context, workspaceID string) ([]model.URLRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.urls.workspaceURLs(workspaceID), nil
+}
+
+func (s *InMemoryStore) GetStats(ctx context.Context) (model.Stats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return countStats(s.urls.records, s.users), nil
+}
+
+func (s *InMemoryStore) SetOriginalURL(ctx context.Context, shortURL, originalURL string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, err := s.urls.retarget(shortURL, originalURL)
+	if err != nil {
+		return err
+	}
+	s.urls.put(rec)
+	return nil
+}
+
+func (s *InMemoryStore) SetURLDisabled(ctx context.Context, shortURL string, disabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.urls.update(shortURL, func(r *model.URLRecord) { r.IsDisabled = disabled }) {
+		return ErrStoreNotFound
+	}
+	return nil
+}
+
+func (s *InMemoryStore) SaveAbuseReport(ctx context.Context, report model.AbuseReport) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reports = append(s.reports, report)
+	return nil
+}
+
+func (s *InMemoryStore) GetOpenAbuseReports(ctx context.Context) ([]model.AbuseReport, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reports := []model.AbuseReport{}
+	for _, r := range s.reports {
+		if r.ResolvedAt == nil {
+			reports = append(reports, r)
+		}
+	}
+
+	return reports, nil
+}
+
+func (s *InMemoryStore) SetURLModeration(ctx context.Context, shortURL string, state model.ModerationState, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ok := s.urls.update(shortURL, func(r *model.URLRecord) {
+		r.Moderation = state
+		r.ModerationReason = reason
+	})
+	if !ok {
+		return ErrStoreNotFound
+	}
+	resolveReports(s.reports, shortURL, time.Now())
+	return nil
+}
+
 func (s *InMemoryStore) Ping(ctx context.Context) error {
 	return nil
 }
@@ -100,7 +417,13 @@ func (s *InMemoryStore) Close() {}
 
 func NewInMemoryStore() *InMemoryStore {
 	return &InMemoryStore{
-		mu:      &sync.Mutex{},
-		records: []model.URLRecord{},
+		mu:      &sync.RWMutex{},
+		urls:    newURLTable(nil),
+		apiKeys: []model.APIKey{},
+		users:   []model.User{},
+
+		workspaces: []model.Workspace{},
+		members:    []model.WorkspaceMember{},
+		reports:    []model.AbuseReport{},
 	}
 }
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/kayumovtd/url-shortener/internal/model"
+)
+
+func shortURLsOf(urls []model.URLRecord) []string {
+	result := make([]string, 0, len(urls))
+	for _, u := range urls {
+		result = append(result, u.ShortURL)
+	}
+	return result
+}
+
+func TestInMemoryStore(t *testing.T) {
+	runStoreSuite(t, func(t *testing.T) Store { return NewInMemoryStore() })
+}
+
+func TestInMemoryStore_Indexes(t *testing.T) {
+	ctx := context.Background()
+	s := NewInMemoryStore()
+
+	s.SaveURL(ctx, "a", "https://a.com", "anon")
+	s.SaveURL(ctx, "b", "https://b.com", "user")
+	s.SaveURL(ctx, "c", "https://c.com", "anon")
+
+	var conflict *ErrStoreConflict
+	if err := s.SaveURL(ctx, "d", "https://a.com", "user"); !errors.As(err, &conflict) || conflict.ShortURL != "a" {
+		t.Errorf("expected conflict with a, got %v", err)
+	}
+	if err := s.SaveURL(ctx, "a", "https://other.com", "user"); !errors.As(err, &conflict) {
+		t.Errorf("taken short url was overwritten: %v", err)
+	}
+
+	// Повторное сохранение short меняет original_url и индекс по нему
+	s.SaveURLs(ctx, map[string]string{"b": "https://b2.com"}, "user")
+	if err := s.SaveURL(ctx, "e", "https://b.com", "user"); err != nil {
+		t.Errorf("stale original url index: %v", err)
+	}
+
+	s.MergeUserURLs(ctx, "anon", "user")
+	urls, _ := s.GetUserURLs(ctx, "user")
+	if got := shortURLsOf(urls); len(got) != 4 || got[0] != "a" || got[1] != "b" || got[2] != "c" || got[3] != "e" {
+		t.Errorf("user urls are not in insertion order after merge: %v", got)
+	}
+	if urls, _ := s.GetUserURLs(ctx, "anon"); len(urls) != 0 {
+		t.Errorf("anonymous user still has urls: %v", shortURLsOf(urls))
+	}
+
+	s.MoveURLsToWorkspace(ctx, "user", "ws1", []string{"c", "a", "missing"})
+	urls, _ = s.GetWorkspaceURLs(ctx, "ws1")
+	if got := shortURLsOf(urls); len(got) != 2 || got[0] != "a" || got[1] != "c" {
+		t.Errorf("unexpected workspace urls: %v", got)
+	}
+
+	s.MarkURLsDeleted(ctx, "user", []string{"b", "c"})
+	if rec, _ := s.GetURL(ctx, "b"); !rec.IsDeleted {
+		t.Error("personal url was not deleted")
+	}
+	if rec, _ := s.GetURL(ctx, "c"); rec.IsDeleted {
+		t.Error("workspace url was deleted without membership")
+	}
+}
+
+func TestInMemoryStore_ConcurrentAccess(t *testing.T) {
+	ctx := context.Background()
+	s := NewInMemoryStore()
+
+	var wg sync.WaitGroup
+	for w := range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				id := strconv.Itoa(w*1000 + i)
+				s.SaveURL(ctx, "s"+id, "https://example.com/"+id, "u"+strconv.Itoa(w))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				s.GetURL(ctx, "s"+strconv.Itoa(w*1000+i))
+				s.GetUserURLs(ctx, "u"+strconv.Itoa(w))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if stats, _ := s.GetStats(ctx); stats.URLs != 800 || stats.Users != 4 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
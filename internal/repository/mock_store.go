@@ -3,6 +3,8 @@ package repository
 import (
 	"context"
 	"errors"
+	"slices"
+	"time"
 
 	"github.com/kayumovtd/url-shortener/internal/model"
 )
@@ -17,7 +19,14 @@ const (
 
 // TODO: Заюзать gomock
 type MockStore struct {
-	Data      []model.URLRecord
+	Data    []model.URLRecord
+	APIKeys []model.APIKey
+	Users   []model.User
+
+	Workspaces []model.Workspace
+	Members    []model.WorkspaceMember
+	Reports    []model.AbuseReport
+
 	ErrorType MockErrorType
 }
 
@@ -56,7 +65,7 @@ func (f *MockStore) GetURL(ctx context.Context, shortURL string) (model.URLRecor
 		}
 	}
 
-	return model.URLRecord{}, errors.New("key not found")
+	return model.URLRecord{}, ErrStoreNotFound
 }
 
 func (f *MockStore) GetUserURLs(ctx context.Context, userID string) ([]model.URLRecord, error) {
@@ -71,17 +80,276 @@ func (f *MockStore) GetUserURLs(ctx context.Context, userID string) ([]model.URL
 }
 
 func (f *MockStore) MarkURLsDeleted(ctx context.Context, userID string, shortURLs []string) error {
+	if f.ErrorType == SomeError {
+		return errors.New("some error")
+	}
+
 	for i, rec := range f.Data {
-		for _, shortURL := range shortURLs {
-			if rec.UserID == userID && rec.ShortURL == shortURL {
-				f.Data[i].IsDeleted = true
+		if slices.Contains(shortURLs, rec.ShortURL) && canDeleteURL(rec, userID, f.Members) {
+			f.Data[i].IsDeleted = true
+		}
+	}
+
+	return nil
+}
+
+func (f *MockStore) SaveAPIKey(ctx context.Context, key model.APIKey) error {
+	if f.ErrorType == SomeError {
+		return errors.New("some error")
+	}
+	f.APIKeys = append(f.APIKeys, key)
+	return nil
+}
+
+func (f *MockStore) GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error) {
+	for _, key := range f.APIKeys {
+		if key.Hash == hash {
+			return key, nil
+		}
+	}
+
+	return model.APIKey{}, ErrStoreNotFound
+}
+
+func (f *MockStore) GetUserAPIKeys(ctx context.Context, userID string) ([]model.APIKey, error) {
+	keys := []model.APIKey{}
+	for _, key := range f.APIKeys {
+		if key.UserID == userID {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+func (f *MockStore) RevokeAPIKey(ctx context.Context, userID, keyID string) error {
+	for i, key := range f.APIKeys {
+		if key.UserID == userID && key.ID == keyID {
+			now := time.Now()
+			f.APIKeys[i].RevokedAt = &now
+			return nil
+		}
+	}
+
+	return ErrStoreNotFound
+}
+
+func (f *MockStore) SaveUser(ctx context.Context, user model.User) error {
+	for _, u := range f.Users {
+		if u.Login == user.Login {
+			return ErrStoreAlreadyExists
+		}
+	}
+	f.Users = append(f.Users, user)
+	return nil
+}
+
+func (f *MockStore) GetUser(ctx context.Context, userID string) (model.User, error) {
+	for _, u := range f.Users {
+		if u.ID == userID {
+			return u, nil
+		}
+	}
+
+	return model.User{}, ErrStoreNotFound
+}
+
+func (f *MockStore) GetUserByLogin(ctx context.Context, login string) (model.User, error) {
+	for _, u := range f.Users {
+		if u.Login == login {
+			return u, nil
+		}
+	}
+
+	return model.User{}, ErrStoreNotFound
+}
+
+func (f *MockStore) MergeUserURLs(ctx context.Context, fromUserID, toUserID string) error {
+	for i, rec := range f.Data {
+		if rec.UserID == fromUserID {
+			f.Data[i].UserID = toUserID
+		}
+	}
+
+	return nil
+}
+
+func (f *MockStore) MergeUserAPIKeys(ctx context.Context, fromUserID, toUserID string) error {
+	for i, key := range f.APIKeys {
+		if key.UserID == fromUserID {
+			f.APIKeys[i].UserID = toUserID
+		}
+	}
+
+	return nil
+}
+
+func (f *MockStore) SaveWorkspace(ctx context.Context, ws model.Workspace, ownerID string) error {
+	f.Workspaces = append(f.Workspaces, ws)
+	f.Members = append(f.Members, model.WorkspaceMember{WorkspaceID: ws.ID, UserID: ownerID, Role: model.RoleOwner})
+	return nil
+}
+
+func (f *MockStore) GetWorkspace(ctx context.Context, workspaceID string) (model.Workspace, error) {
+	for _, ws := range f.Workspaces {
+		if ws.ID == workspaceID {
+			return ws, nil
+		}
+	}
+
+	return model.Workspace{}, ErrStoreNotFound
+}
+
+func (f *MockStore) GetUserWorkspaces(ctx context.Context, userID string) ([]model.UserWorkspace, error) {
+	result := []model.UserWorkspace{}
+	for _, m := range f.Members {
+		if m.UserID != userID {
+			continue
+		}
+		for _, ws := range f.Workspaces {
+			if ws.ID == m.WorkspaceID {
+				result = append(result, model.UserWorkspace{Workspace: ws, Role: m.Role})
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (f *MockStore) GetWorkspaceMember(ctx context.Context, workspaceID, userID string) (model.WorkspaceMember, error) {
+	for _, m := range f.Members {
+		if m.WorkspaceID == workspaceID && m.UserID == userID {
+			return m, nil
+		}
+	}
+
+	return model.WorkspaceMember{}, ErrStoreNotFound
+}
+
+func (f *MockStore) GetWorkspaceMembers(ctx context.Context, workspaceID string) ([]model.WorkspaceMember, error) {
+	members := []model.WorkspaceMember{}
+	for _, m := range f.Members {
+		if m.WorkspaceID == workspaceID {
+			members = append(members, m)
+		}
+	}
+
+	return members, nil
+}
+
+func (f *MockStore) SaveWorkspaceMember(ctx context.Context, member model.WorkspaceMember) error {
+	if leavesNoOwner(f.Members, member.WorkspaceID, member.UserID, member.Role) {
+		return ErrStoreLastOwner
+	}
+	for i, m := range f.Members {
+		if m.WorkspaceID == member.WorkspaceID && m.UserID == member.UserID {
+			f.Members[i].Role = member.Role
+			return nil
+		}
+	}
+
+	f.Members = append(f.Members, member)
+	return nil
+}
+
+func (f *MockStore) DeleteWorkspaceMember(ctx context.Context, workspaceID, userID string) error {
+	for i, m := range f.Members {
+		if m.WorkspaceID == workspaceID && m.UserID == userID {
+			if leavesNoOwner(f.Members, workspaceID, userID, "") {
+				return ErrStoreLastOwner
 			}
+			f.Members = append(f.Members[:i], f.Members[i+1:]...)
+			return nil
+		}
+	}
+
+	return ErrStoreNotFound
+}
+
+func (f *MockStore) MoveURLsToWorkspace(ctx context.Context, userID, workspaceID string, shortURLs []string) error {
+	for i, rec := range f.Data {
+		if rec.UserID == userID && rec.WorkspaceID == "" && slices.Contains(shortURLs, rec.ShortURL) {
+			f.Data[i].WorkspaceID = workspaceID
 		}
 	}
 
 	return nil
 }
 
+func (f *MockStore) GetWorkspaceURLs(ctx context.Context, workspaceID string) ([]model.URLRecord, error) {
+	urls := []model.URLRecord{}
+	for _, rec := range f.Data {
+		if rec.WorkspaceID == workspaceID {
+			urls = append(urls, rec)
+		}
+	}
+
+	return urls, nil
+}
+
+func (f *MockStore) GetStats(ctx context.Context) (model.Stats, error) {
+	return countStats(f.Data, f.Users), nil
+}
+
+func (f *MockStore) SetOriginalURL(ctx context.Context, shortURL, originalURL string) error {
+	if f.ErrorType == SomeError {
+		return errors.New("some error")
+	}
+	for _, rec := range f.Data {
+		if rec.OriginalURL == originalURL && rec.ShortURL != shortURL {
+			return NewErrStoreConflict(rec.ShortURL, originalURL, nil)
+		}
+	}
+	for i, rec := range f.Data {
+		if rec.ShortURL == shortURL {
+			f.Data[i].OriginalURL = originalURL
+			return nil
+		}
+	}
+
+	return ErrStoreNotFound
+}
+
+func (f *MockStore) SetURLDisabled(ctx context.Context, shortURL string, disabled bool) error {
+	for i, rec := range f.Data {
+		if rec.ShortURL == shortURL {
+			f.Data[i].IsDisabled = disabled
+			return nil
+		}
+	}
+
+	return ErrStoreNotFound
+}
+
+func (f *MockStore) SaveAbuseReport(ctx context.Context, report model.AbuseReport) error {
+	f.Reports = append(f.Reports, report)
+	return nil
+}
+
+func (f *MockStore) GetOpenAbuseReports(ctx context.Context) ([]model.AbuseReport, error) {
+	reports := []model.AbuseReport{}
+	for _, r := range f.Reports {
+		if r.ResolvedAt == nil {
+			reports = append(reports, r)
+		}
+	}
+
+	return reports, nil
+}
+
+func (f *MockStore) SetURLModeration(ctx context.Context, shortURL string, state model.ModerationState, reason string) error {
+	for i, rec := range f.Data {
+		if rec.ShortURL == shortURL {
+			f.Data[i].Moderation = state
+			f.Data[i].ModerationReason = reason
+			resolveReports(f.Reports, shortURL, time.Now())
+			return nil
+		}
+	}
+
+	return ErrStoreNotFound
+}
+
 func (f *MockStore) Ping(ctx context.Context) error {
 	return nil
 }
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimitStore хранит состояние token bucket'ов.
+// Take атомарно списывает один токен из корзины key и, если токенов нет,
+// возвращает время, через которое он появится.
+// Интерфейс держим минимальным, чтобы потом можно было вынести состояние в общий для инстансов сторадж.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit int, period time.Duration) (bool, time.Duration, error)
+}
+
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+	period   time.Duration
+}
+
+type InMemoryRateLimitStore struct {
+	mu        *sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+	idleTTL   time.Duration
+	now       func() time.Time
+}
+
+func NewInMemoryRateLimitStore() *InMemoryRateLimitStore {
+	return &InMemoryRateLimitStore{
+		mu:        &sync.Mutex{},
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+		idleTTL:   10 * time.Minute,
+		now:       time.Now,
+	}
+}
+
+func (s *InMemoryRateLimitStore) Take(ctx context.Context, key string, limit int, period time.Duration) (bool, time.Duration, error) {
+	if limit <= 0 || period <= 0 {
+		return true, 0, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	rate := float64(limit) / period.Seconds() // токенов в секунду
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(limit), lastSeen: now, period: period}
+		s.buckets[key] = b
+	}
+
+	elapsed := now.Sub(b.lastSeen).Seconds()
+	b.tokens = math.Min(float64(limit), b.tokens+elapsed*rate)
+	b.lastSeen = now
+	b.period = period
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+
+	wait := time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	return false, wait, nil
+}
+
+// sweep выкидывает давно неиспользуемые корзины, чтобы map не росла бесконечно.
+// Удаляем только те, что успели наполниться полностью, иначе сбросили бы лимит раньше времени.
+func (s *InMemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.idleTTL {
+		return
+	}
+	for key, b := range s.buckets {
+		idle := now.Sub(b.lastSeen)
+		if idle >= s.idleTTL && idle >= b.period {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
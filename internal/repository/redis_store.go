@@ -0,0 +1,830 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"github.com/redis/go-redis/v9"
+)
+
+// Все ключи хранилища начинаются с redisKeyPrefix:
+//
+//	url:<short>                  hash  ссылка
+//	urls:by_original             hash  original_url -> short, держит уникальность исходных URL
+//	urls:active                  int   ссылки без пометки об удалении, для GetStats
+//	url_authors                  set   авторы ссылок, для GetStats
+//	user_urls:<user>             set   ссылки автора
+//	workspace_urls:<ws>          set   ссылки рабочего пространства
+//	apikey:<id>                  hash  API-ключ; apikeys:by_hash: hash -> id; user_apikeys:<user>: set id
+//	user:<id>                    hash  пользователь; users:by_login: login -> id; users: set id
+//	workspace:<id>               hash  рабочее пространство
+//	workspace_members:<ws>       hash  user -> role; user_workspaces:<user>: set ws
+//	report:<id>                  hash  жалоба; reports:open: set id; reports:open_by_url:<short>: set id
+//
+// Скрипты вычисляют ключи сами, поэтому Redis Cluster не поддерживается — только один
+// инстанс (в том числе за Sentinel).
+const redisKeyPrefix = "shortener:"
+
+const (
+	redisTrue  = "1"
+	redisFalse = "0"
+)
+
+func redisKey(parts ...string) string {
+	return redisKeyPrefix + strings.Join(parts, ":")
+}
+
+func redisBool(b bool) string {
+	if b {
+		return redisTrue
+	}
+	return redisFalse
+}
+
+func redisTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func parseRedisTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return t
+}
+
+func parseRedisTimePtr(s string) *time.Time {
+	if s == "" {
+		return nil
+	}
+	t := parseRedisTime(s)
+	return &t
+}
+
+// saveURLsScript сохраняет пачку ссылок целиком или не сохраняет ничего.
+// ARGV: префикс, автор, strict, затем тройки short, original, id.
+// strict (SaveURL) — конфликт и при занятом short, и при уже известном original.
+// Без strict (SaveURLs) существующий short обновляется, как ON CONFLICT в DBStore.
+var saveURLsScript = redis.NewScript(`
+local p, uid, strict = ARGV[1], ARGV[2], ARGV[3] == '1'
+local byOriginal = p .. 'urls:by_original'
+
+local seen = {}
+for i = 4, #ARGV, 3 do
+	local short, orig = ARGV[i], ARGV[i + 1]
+	local owner = redis.call('HGET', byOriginal, orig) or seen[orig]
+	if owner and (strict or owner ~= short) then
+		return {'conflict', owner, orig}
+	end
+	if strict and redis.call('EXISTS', p .. 'url:' .. short) == 1 then
+		return {'conflict', short, orig}
+	end
+	seen[orig] = short
+end
+
+for i = 4, #ARGV, 3 do
+	local short, orig, id = ARGV[i], ARGV[i + 1], ARGV[i + 2]
+	local key = p .. 'url:' .. short
+	local old = redis.call('HGET', key, 'original_url')
+	if old then
+		if old ~= orig then
+			redis.call('HDEL', byOriginal, old)
+			redis.call('HSET', key, 'original_url', orig)
+			redis.call('HSET', byOriginal, orig, short)
+		end
+	else
+		redis.call('HSET', key, 'id', id, 'user_id', uid, 'short_url', short, 'original_url', orig,
+			'is_deleted', '0', 'workspace_id', '', 'is_disabled', '0', 'moderation', '', 'moderation_reason', '')
+		redis.call('HSET', byOriginal, orig, short)
+		redis.call('SADD', p .. 'user_urls:' .. uid, short)
+		redis.call('SADD', p .. 'url_authors', uid)
+		redis.call('INCR', p .. 'urls:active')
+	end
+end
+return {'ok'}
+`)
+
+// markDeletedScript — права те же, что в canDeleteURL: роли с правом редактирования — owner и editor.
+// ARGV: префикс, пользователь, затем short.
+var markDeletedScript = redis.NewScript(`
+local p, uid = ARGV[1], ARGV[2]
+local n = 0
+for i = 3, #ARGV do
+	local key = p .. 'url:' .. ARGV[i]
+	local f = redis.call('HMGET', key, 'user_id', 'workspace_id', 'is_deleted')
+	if f[1] and f[3] == '0' then
+		local allowed
+		if f[2] == '' then
+			allowed = f[1] == uid
+		else
+			local role = redis.call('HGET', p .. 'workspace_members:' .. f[2], uid)
+			allowed = role == 'owner' or role == 'editor'
+		end
+		if allowed then
+			redis.call('HSET', key, 'is_deleted', '1')
+			redis.call('DECR', p .. 'urls:active')
+			n = n + 1
+		end
+	end
+end
+return n
+`)
+
+// ARGV: префикс, from, to
+var mergeUserURLsScript = redis.NewScript(`
+local p, from, to = ARGV[1], ARGV[2], ARGV[3]
+if from == to then
+	return 0
+end
+local src = p .. 'user_urls:' .. from
+local shorts = redis.call('SMEMBERS', src)
+for _, short in ipairs(shorts) do
+	redis.call('HSET', p .. 'url:' .. short, 'user_id', to)
+	redis.call('SADD', p .. 'user_urls:' .. to, short)
+end
+redis.call('DEL', src)
+if #shorts > 0 then
+	redis.call('SREM', p .. 'url_authors', from)
+	redis.call('SADD', p .. 'url_authors', to)
+end
+return #shorts
+`)
+
+// ARGV: префикс, from, to
+var mergeUserAPIKeysScript = redis.NewScript(`
+local p, from, to = ARGV[1], ARGV[2], ARGV[3]
+if from == to then
+	return 0
+end
+local src = p .. 'user_apikeys:' .. from
+local ids = redis.call('SMEMBERS', src)
+for _, id in ipairs(ids) do
+	redis.call('HSET', p .. 'apikey:' .. id, 'user_id', to)
+	redis.call('SADD', p .. 'user_apikeys:' .. to, id)
+end
+redis.call('DEL', src)
+return #ids
+`)
+
+// ARGV: префикс, пользователь, рабочее пространство, затем short
+var moveURLsScript = redis.NewScript(`
+local p, uid, ws = ARGV[1], ARGV[2], ARGV[3]
+for i = 4, #ARGV do
+	local key = p .. 'url:' .. ARGV[i]
+	local f = redis.call('HMGET', key, 'user_id', 'workspace_id')
+	if f[1] == uid and f[2] == '' then
+		redis.call('HSET', key, 'workspace_id', ws)
+		redis.call('SADD', p .. 'workspace_urls:' .. ws, ARGV[i])
+	end
+end
+return 0
+`)
+
+// hsetExistingScript меняет поля хэша, только если он существует. ARGV: ключ, затем пары поле-значение.
+var hsetExistingScript = redis.NewScript(`
+if redis.call('EXISTS', ARGV[1]) == 0 then
+	return 0
+end
+redis.call('HSET', ARGV[1], unpack(ARGV, 2))
+return 1
+`)
+
+// setOriginalScript меняет оригинал ссылки вместе с индексом by_original. ARGV: префикс, short, original.
+var setOriginalScript = redis.NewScript(`
+local p, short, orig = ARGV[1], ARGV[2], ARGV[3]
+local key, byOriginal = p .. 'url:' .. short, p .. 'urls:by_original'
+local old = redis.call('HGET', key, 'original_url')
+if not old then
+	return {'not_found'}
+end
+local owner = redis.call('HGET', byOriginal, orig)
+if owner and owner ~= short then
+	return {'conflict', owner}
+end
+redis.call('HDEL', byOriginal, old)
+redis.call('HSET', key, 'original_url', orig)
+redis.call('HSET', byOriginal, orig, short)
+return {'ok'}
+`)
+
+// ARGV: префикс, short, состояние, причина, время закрытия жалоб
+var setModerationScript = redis.NewScript(`
+local p, short = ARGV[1], ARGV[2]
+local key = p .. 'url:' .. short
+if redis.call('EXISTS', key) == 0 then
+	return 0
+end
+redis.call('HSET', key, 'moderation', ARGV[3], 'moderation_reason', ARGV[4])
+local open = p .. 'reports:open_by_url:' .. short
+for _, id in ipairs(redis.call('SMEMBERS', open)) do
+	redis.call('HSET', p .. 'report:' .. id, 'resolved_at', ARGV[5])
+	redis.call('SREM', p .. 'reports:open', id)
+end
+redis.call('DEL', open)
+return 1
+`)
+
+// setMemberScript добавляет, меняет или исключает участника, не оставляя пространство без владельца.
+// ARGV: префикс, пространство, пользователь, новая роль (пустая — исключить).
+var setMemberScript = redis.NewScript(`
+local p, ws, uid, role = ARGV[1], ARGV[2], ARGV[3], ARGV[4]
+local key = p .. 'workspace_members:' .. ws
+local current = redis.call('HGET', key, uid)
+if role == '' and not current then
+	return 'not_found'
+end
+if current == 'owner' and role ~= 'owner' then
+	local members = redis.call('HGETALL', key)
+	local other = false
+	for i = 1, #members, 2 do
+		if members[i] ~= uid and members[i + 1] == 'owner' then
+			other = true
+			break
+		end
+	end
+	if not other then
+		return 'last_owner'
+	end
+end
+if role == '' then
+	redis.call('HDEL', key, uid)
+	redis.call('SREM', p .. 'user_workspaces:' .. uid, ws)
+else
+	redis.call('HSET', key, uid, role)
+	redis.call('SADD', p .. 'user_workspaces:' .. uid, ws)
+end
+return 'ok'
+`)
+
+// ARGV: префикс, id, login, password_hash, created_at
+var saveUserScript = redis.NewScript(`
+local p, id, login = ARGV[1], ARGV[2], ARGV[3]
+local key = p .. 'user:' .. id
+if redis.call('EXISTS', key) == 1 or redis.call('HEXISTS', p .. 'users:by_login', login) == 1 then
+	return 0
+end
+redis.call('HSET', key, 'id', id, 'login', login, 'password_hash', ARGV[4], 'created_at', ARGV[5])
+redis.call('HSET', p .. 'users:by_login', login, id)
+redis.call('SADD', p .. 'users', id)
+return 1
+`)
+
+// ARGV: префикс, id, владелец, name, created_at
+var saveWorkspaceScript = redis.NewScript(`
+local p, id, owner = ARGV[1], ARGV[2], ARGV[3]
+local key = p .. 'workspace:' .. id
+if redis.call('EXISTS', key) == 1 then
+	return 0
+end
+redis.call('HSET', key, 'id', id, 'name', ARGV[4], 'created_at', ARGV[5])
+redis.call('HSET', p .. 'workspace_members:' .. id, owner, 'owner')
+redis.call('SADD', p .. 'user_workspaces:' .. owner, id)
+return 1
+`)
+
+// Отозванный ключ сохраняет первое время отзыва. ARGV: ключ, пользователь, время.
+var revokeAPIKeyScript = redis.NewScript(`
+if redis.call('HGET', ARGV[1], 'user_id') ~= ARGV[2] then
+	return 0
+end
+redis.call('HSETNX', ARGV[1], 'revoked_at', ARGV[3])
+return 1
+`)
+
+// ARGV: префикс
+var statsScript = redis.NewScript(`
+local p = ARGV[1]
+local urls = tonumber(redis.call('GET', p .. 'urls:active') or '0')
+local users = #redis.call('SUNION', p .. 'users', p .. 'url_authors')
+return {urls, users}
+`)
+
+// RedisStore — общее хранилище для нескольких реплик без Postgres.
+// Всё, что меняет больше одного ключа, выполняется Lua-скриптом или в MULTI, то есть атомарно.
+type RedisStore struct {
+	client *redis.Client
+}
+
+func (s *RedisStore) SaveURL(ctx context.Context, shortURL, originalURL string, userID string) error {
+	return s.saveURLs(ctx, userID, true, shortURL, originalURL)
+}
+
+func (s *RedisStore) SaveURLs(ctx context.Context, urls map[string]string, userID string) error {
+	if len(urls) == 0 {
+		return nil
+	}
+
+	pairs := make([]string, 0, 2*len(urls))
+	for short, orig := range urls {
+		pairs = append(pairs, short, orig)
+	}
+	return s.saveURLs(ctx, userID, false, pairs...)
+}
+
+func (s *RedisStore) saveURLs(ctx context.Context, userID string, strict bool, pairs ...string) error {
+	args := make([]any, 0, 3+len(pairs)/2*3)
+	args = append(args, redisKeyPrefix, userID, redisBool(strict))
+	for i := 0; i < len(pairs); i += 2 {
+		args = append(args, pairs[i], pairs[i+1], uuid.NewString())
+	}
+
+	res, err := saveURLsScript.Run(ctx, s.client, nil, args...).StringSlice()
+	if err != nil {
+		return fmt.Errorf("failed to save urls: %w", err)
+	}
+	if res[0] == "conflict" {
+		return NewErrStoreConflict(res[1], res[2], nil)
+	}
+	return nil
+}
+
+func (s *RedisStore) GetURL(ctx context.Context, shortURL string) (model.URLRecord, error) {
+	h, err := s.client.HGetAll(ctx, redisKey("url", shortURL)).Result()
+	if err != nil {
+		return model.URLRecord{}, fmt.Errorf("failed to get url: %w", err)
+	}
+	if len(h) == 0 {
+		return model.URLRecord{}, ErrStoreNotFound
+	}
+	return urlRecordFromHash(h), nil
+}
+
+func urlRecordFromHash(h map[string]string) model.URLRecord {
+	return model.URLRecord{
+		ID:               h["id"],
+		UserID:           h["user_id"],
+		ShortURL:         h["short_url"],
+		OriginalURL:      h["original_url"],
+		IsDeleted:        h["is_deleted"] == redisTrue,
+		WorkspaceID:      h["workspace_id"],
+		IsDisabled:       h["is_disabled"] == redisTrue,
+		Moderation:       model.ModerationState(h["moderation"]),
+		ModerationReason: h["moderation_reason"],
+	}
+}
+
+// hashesByIndex читает хэши <kind>:<id> для всех id из множества-индекса.
+// Хэши, которых уже нет, пропускаются.
+func (s *RedisStore) hashesByIndex(ctx context.Context, index, kind string) ([]map[string]string, error) {
+	ids, err := s.client.SMembers(ctx, index).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			cmds[i] = pipe.HGetAll(ctx, redisKey(kind, id))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]map[string]string, 0, len(cmds))
+	for _, cmd := range cmds {
+		if h := cmd.Val(); len(h) > 0 {
+			hashes = append(hashes, h)
+		}
+	}
+	return hashes, nil
+}
+
+func (s *RedisStore) urlsByIndex(ctx context.Context, index string, keep func(model.URLRecord) bool) ([]model.URLRecord, error) {
+	hashes, err := s.hashesByIndex(ctx, index, "url")
+	if err != nil {
+		return nil, err
+	}
+
+	urls := []model.URLRecord{}
+	for _, h := range hashes {
+		if rec := urlRecordFromHash(h); keep(rec) {
+			urls = append(urls, rec)
+		}
+	}
+	slices.SortFunc(urls, func(a, b model.URLRecord) int { return strings.Compare(a.ShortURL, b.ShortURL) })
+	return urls, nil
+}
+
+func (s *RedisStore) GetUserURLs(ctx context.Context, userID string) ([]model.URLRecord, error) {
+	urls, err := s.urlsByIndex(ctx, redisKey("user_urls", userID), func(r model.URLRecord) bool {
+		return r.UserID == userID
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user urls: %w", err)
+	}
+	return urls, nil
+}
+
+func (s *RedisStore) MarkURLsDeleted(ctx context.Context, userID string, shortURLs []string) error {
+	if len(shortURLs) == 0 {
+		return nil
+	}
+
+	args := make([]any, 0, 2+len(shortURLs))
+	args = append(args, redisKeyPrefix, userID)
+	for _, u := range shortURLs {
+		args = append(args, u)
+	}
+	if err := markDeletedScript.Run(ctx, s.client, nil, args...).Err(); err != nil {
+		return fmt.Errorf("failed to mark urls deleted: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) SaveAPIKey(ctx context.Context, key model.APIKey) error {
+	fields := []any{
+		"id", key.ID,
+		"user_id", key.UserID,
+		"name", key.Name,
+		"prefix", key.Prefix,
+		"hash", key.Hash,
+		"created_at", redisTime(key.CreatedAt),
+	}
+	if key.RevokedAt != nil {
+		fields = append(fields, "revoked_at", redisTime(*key.RevokedAt))
+	}
+
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, redisKey("apikey", key.ID), fields...)
+		pipe.HSet(ctx, redisKey("apikeys", "by_hash"), key.Hash, key.ID)
+		pipe.SAdd(ctx, redisKey("user_apikeys", key.UserID), key.ID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save api key: %w", err)
+	}
+	return nil
+}
+
+func apiKeyFromHash(h map[string]string) model.APIKey {
+	return model.APIKey{
+		ID:        h["id"],
+		UserID:    h["user_id"],
+		Name:      h["name"],
+		Prefix:    h["prefix"],
+		Hash:      h["hash"],
+		CreatedAt: parseRedisTime(h["created_at"]),
+		RevokedAt: parseRedisTimePtr(h["revoked_at"]),
+	}
+}
+
+func (s *RedisStore) GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error) {
+	id, err := s.client.HGet(ctx, redisKey("apikeys", "by_hash"), hash).Result()
+	if errors.Is(err, redis.Nil) {
+		return model.APIKey{}, ErrStoreNotFound
+	}
+	if err != nil {
+		return model.APIKey{}, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	h, err := s.client.HGetAll(ctx, redisKey("apikey", id)).Result()
+	if err != nil {
+		return model.APIKey{}, fmt.Errorf("failed to get api key: %w", err)
+	}
+	if len(h) == 0 {
+		return model.APIKey{}, ErrStoreNotFound
+	}
+	return apiKeyFromHash(h), nil
+}
+
+func (s *RedisStore) GetUserAPIKeys(ctx context.Context, userID string) ([]model.APIKey, error) {
+	hashes, err := s.hashesByIndex(ctx, redisKey("user_apikeys", userID), "apikey")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user api keys: %w", err)
+	}
+
+	keys := make([]model.APIKey, 0, len(hashes))
+	for _, h := range hashes {
+		keys = append(keys, apiKeyFromHash(h))
+	}
+	slices.SortFunc(keys, func(a, b model.APIKey) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return keys, nil
+}
+
+func (s *RedisStore) RevokeAPIKey(ctx context.Context, userID, keyID string) error {
+	ok, err := revokeAPIKeyScript.Run(ctx, s.client, nil,
+		redisKey("apikey", keyID), userID, redisTime(time.Now())).Bool()
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	if !ok {
+		return ErrStoreNotFound
+	}
+	return nil
+}
+
+func (s *RedisStore) SaveUser(ctx context.Context, user model.User) error {
+	ok, err := saveUserScript.Run(ctx, s.client, nil,
+		redisKeyPrefix, user.ID, user.Login, user.PasswordHash, redisTime(user.CreatedAt)).Bool()
+	if err != nil {
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+	if !ok {
+		return ErrStoreAlreadyExists
+	}
+	return nil
+}
+
+func (s *RedisStore) GetUser(ctx context.Context, userID string) (model.User, error) {
+	h, err := s.client.HGetAll(ctx, redisKey("user", userID)).Result()
+	if err != nil {
+		return model.User{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	if len(h) == 0 {
+		return model.User{}, ErrStoreNotFound
+	}
+	return model.User{
+		ID:           h["id"],
+		Login:        h["login"],
+		PasswordHash: h["password_hash"],
+		CreatedAt:    parseRedisTime(h["created_at"]),
+	}, nil
+}
+
+func (s *RedisStore) GetUserByLogin(ctx context.Context, login string) (model.User, error) {
+	id, err := s.client.HGet(ctx, redisKey("users", "by_login"), login).Result()
+	if errors.Is(err, redis.Nil) {
+		return model.User{}, ErrStoreNotFound
+	}
+	if err != nil {
+		return model.User{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	return s.GetUser(ctx, id)
+}
+
+func (s *RedisStore) MergeUserURLs(ctx context.Context, fromUserID, toUserID string) error {
+	if err := mergeUserURLsScript.Run(ctx, s.client, nil, redisKeyPrefix, fromUserID, toUserID).Err(); err != nil {
+		return fmt.Errorf("failed to merge user urls: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) MergeUserAPIKeys(ctx context.Context, fromUserID, toUserID string) error {
+	if err := mergeUserAPIKeysScript.Run(ctx, s.client, nil, redisKeyPrefix, fromUserID, toUserID).Err(); err != nil {
+		return fmt.Errorf("failed to merge user api keys: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) SaveWorkspace(ctx context.Context, ws model.Workspace, ownerID string) error {
+	ok, err := saveWorkspaceScript.Run(ctx, s.client, nil,
+		redisKeyPrefix, ws.ID, ownerID, ws.Name, redisTime(ws.CreatedAt)).Bool()
+	if err != nil {
+		return fmt.Errorf("failed to save workspace: %w", err)
+	}
+	if !ok {
+		return ErrStoreAlreadyExists
+	}
+	return nil
+}
+
+func workspaceFromHash(h map[string]string) model.Workspace {
+	return model.Workspace{
+		ID:        h["id"],
+		Name:      h["name"],
+		CreatedAt: parseRedisTime(h["created_at"]),
+	}
+}
+
+func (s *RedisStore) GetWorkspace(ctx context.Context, workspaceID string) (model.Workspace, error) {
+	h, err := s.client.HGetAll(ctx, redisKey("workspace", workspaceID)).Result()
+	if err != nil {
+		return model.Workspace{}, fmt.Errorf("failed to get workspace: %w", err)
+	}
+	if len(h) == 0 {
+		return model.Workspace{}, ErrStoreNotFound
+	}
+	return workspaceFromHash(h), nil
+}
+
+func (s *RedisStore) GetUserWorkspaces(ctx context.Context, userID string) ([]model.UserWorkspace, error) {
+	hashes, err := s.hashesByIndex(ctx, redisKey("user_workspaces", userID), "workspace")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user workspaces: %w", err)
+	}
+
+	roles := make([]*redis.StringCmd, len(hashes))
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, h := range hashes {
+			roles[i] = pipe.HGet(ctx, redisKey("workspace_members", h["id"]), userID)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to get workspace roles: %w", err)
+	}
+
+	result := []model.UserWorkspace{}
+	for i, h := range hashes {
+		// Индекс мог пережить удаление участника — такое пространство не показываем
+		role, err := roles[i].Result()
+		if err != nil {
+			continue
+		}
+		result = append(result, model.UserWorkspace{Workspace: workspaceFromHash(h), Role: model.WorkspaceRole(role)})
+	}
+	slices.SortFunc(result, func(a, b model.UserWorkspace) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return result, nil
+}
+
+func (s *RedisStore) GetWorkspaceMember(ctx context.Context, workspaceID, userID string) (model.WorkspaceMember, error) {
+	role, err := s.client.HGet(ctx, redisKey("workspace_members", workspaceID), userID).Result()
+	if errors.Is(err, redis.Nil) {
+		return model.WorkspaceMember{}, ErrStoreNotFound
+	}
+	if err != nil {
+		return model.WorkspaceMember{}, fmt.Errorf("failed to get workspace member: %w", err)
+	}
+	return model.WorkspaceMember{WorkspaceID: workspaceID, UserID: userID, Role: model.WorkspaceRole(role)}, nil
+}
+
+func (s *RedisStore) GetWorkspaceMembers(ctx context.Context, workspaceID string) ([]model.WorkspaceMember, error) {
+	roles, err := s.client.HGetAll(ctx, redisKey("workspace_members", workspaceID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace members: %w", err)
+	}
+
+	members := make([]model.WorkspaceMember, 0, len(roles))
+	for userID, role := range roles {
+		members = append(members, model.WorkspaceMember{WorkspaceID: workspaceID, UserID: userID, Role: model.WorkspaceRole(role)})
+	}
+	slices.SortFunc(members, func(a, b model.WorkspaceMember) int { return strings.Compare(a.UserID, b.UserID) })
+	return members, nil
+}
+
+func (s *RedisStore) SaveWorkspaceMember(ctx context.Context, member model.WorkspaceMember) error {
+	return s.setMember(ctx, member.WorkspaceID, member.UserID, member.Role)
+}
+
+func (s *RedisStore) DeleteWorkspaceMember(ctx context.Context, workspaceID, userID string) error {
+	return s.setMember(ctx, workspaceID, userID, "")
+}
+
+func (s *RedisStore) setMember(ctx context.Context, workspaceID, userID string, role model.WorkspaceRole) error {
+	res, err := setMemberScript.Run(ctx, s.client, nil, redisKeyPrefix, workspaceID, userID, string(role)).Text()
+	if err != nil {
+		return fmt.Errorf("failed to update workspace member: %w", err)
+	}
+	switch res {
+	case "not_found":
+		return ErrStoreNotFound
+	case "last_owner":
+		return ErrStoreLastOwner
+	}
+	return nil
+}
+
+func (s *RedisStore) MoveURLsToWorkspace(ctx context.Context, userID, workspaceID string, shortURLs []string) error {
+	if len(shortURLs) == 0 {
+		return nil
+	}
+
+	args := make([]any, 0, 3+len(shortURLs))
+	args = append(args, redisKeyPrefix, userID, workspaceID)
+	for _, u := range shortURLs {
+		args = append(args, u)
+	}
+	if err := moveURLsScript.Run(ctx, s.client, nil, args...).Err(); err != nil {
+		return fmt.Errorf("failed to move urls: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) GetWorkspaceURLs(ctx context.Context, workspaceID string) ([]model.URLRecord, error) {
+	urls, err := s.urlsByIndex(ctx, redisKey("workspace_urls", workspaceID), func(r model.URLRecord) bool {
+		return r.WorkspaceID == workspaceID
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace urls: %w", err)
+	}
+	return urls, nil
+}
+
+func (s *RedisStore) GetStats(ctx context.Context) (model.Stats, error) {
+	res, err := statsScript.Run(ctx, s.client, nil, redisKeyPrefix).Int64Slice()
+	if err != nil {
+		return model.Stats{}, fmt.Errorf("failed to get stats: %w", err)
+	}
+	return model.Stats{URLs: int(res[0]), Users: int(res[1])}, nil
+}
+
+func (s *RedisStore) SetOriginalURL(ctx context.Context, shortURL, originalURL string) error {
+	res, err := setOriginalScript.Run(ctx, s.client, nil, redisKeyPrefix, shortURL, originalURL).StringSlice()
+	if err != nil {
+		return fmt.Errorf("failed to set original url: %w", err)
+	}
+	switch res[0] {
+	case "not_found":
+		return ErrStoreNotFound
+	case "conflict":
+		return NewErrStoreConflict(res[1], originalURL, nil)
+	}
+	return nil
+}
+
+func (s *RedisStore) SetURLDisabled(ctx context.Context, shortURL string, disabled bool) error {
+	ok, err := hsetExistingScript.Run(ctx, s.client, nil,
+		redisKey("url", shortURL), "is_disabled", redisBool(disabled)).Bool()
+	if err != nil {
+		return fmt.Errorf("failed to set url disabled: %w", err)
+	}
+	if !ok {
+		return ErrStoreNotFound
+	}
+	return nil
+}
+
+func (s *RedisStore) SaveAbuseReport(ctx context.Context, report model.AbuseReport) error {
+	fields := []any{
+		"id", report.ID,
+		"short_url", report.ShortURL,
+		"reason", report.Reason,
+		"reporter_id", report.ReporterID,
+		"created_at", redisTime(report.CreatedAt),
+	}
+	if report.ResolvedAt != nil {
+		fields = append(fields, "resolved_at", redisTime(*report.ResolvedAt))
+	}
+
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, redisKey("report", report.ID), fields...)
+		if report.ResolvedAt == nil {
+			pipe.SAdd(ctx, redisKey("reports", "open"), report.ID)
+			pipe.SAdd(ctx, redisKey("reports", "open_by_url", report.ShortURL), report.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save abuse report: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) GetOpenAbuseReports(ctx context.Context) ([]model.AbuseReport, error) {
+	hashes, err := s.hashesByIndex(ctx, redisKey("reports", "open"), "report")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get abuse reports: %w", err)
+	}
+
+	reports := make([]model.AbuseReport, 0, len(hashes))
+	for _, h := range hashes {
+		reports = append(reports, model.AbuseReport{
+			ID:         h["id"],
+			ShortURL:   h["short_url"],
+			Reason:     h["reason"],
+			ReporterID: h["reporter_id"],
+			CreatedAt:  parseRedisTime(h["created_at"]),
+			ResolvedAt: parseRedisTimePtr(h["resolved_at"]),
+		})
+	}
+	slices.SortFunc(reports, func(a, b model.AbuseReport) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return reports, nil
+}
+
+func (s *RedisStore) SetURLModeration(ctx context.Context, shortURL string, state model.ModerationState, reason string) error {
+	ok, err := setModerationScript.Run(ctx, s.client, nil,
+		redisKeyPrefix, shortURL, string(state), reason, redisTime(time.Now())).Bool()
+	if err != nil {
+		return fmt.Errorf("failed to set url moderation: %w", err)
+	}
+	if !ok {
+		return ErrStoreNotFound
+	}
+	return nil
+}
+
+func (s *RedisStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+func (s *RedisStore) Close() {
+	s.client.Close()
+}
+
+// NewRedisStore подключается по URL вида redis://[:password@]host:port/db или rediss:// для TLS
+func NewRedisStore(redisURL string) (*RedisStore, error) {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		// Текст ошибки может содержать URL с паролем
+		return nil, errors.New("invalid redis url")
+	}
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to ping redis: %w", err)
+	}
+
+	return &RedisStore{client: client}, nil
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestRedisStore(t *testing.T) *RedisStore {
+	t.Helper()
+
+	srv := miniredis.RunT(t)
+	store, err := NewRedisStore("redis://" + srv.Addr())
+	if err != nil {
+		t.Fatalf("failed to create redis store: %v", err)
+	}
+	t.Cleanup(store.Close)
+	return store
+}
+
+func TestRedisStore(t *testing.T) {
+	runStoreSuite(t, func(t *testing.T) Store { return newTestRedisStore(t) })
+}
@@ -0,0 +1,643 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"github.com/kayumovtd/url-shortener/migrations"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// SQLiteStore — SQL-хранилище в одном файле для тех, кому нужен SQL без сервера PostgreSQL.
+// Запросы повторяют DBStore, схема — migrations/sqlite.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	code := sqliteErr.Code()
+	return code == sqlite3.SQLITE_CONSTRAINT_UNIQUE || code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
+
+// jsonList передаёт список одним параметром: в SQLite нет массивов, вместо ANY($1) — json_each(?1)
+func jsonList(items []string) string {
+	data, _ := json.Marshal(items)
+	return string(data)
+}
+
+const sqliteURLColumns = `id, user_id, short_url, original_url, is_deleted, workspace_id, is_disabled, moderation_state, moderation_reason`
+
+func scanURLRecord(row interface{ Scan(...any) error }) (model.URLRecord, error) {
+	var rec model.URLRecord
+	err := row.Scan(
+		&rec.ID,
+		&rec.UserID,
+		&rec.ShortURL,
+		&rec.OriginalURL,
+		&rec.IsDeleted,
+		&rec.WorkspaceID,
+		&rec.IsDisabled,
+		&rec.Moderation,
+		&rec.ModerationReason,
+	)
+	return rec, err
+}
+
+func (s *SQLiteStore) SaveURL(ctx context.Context, shortURL, originalURL string, userID string) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO urls (short_url, original_url, user_id) VALUES (?1, ?2, ?3)`,
+		shortURL, originalURL, userID,
+	)
+	if err == nil {
+		return nil
+	}
+	if !isUniqueViolation(err) {
+		return err
+	}
+
+	// В отличие от PostgreSQL, отдаём ту короткую ссылку, что уже лежит в базе
+	var existing string
+	if s.db.QueryRowContext(ctx, `SELECT short_url FROM urls WHERE original_url = ?1`, originalURL).Scan(&existing) == nil {
+		shortURL = existing
+	}
+	return NewErrStoreConflict(shortURL, originalURL, err)
+}
+
+func (s *SQLiteStore) SaveURLs(ctx context.Context, urls map[string]string, userID string) error {
+	if len(urls) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO urls (short_url, original_url, user_id) VALUES (?1, ?2, ?3)
+		 ON CONFLICT (short_url) DO UPDATE SET original_url = excluded.original_url`,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for short, orig := range urls {
+		if _, err := stmt.ExecContext(ctx, short, orig, userID); err != nil {
+			return fmt.Errorf("batch execution failed: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) GetURL(ctx context.Context, shortURL string) (model.URLRecord, error) {
+	rec, err := scanURLRecord(s.db.QueryRowContext(ctx,
+		`SELECT `+sqliteURLColumns+` FROM urls WHERE short_url = ?1`, shortURL,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.URLRecord{}, ErrStoreNotFound
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return model.URLRecord{}, fmt.Errorf("request canceled or timed out: %w", err)
+	}
+	if err != nil {
+		return model.URLRecord{}, fmt.Errorf("failed to get original url: %w", err)
+	}
+	return rec, nil
+}
+
+func (s *SQLiteStore) queryURLs(ctx context.Context, query string, args ...any) ([]model.URLRecord, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query urls: %w", err)
+	}
+	defer rows.Close()
+
+	urls := []model.URLRecord{}
+	for rows.Next() {
+		rec, err := scanURLRecord(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		urls = append(urls, rec)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return urls, nil
+}
+
+func (s *SQLiteStore) GetUserURLs(ctx context.Context, userID string) ([]model.URLRecord, error) {
+	return s.queryURLs(ctx, `SELECT `+sqliteURLColumns+` FROM urls WHERE user_id = ?1`, userID)
+}
+
+func (s *SQLiteStore) MarkURLsDeleted(ctx context.Context, userID string, shortURLs []string) error {
+	if len(shortURLs) == 0 {
+		return nil
+	}
+
+	query := `
+        UPDATE urls
+        SET is_deleted = TRUE
+        WHERE short_url IN (SELECT value FROM json_each(?2)) AND (
+            (workspace_id = '' AND user_id = ?1)
+            OR workspace_id IN (
+                SELECT workspace_id FROM workspace_members
+                WHERE user_id = ?1 AND role IN ('owner', 'editor')
+            )
+        )
+    `
+	_, err := s.db.ExecContext(ctx, query, userID, jsonList(shortURLs))
+	if err != nil {
+		return fmt.Errorf("failed to mark urls deleted: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) SaveAPIKey(ctx context.Context, key model.APIKey) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO api_keys (id, user_id, name, prefix, key_hash, created_at)
+		 VALUES (?1, ?2, ?3, ?4, ?5, ?6)`,
+		key.ID, key.UserID, key.Name, key.Prefix, key.Hash, key.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save api key: %w", err)
+	}
+	return nil
+}
+
+const sqliteAPIKeyColumns = `id, user_id, name, prefix, key_hash, created_at, revoked_at`
+
+func scanAPIKey(row interface{ Scan(...any) error }) (model.APIKey, error) {
+	var key model.APIKey
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.Hash, &key.CreatedAt, &key.RevokedAt)
+	return key, err
+}
+
+func (s *SQLiteStore) GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error) {
+	key, err := scanAPIKey(s.db.QueryRowContext(ctx,
+		`SELECT `+sqliteAPIKeyColumns+` FROM api_keys WHERE key_hash = ?1`, hash,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.APIKey{}, ErrStoreNotFound
+	}
+	if err != nil {
+		return model.APIKey{}, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return key, nil
+}
+
+func (s *SQLiteStore) GetUserAPIKeys(ctx context.Context, userID string) ([]model.APIKey, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+sqliteAPIKeyColumns+` FROM api_keys WHERE user_id = ?1 ORDER BY created_at`, userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []model.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return keys, nil
+}
+
+func (s *SQLiteStore) RevokeAPIKey(ctx context.Context, userID, keyID string) error {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE api_keys SET revoked_at = COALESCE(revoked_at, ?3) WHERE user_id = ?1 AND id = ?2`,
+		userID, keyID, time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return checkAffected(res)
+}
+
+// checkAffected — ErrStoreNotFound, если запрос не затронул ни одной строки
+func checkAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrStoreNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteStore) SaveUser(ctx context.Context, user model.User) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO users (id, login, password_hash, created_at) VALUES (?1, ?2, ?3, ?4)`,
+		user.ID, user.Login, user.PasswordHash, user.CreatedAt.UTC(),
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrStoreAlreadyExists
+		}
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) GetUser(ctx context.Context, userID string) (model.User, error) {
+	return s.getUser(ctx, `SELECT id, login, password_hash, created_at FROM users WHERE id = ?1`, userID)
+}
+
+func (s *SQLiteStore) GetUserByLogin(ctx context.Context, login string) (model.User, error) {
+	return s.getUser(ctx, `SELECT id, login, password_hash, created_at FROM users WHERE login = ?1`, login)
+}
+
+func (s *SQLiteStore) getUser(ctx context.Context, query string, arg string) (model.User, error) {
+	var user model.User
+	err := s.db.QueryRowContext(ctx, query, arg).Scan(&user.ID, &user.Login, &user.PasswordHash, &user.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.User{}, ErrStoreNotFound
+	}
+	if err != nil {
+		return model.User{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+func (s *SQLiteStore) MergeUserURLs(ctx context.Context, fromUserID, toUserID string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE urls SET user_id = ?2 WHERE user_id = ?1`, fromUserID, toUserID)
+	if err != nil {
+		return fmt.Errorf("failed to merge user urls: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) MergeUserAPIKeys(ctx context.Context, fromUserID, toUserID string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE api_keys SET user_id = ?2 WHERE user_id = ?1`, fromUserID, toUserID)
+	if err != nil {
+		return fmt.Errorf("failed to merge user api keys: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) SaveWorkspace(ctx context.Context, ws model.Workspace, ownerID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO workspaces (id, name, created_at) VALUES (?1, ?2, ?3)`,
+		ws.ID, ws.Name, ws.CreatedAt.UTC(),
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrStoreAlreadyExists
+		}
+		return fmt.Errorf("failed to save workspace: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO workspace_members (workspace_id, user_id, role) VALUES (?1, ?2, ?3)`,
+		ws.ID, ownerID, model.RoleOwner,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save workspace owner: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) GetWorkspace(ctx context.Context, workspaceID string) (model.Workspace, error) {
+	var ws model.Workspace
+	err := s.db.QueryRowContext(ctx,
+		`SELECT id, name, created_at FROM workspaces WHERE id = ?1`, workspaceID,
+	).Scan(&ws.ID, &ws.Name, &ws.CreatedAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Workspace{}, ErrStoreNotFound
+	}
+	if err != nil {
+		return model.Workspace{}, fmt.Errorf("failed to get workspace: %w", err)
+	}
+	return ws, nil
+}
+
+func (s *SQLiteStore) GetUserWorkspaces(ctx context.Context, userID string) ([]model.UserWorkspace, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT w.id, w.name, w.created_at, m.role
+		 FROM workspaces w JOIN workspace_members m ON m.workspace_id = w.id
+		 WHERE m.user_id = ?1 ORDER BY w.created_at`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workspaces: %w", err)
+	}
+	defer rows.Close()
+
+	result := []model.UserWorkspace{}
+	for rows.Next() {
+		var uw model.UserWorkspace
+		if err := rows.Scan(&uw.ID, &uw.Name, &uw.CreatedAt, &uw.Role); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		result = append(result, uw)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return result, nil
+}
+
+func (s *SQLiteStore) GetWorkspaceMember(ctx context.Context, workspaceID, userID string) (model.WorkspaceMember, error) {
+	m := model.WorkspaceMember{WorkspaceID: workspaceID, UserID: userID}
+	err := s.db.QueryRowContext(ctx,
+		`SELECT role FROM workspace_members WHERE workspace_id = ?1 AND user_id = ?2`,
+		workspaceID, userID,
+	).Scan(&m.Role)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.WorkspaceMember{}, ErrStoreNotFound
+	}
+	if err != nil {
+		return model.WorkspaceMember{}, fmt.Errorf("failed to get workspace member: %w", err)
+	}
+	return m, nil
+}
+
+func (s *SQLiteStore) GetWorkspaceMembers(ctx context.Context, workspaceID string) ([]model.WorkspaceMember, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT workspace_id, user_id, role FROM workspace_members WHERE workspace_id = ?1`, workspaceID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query workspace members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []model.WorkspaceMember{}
+	for rows.Next() {
+		var m model.WorkspaceMember
+		if err := rows.Scan(&m.WorkspaceID, &m.UserID, &m.Role); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		members = append(members, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return members, nil
+}
+
+func (s *SQLiteStore) SaveWorkspaceMember(ctx context.Context, member model.WorkspaceMember) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := sqliteCheckOwner(ctx, tx, member.WorkspaceID, member.UserID, member.Role); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO workspace_members (workspace_id, user_id, role) VALUES (?1, ?2, ?3)
+		 ON CONFLICT (workspace_id, user_id) DO UPDATE SET role = excluded.role`,
+		member.WorkspaceID, member.UserID, member.Role,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save workspace member: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) DeleteWorkspaceMember(ctx context.Context, workspaceID, userID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := sqliteCheckOwner(ctx, tx, workspaceID, userID, ""); err != nil {
+		return err
+	}
+	res, err := tx.ExecContext(ctx,
+		`DELETE FROM workspace_members WHERE workspace_id = ?1 AND user_id = ?2`,
+		workspaceID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete workspace member: %w", err)
+	}
+	if err := checkAffected(res); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+	return nil
+}
+
+// sqliteCheckOwner не даёт оставить пространство без владельца. Одно соединение
+// на базу сериализует транзакции, так что проверка и запись не разойдутся.
+func sqliteCheckOwner(ctx context.Context, tx *sql.Tx, workspaceID, userID string, role model.WorkspaceRole) error {
+	if role == model.RoleOwner {
+		return nil
+	}
+
+	var others, self int
+	err := tx.QueryRowContext(ctx,
+		`SELECT count(*) FILTER (WHERE user_id <> ?2), count(*) FILTER (WHERE user_id = ?2)
+		 FROM workspace_members WHERE workspace_id = ?1 AND role = ?3`,
+		workspaceID, userID, model.RoleOwner,
+	).Scan(&others, &self)
+	if err != nil {
+		return fmt.Errorf("failed to count workspace owners: %w", err)
+	}
+	if self > 0 && others == 0 {
+		return ErrStoreLastOwner
+	}
+	return nil
+}
+
+func (s *SQLiteStore) MoveURLsToWorkspace(ctx context.Context, userID, workspaceID string, shortURLs []string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE urls SET workspace_id = ?2
+		 WHERE user_id = ?1 AND workspace_id = '' AND short_url IN (SELECT value FROM json_each(?3))`,
+		userID, workspaceID, jsonList(shortURLs),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to move urls to workspace: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) GetWorkspaceURLs(ctx context.Context, workspaceID string) ([]model.URLRecord, error) {
+	return s.queryURLs(ctx, `SELECT `+sqliteURLColumns+` FROM urls WHERE workspace_id = ?1`, workspaceID)
+}
+
+func (s *SQLiteStore) GetStats(ctx context.Context) (model.Stats, error) {
+	var stats model.Stats
+	err := s.db.QueryRowContext(ctx, `
+		SELECT
+			(SELECT count(*) FROM urls WHERE NOT is_deleted),
+			(SELECT count(*) FROM (SELECT user_id FROM urls UNION SELECT id FROM users))
+	`).Scan(&stats.URLs, &stats.Users)
+	if err != nil {
+		return model.Stats{}, fmt.Errorf("failed to get stats: %w", err)
+	}
+	return stats, nil
+}
+
+func (s *SQLiteStore) SetOriginalURL(ctx context.Context, shortURL, originalURL string) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE urls SET original_url = ?2 WHERE short_url = ?1`, shortURL, originalURL)
+	if err != nil {
+		if !isUniqueViolation(err) {
+			return fmt.Errorf("failed to update url: %w", err)
+		}
+		var existing string
+		if s.db.QueryRowContext(ctx, `SELECT short_url FROM urls WHERE original_url = ?1`, originalURL).Scan(&existing) == nil {
+			shortURL = existing
+		}
+		return NewErrStoreConflict(shortURL, originalURL, err)
+	}
+	return checkAffected(res)
+}
+
+func (s *SQLiteStore) SetURLDisabled(ctx context.Context, shortURL string, disabled bool) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE urls SET is_disabled = ?2 WHERE short_url = ?1`, shortURL, disabled)
+	if err != nil {
+		return fmt.Errorf("failed to update url: %w", err)
+	}
+	return checkAffected(res)
+}
+
+func (s *SQLiteStore) SaveAbuseReport(ctx context.Context, report model.AbuseReport) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO abuse_reports (id, short_url, reason, reporter_id, created_at) VALUES (?1, ?2, ?3, ?4, ?5)`,
+		report.ID, report.ShortURL, report.Reason, report.ReporterID, report.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save abuse report: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) GetOpenAbuseReports(ctx context.Context) ([]model.AbuseReport, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, short_url, reason, reporter_id, created_at
+		 FROM abuse_reports WHERE resolved_at IS NULL ORDER BY created_at`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query abuse reports: %w", err)
+	}
+	defer rows.Close()
+
+	reports := []model.AbuseReport{}
+	for rows.Next() {
+		var r model.AbuseReport
+		if err := rows.Scan(&r.ID, &r.ShortURL, &r.Reason, &r.ReporterID, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		reports = append(reports, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("row iteration error: %w", err)
+	}
+	return reports, nil
+}
+
+func (s *SQLiteStore) SetURLModeration(ctx context.Context, shortURL string, state model.ModerationState, reason string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE urls SET moderation_state = ?2, moderation_reason = ?3 WHERE short_url = ?1`,
+		shortURL, state, reason,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update url moderation: %w", err)
+	}
+	if err := checkAffected(res); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE abuse_reports SET resolved_at = ?2 WHERE short_url = ?1 AND resolved_at IS NULL`,
+		shortURL, time.Now().UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to resolve abuse reports: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s *SQLiteStore) Close() {
+	s.db.Close()
+}
+
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	dsn := "file:" + path +
+		"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite db: %w", err)
+	}
+	// SQLite пишет в один поток: с одним соединением транзакции не ловят SQLITE_BUSY друг от друга
+	db.SetMaxOpenConns(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping sqlite db: %w", err)
+	}
+
+	if err := migrations.ApplySQLiteMigrations(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to apply migrations: %w", err)
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func newTestSQLiteStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "urls.db"))
+	if err != nil {
+		t.Fatalf("failed to create sqlite store: %v", err)
+	}
+	t.Cleanup(store.Close)
+	return store
+}
+
+func TestSQLiteStore(t *testing.T) {
+	runStoreSuite(t, func(t *testing.T) Store { return newTestSQLiteStore(t) })
+}
+
+func TestSQLiteStore_Reopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "urls.db")
+
+	s, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("failed to create sqlite store: %v", err)
+	}
+	s.SaveURL(ctx, "abc", "https://example.com", "u1")
+	s.Close()
+
+	// Повторный запуск миграций на готовой схеме не должен падать
+	s, err = NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("failed to reopen sqlite store: %v", err)
+	}
+	defer s.Close()
+	if rec, err := s.GetURL(ctx, "abc"); err != nil || rec.OriginalURL != "https://example.com" {
+		t.Errorf("data lost after reopen: %+v, %v", rec, err)
+	}
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/kayumovtd/url-shortener/internal/model"
 )
@@ -11,7 +12,108 @@ type Store interface {
 	SaveURLs(ctx context.Context, urls map[string]string, userID string) error
 	GetURL(ctx context.Context, shortURL string) (model.URLRecord, error)
 	GetUserURLs(ctx context.Context, userID string) ([]model.URLRecord, error)
+	// MarkURLsDeleted удаляет личные ссылки пользователя и ссылки рабочих пространств,
+	// где у него есть право на редактирование. Остальные id молча пропускаются.
 	MarkURLsDeleted(ctx context.Context, userID string, shortURLs []string) error
+	// SetOriginalURL перенаправляет ссылку на новый адрес. Права проверяет вызывающий.
+	SetOriginalURL(ctx context.Context, shortURL, originalURL string) error
+
+	SaveAPIKey(ctx context.Context, key model.APIKey) error
+	GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error)
+	GetUserAPIKeys(ctx context.Context, userID string) ([]model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID string) error
+
+	SaveUser(ctx context.Context, user model.User) error
+	GetUser(ctx context.Context, userID string) (model.User, error)
+	GetUserByLogin(ctx context.Context, login string) (model.User, error)
+	// MergeUserURLs переносит все ссылки fromUserID на toUserID
+	MergeUserURLs(ctx context.Context, fromUserID, toUserID string) error
+	// MergeUserAPIKeys переносит все API-ключи fromUserID, включая отозванные, на toUserID
+	MergeUserAPIKeys(ctx context.Context, fromUserID, toUserID string) error
+
+	// SaveWorkspace создаёт рабочее пространство с ownerID в роли владельца
+	SaveWorkspace(ctx context.Context, ws model.Workspace, ownerID string) error
+	GetWorkspace(ctx context.Context, workspaceID string) (model.Workspace, error)
+	GetUserWorkspaces(ctx context.Context, userID string) ([]model.UserWorkspace, error)
+	GetWorkspaceMember(ctx context.Context, workspaceID, userID string) (model.WorkspaceMember, error)
+	GetWorkspaceMembers(ctx context.Context, workspaceID string) ([]model.WorkspaceMember, error)
+	// SaveWorkspaceMember и DeleteWorkspaceMember отказывают с ErrStoreLastOwner, если пространство
+	// осталось бы без владельца. Проверка и запись идут одной операцией.
+	SaveWorkspaceMember(ctx context.Context, member model.WorkspaceMember) error
+	DeleteWorkspaceMember(ctx context.Context, workspaceID, userID string) error
+	// MoveURLsToWorkspace передаёт личные ссылки пользователя в рабочее пространство
+	MoveURLsToWorkspace(ctx context.Context, userID, workspaceID string, shortURLs []string) error
+	GetWorkspaceURLs(ctx context.Context, workspaceID string) ([]model.URLRecord, error)
+
+	// GetStats считает ссылки, кроме удалённых, и пользователей: авторов ссылок вместе с зарегистрированными
+	GetStats(ctx context.Context) (model.Stats, error)
+	SetURLDisabled(ctx context.Context, shortURL string, disabled bool) error
+
+	SaveAbuseReport(ctx context.Context, report model.AbuseReport) error
+	GetOpenAbuseReports(ctx context.Context) ([]model.AbuseReport, error)
+	// SetURLModeration меняет состояние модерации ссылки и закрывает все открытые жалобы на неё
+	SetURLModeration(ctx context.Context, shortURL string, state model.ModerationState, reason string) error
+
 	Ping(ctx context.Context) error
 	Close()
 }
+
+// canDeleteURL — личную ссылку удаляет автор, ссылку рабочего пространства — его редакторы и владельцы.
+func canDeleteURL(rec model.URLRecord, userID string, members []model.WorkspaceMember) bool {
+	if rec.WorkspaceID == "" {
+		return rec.UserID == userID
+	}
+	for _, m := range members {
+		if m.WorkspaceID == rec.WorkspaceID && m.UserID == userID {
+			return m.Role.CanEdit()
+		}
+	}
+	return false
+}
+
+// leavesNoOwner — userID в роли role (пустая — исключён) оставит пространство без владельца.
+// Общая проверка для хранилищ, которые держат участников в памяти.
+func leavesNoOwner(members []model.WorkspaceMember, workspaceID, userID string, role model.WorkspaceRole) bool {
+	if role == model.RoleOwner {
+		return false
+	}
+	wasOwner := false
+	for _, m := range members {
+		if m.WorkspaceID != workspaceID || m.Role != model.RoleOwner {
+			continue
+		}
+		if m.UserID != userID {
+			return false
+		}
+		wasOwner = true
+	}
+	return wasOwner
+}
+
+// countStats — общая часть GetStats для хранилищ, которые держат всё в памяти
+func countStats(records []model.URLRecord, users []model.User) model.Stats {
+	ids := make(map[string]struct{}, len(users))
+	for _, u := range users {
+		ids[u.ID] = struct{}{}
+	}
+
+	var stats model.Stats
+	for _, rec := range records {
+		if !rec.IsDeleted {
+			stats.URLs++
+		}
+		ids[rec.UserID] = struct{}{}
+	}
+	stats.Users = len(ids)
+
+	return stats
+}
+
+// resolveReports закрывает открытые жалобы на ссылку, общая часть SetURLModeration
+func resolveReports(reports []model.AbuseReport, shortURL string, at time.Time) {
+	for i, r := range reports {
+		if r.ShortURL == shortURL && r.ResolvedAt == nil {
+			reports[i].ResolvedAt = &at
+		}
+	}
+}
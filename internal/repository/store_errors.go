@@ -1,6 +1,15 @@
 package repository
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrStoreNotFound      = errors.New("not found")
+	ErrStoreAlreadyExists = errors.New("already exists")
+	ErrStoreLastOwner     = errors.New("last owner of workspace")
+)
 
 type ErrStoreConflict struct {
 	ShortURL    string
@@ -2,12 +2,51 @@ package repository
 
 import (
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/kayumovtd/url-shortener/internal/config"
 	"github.com/kayumovtd/url-shortener/internal/logger"
+	"go.uber.org/zap"
 )
 
 func NewStore(cfg *config.Config, l *logger.Logger) (Store, error) {
+	store, err := newBackend(cfg, l)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheCfg, err := parseCacheConfig(cfg)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	if cacheCfg.Size == 0 {
+		return store, nil
+	}
+	l.Info("using url cache", zap.Int("size", cacheCfg.Size),
+		zap.Duration("ttl", cacheCfg.TTL), zap.Duration("negative_ttl", cacheCfg.NegativeTTL))
+	return NewCachedStore(store, cacheCfg), nil
+}
+
+func newBackend(cfg *config.Config, l *logger.Logger) (Store, error) {
+	switch config.DSNScheme(cfg.DatabaseDSN) {
+	case "bolt", "bbolt":
+		boltStore, err := NewBoltStore(config.DSNPath(cfg.DatabaseDSN))
+		if err != nil {
+			return nil, fmt.Errorf("failed to init bolt store: %w", err)
+		}
+		l.Info("using bolt store")
+		return boltStore, nil
+	case "sqlite":
+		sqliteStore, err := NewSQLiteStore(config.DSNPath(cfg.DatabaseDSN))
+		if err != nil {
+			return nil, fmt.Errorf("failed to init sqlite store: %w", err)
+		}
+		l.Info("using sqlite store")
+		return sqliteStore, nil
+	}
+
 	if cfg.DatabaseDSN != "" {
 		dbStore, err := NewDBStore(cfg.DatabaseDSN)
 		if err != nil {
@@ -17,8 +56,17 @@ func NewStore(cfg *config.Config, l *logger.Logger) (Store, error) {
 		return dbStore, nil
 	}
 
+	if cfg.RedisURL != "" {
+		redisStore, err := NewRedisStore(cfg.RedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to init redis store: %w", err)
+		}
+		l.Info("using redis store")
+		return redisStore, nil
+	}
+
 	if cfg.FileStoragePath != "" {
-		fileStore, err := NewFileStore(cfg.FileStoragePath)
+		fileStore, err := NewFileStore(cfg.FileStoragePath, l)
 		if err != nil {
 			return nil, fmt.Errorf("failed to init file store: %w", err)
 		}
@@ -29,3 +77,51 @@ func NewStore(cfg *config.Config, l *logger.Logger) (Store, error) {
 	l.Info("using in-memory store")
 	return NewInMemoryStore(), nil
 }
+
+func parseCacheConfig(cfg *config.Config) (CacheConfig, error) {
+	size, err := strconv.Atoi(cfg.URLCacheSize)
+	if err != nil || size < 0 {
+		return CacheConfig{}, fmt.Errorf("invalid url cache size %q", cfg.URLCacheSize)
+	}
+	ttl, err := time.ParseDuration(cfg.URLCacheTTL)
+	if err != nil {
+		return CacheConfig{}, fmt.Errorf("invalid url cache ttl: %w", err)
+	}
+	negativeTTL, err := time.ParseDuration(cfg.URLCacheNegativeTTL)
+	if err != nil {
+		return CacheConfig{}, fmt.Errorf("invalid url cache negative ttl: %w", err)
+	}
+	return CacheConfig{Size: size, TTL: ttl, NegativeTTL: negativeTTL}, nil
+}
+
+// Unwrap снимает с хранилища декораторы, которые добавляет NewStore, — например, кэш
+func Unwrap(s Store) Store {
+	for {
+		u, ok := s.(interface{ Unwrap() Store })
+		if !ok {
+			return s
+		}
+		s = u.Unwrap()
+	}
+}
+
+// BackendName — короткое имя реализации хранилища для логов и метрик
+func BackendName(s Store) string {
+	s = Unwrap(s)
+	switch s.(type) {
+	case *DBStore:
+		return "postgres"
+	case *RedisStore:
+		return "redis"
+	case *BoltStore:
+		return "bolt"
+	case *SQLiteStore:
+		return "sqlite"
+	case *FileStore:
+		return "file"
+	case *InMemoryStore:
+		return "memory"
+	default:
+		return fmt.Sprintf("%T", s)
+	}
+}
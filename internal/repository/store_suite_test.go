@@ -0,0 +1,371 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kayumovtd/url-shortener/internal/model"
+)
+
+// runStoreSuite — общие поведенческие тесты хранилищ. Проверяет только то, в чём все бэкенды
+// обязаны совпадать; newStore должен отдавать пустое хранилище. Особенности отдельных
+// бэкендов (переоткрытие, формат на диске) проверяются рядом с ними.
+func runStoreSuite(t *testing.T, newStore func(t *testing.T) Store) {
+	ctx := context.Background()
+
+	t.Run("save_and_get", func(t *testing.T) {
+		s := newStore(t)
+		if err := s.SaveURL(ctx, "abc", "https://example.com", "u1"); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+
+		rec, err := s.GetURL(ctx, "abc")
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+		// Пустое состояние модерации сервис читает как active, SQL-схемы проставляют его сами
+		if rec.ID == "" || rec.UserID != "u1" || rec.ShortURL != "abc" || rec.OriginalURL != "https://example.com" ||
+			rec.IsDeleted || rec.IsDisabled || (rec.Moderation != "" && rec.Moderation != model.ModerationActive) {
+			t.Errorf("unexpected record: %+v", rec)
+		}
+
+		if _, err := s.GetURL(ctx, "nope"); !errors.Is(err, ErrStoreNotFound) {
+			t.Errorf("expected not found, got %v", err)
+		}
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		s := newStore(t)
+		s.SaveURL(ctx, "abc", "https://example.com", "u1")
+
+		err := s.SaveURL(ctx, "xyz", "https://example.com", "u2")
+		var conflict *ErrStoreConflict
+		if !errors.As(err, &conflict) {
+			t.Fatalf("expected conflict, got %v", err)
+		}
+
+		err = s.SaveURL(ctx, "abc", "https://other.com", "u2")
+		if !errors.As(err, &conflict) {
+			t.Errorf("expected conflict for taken short url, got %v", err)
+		}
+		if rec, _ := s.GetURL(ctx, "abc"); rec.OriginalURL != "https://example.com" || rec.UserID != "u1" {
+			t.Errorf("conflicting save changed the record: %+v", rec)
+		}
+	})
+
+	t.Run("batch_is_atomic", func(t *testing.T) {
+		s := newStore(t)
+		s.SaveURL(ctx, "abc", "https://example.com", "u1")
+
+		err := s.SaveURLs(ctx, map[string]string{
+			"new": "https://new.example.com",
+			"xyz": "https://example.com",
+		}, "u1")
+		if err == nil {
+			t.Fatal("expected error for a duplicate original url")
+		}
+		if _, err := s.GetURL(ctx, "new"); !errors.Is(err, ErrStoreNotFound) {
+			t.Errorf("part of a failed batch was saved: %v", err)
+		}
+	})
+
+	t.Run("batch_resave_is_idempotent", func(t *testing.T) {
+		s := newStore(t)
+		urls := map[string]string{"a": "https://a.com", "b": "https://b.com"}
+		if err := s.SaveURLs(ctx, urls, "u1"); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+		if err := s.SaveURLs(ctx, urls, "u1"); err != nil {
+			t.Fatalf("resave failed: %v", err)
+		}
+
+		recs, _ := s.GetUserURLs(ctx, "u1")
+		if len(recs) != 2 {
+			t.Errorf("expected 2 urls, got %+v", recs)
+		}
+		if stats, _ := s.GetStats(ctx); stats.URLs != 2 {
+			t.Errorf("resave changed url count: %+v", stats)
+		}
+	})
+
+	t.Run("delete_permissions", func(t *testing.T) {
+		s := newStore(t)
+		s.SaveURLs(ctx, map[string]string{"own": "https://own.com", "other": "https://other.com"}, "u1")
+		s.SaveURL(ctx, "foreign", "https://foreign.com", "u2")
+
+		if err := s.MarkURLsDeleted(ctx, "u1", []string{"own", "foreign", "missing"}); err != nil {
+			t.Fatalf("delete failed: %v", err)
+		}
+
+		for short, want := range map[string]bool{"own": true, "other": false, "foreign": false} {
+			rec, _ := s.GetURL(ctx, short)
+			if rec.IsDeleted != want {
+				t.Errorf("%s: is_deleted = %v, want %v", short, rec.IsDeleted, want)
+			}
+		}
+		if stats, _ := s.GetStats(ctx); stats.URLs != 2 || stats.Users != 2 {
+			t.Errorf("unexpected stats: %+v", stats)
+		}
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		s := newStore(t)
+		s.SaveURL(ctx, "a", "https://a.com", "anon")
+		s.SaveURL(ctx, "b", "https://b.com", "user")
+
+		if err := s.MergeUserURLs(ctx, "anon", "user"); err != nil {
+			t.Fatalf("merge failed: %v", err)
+		}
+
+		recs, _ := s.GetUserURLs(ctx, "user")
+		if len(recs) != 2 || recs[0].UserID != "user" || recs[1].UserID != "user" {
+			t.Errorf("unexpected user urls: %+v", recs)
+		}
+		if recs, _ := s.GetUserURLs(ctx, "anon"); len(recs) != 0 {
+			t.Errorf("anonymous user still has urls: %+v", recs)
+		}
+		if stats, _ := s.GetStats(ctx); stats.Users != 1 {
+			t.Errorf("merged user is still counted: %+v", stats)
+		}
+	})
+
+	t.Run("disable_and_moderate", func(t *testing.T) {
+		s := newStore(t)
+		s.SaveURL(ctx, "abc", "https://example.com", "u1")
+		now := time.Now().UTC().Truncate(time.Second)
+		s.SaveAbuseReport(ctx, model.AbuseReport{ID: "r1", ShortURL: "abc", Reason: "spam", ReporterID: "u2", CreatedAt: now})
+		s.SaveAbuseReport(ctx, model.AbuseReport{ID: "r2", ShortURL: "other", Reason: "spam", ReporterID: "u2", CreatedAt: now.Add(time.Second)})
+
+		if err := s.SetURLDisabled(ctx, "abc", true); err != nil {
+			t.Fatalf("disable failed: %v", err)
+		}
+		if err := s.SetURLDisabled(ctx, "nope", true); !errors.Is(err, ErrStoreNotFound) {
+			t.Errorf("expected not found, got %v", err)
+		}
+
+		if err := s.SetURLModeration(ctx, "abc", model.ModerationBlocked, "phishing"); err != nil {
+			t.Fatalf("moderation failed: %v", err)
+		}
+		if err := s.SetURLModeration(ctx, "nope", model.ModerationBlocked, ""); !errors.Is(err, ErrStoreNotFound) {
+			t.Errorf("expected not found, got %v", err)
+		}
+		rec, _ := s.GetURL(ctx, "abc")
+		if !rec.IsDisabled || rec.Moderation != model.ModerationBlocked || rec.ModerationReason != "phishing" {
+			t.Errorf("unexpected record: %+v", rec)
+		}
+
+		reports, _ := s.GetOpenAbuseReports(ctx)
+		if len(reports) != 1 || reports[0].ID != "r2" || !reports[0].CreatedAt.Equal(now.Add(time.Second)) {
+			t.Errorf("unexpected open reports: %+v", reports)
+		}
+	})
+
+	t.Run("set_original", func(t *testing.T) {
+		s := newStore(t)
+		s.SaveURL(ctx, "abc", "https://example.com", "u1")
+		s.SaveURL(ctx, "xyz", "https://other.com", "u2")
+
+		if err := s.SetOriginalURL(ctx, "abc", "https://new.com"); err != nil {
+			t.Fatalf("set original failed: %v", err)
+		}
+		if rec, _ := s.GetURL(ctx, "abc"); rec.OriginalURL != "https://new.com" || rec.UserID != "u1" {
+			t.Errorf("unexpected record: %+v", rec)
+		}
+		// Прежний оригинал освобождается для новых ссылок
+		if err := s.SaveURL(ctx, "def", "https://example.com", "u1"); err != nil {
+			t.Errorf("old original is still taken: %v", err)
+		}
+		if err := s.SetOriginalURL(ctx, "abc", "https://new.com"); err != nil {
+			t.Errorf("setting the same original failed: %v", err)
+		}
+
+		err := s.SetOriginalURL(ctx, "abc", "https://other.com")
+		var conflict *ErrStoreConflict
+		if !errors.As(err, &conflict) || conflict.ShortURL != "xyz" {
+			t.Errorf("expected conflict with xyz, got %v", err)
+		}
+		if rec, _ := s.GetURL(ctx, "abc"); rec.OriginalURL != "https://new.com" {
+			t.Errorf("conflicting update changed the record: %+v", rec)
+		}
+
+		if err := s.SetOriginalURL(ctx, "nope", "https://nope.com"); !errors.Is(err, ErrStoreNotFound) {
+			t.Errorf("expected not found, got %v", err)
+		}
+	})
+
+	t.Run("workspaces", func(t *testing.T) {
+		s := newStore(t)
+
+		ws := model.Workspace{ID: "ws1", Name: "Team", CreatedAt: time.Now().UTC().Truncate(time.Second)}
+		if err := s.SaveWorkspace(ctx, ws, "owner"); err != nil {
+			t.Fatalf("save workspace failed: %v", err)
+		}
+		if err := s.SaveWorkspace(ctx, ws, "owner"); !errors.Is(err, ErrStoreAlreadyExists) {
+			t.Errorf("expected already exists, got %v", err)
+		}
+		s.SaveWorkspaceMember(ctx, model.WorkspaceMember{WorkspaceID: "ws1", UserID: "editor", Role: model.RoleViewer})
+		s.SaveWorkspaceMember(ctx, model.WorkspaceMember{WorkspaceID: "ws1", UserID: "editor", Role: model.RoleEditor})
+		s.SaveWorkspaceMember(ctx, model.WorkspaceMember{WorkspaceID: "ws1", UserID: "viewer", Role: model.RoleViewer})
+
+		got, err := s.GetWorkspace(ctx, "ws1")
+		if err != nil || got.Name != "Team" || !got.CreatedAt.Equal(ws.CreatedAt) {
+			t.Errorf("unexpected workspace: %+v, %v", got, err)
+		}
+		if wss, _ := s.GetUserWorkspaces(ctx, "editor"); len(wss) != 1 || wss[0].Role != model.RoleEditor || wss[0].Name != "Team" {
+			t.Errorf("unexpected user workspaces: %+v", wss)
+		}
+		if members, _ := s.GetWorkspaceMembers(ctx, "ws1"); len(members) != 3 {
+			t.Errorf("unexpected members: %+v", members)
+		}
+		if m, err := s.GetWorkspaceMember(ctx, "ws1", "owner"); err != nil || m.Role != model.RoleOwner {
+			t.Errorf("unexpected owner: %+v, %v", m, err)
+		}
+
+		s.SaveURLs(ctx, map[string]string{"a": "https://a.com", "b": "https://b.com"}, "owner")
+		if err := s.MoveURLsToWorkspace(ctx, "owner", "ws1", []string{"a"}); err != nil {
+			t.Fatalf("move failed: %v", err)
+		}
+		urls, _ := s.GetWorkspaceURLs(ctx, "ws1")
+		if len(urls) != 1 || urls[0].ShortURL != "a" {
+			t.Fatalf("unexpected workspace urls: %+v", urls)
+		}
+
+		s.MarkURLsDeleted(ctx, "viewer", []string{"a"})
+		if rec, _ := s.GetURL(ctx, "a"); rec.IsDeleted {
+			t.Error("viewer deleted a workspace url")
+		}
+		s.MarkURLsDeleted(ctx, "editor", []string{"a"})
+		if rec, _ := s.GetURL(ctx, "a"); !rec.IsDeleted {
+			t.Error("editor could not delete a workspace url")
+		}
+		s.SetURLModeration(ctx, "a", model.ModerationBlocked, "phishing")
+		if urls, _ := s.GetWorkspaceURLs(ctx, "ws1"); len(urls) != 1 || !urls[0].IsDeleted ||
+			urls[0].Moderation != model.ModerationBlocked || urls[0].ModerationReason != "phishing" {
+			t.Errorf("workspace urls do not carry the record state: %+v", urls)
+		}
+
+		if err := s.DeleteWorkspaceMember(ctx, "ws1", "editor"); err != nil {
+			t.Fatalf("delete member failed: %v", err)
+		}
+		if err := s.DeleteWorkspaceMember(ctx, "ws1", "editor"); !errors.Is(err, ErrStoreNotFound) {
+			t.Errorf("expected not found, got %v", err)
+		}
+		if wss, _ := s.GetUserWorkspaces(ctx, "editor"); len(wss) != 0 {
+			t.Errorf("removed member still sees the workspace: %+v", wss)
+		}
+	})
+
+	t.Run("keeps_last_owner", func(t *testing.T) {
+		s := newStore(t)
+		s.SaveWorkspace(ctx, model.Workspace{ID: "ws1", Name: "Team", CreatedAt: time.Now().UTC()}, "o1")
+		s.SaveWorkspaceMember(ctx, model.WorkspaceMember{WorkspaceID: "ws1", UserID: "o2", Role: model.RoleOwner})
+
+		if err := s.SaveWorkspaceMember(ctx, model.WorkspaceMember{WorkspaceID: "ws1", UserID: "o1", Role: model.RoleEditor}); err != nil {
+			t.Fatalf("demoting one of two owners failed: %v", err)
+		}
+		if err := s.SaveWorkspaceMember(ctx, model.WorkspaceMember{WorkspaceID: "ws1", UserID: "o2", Role: model.RoleViewer}); !errors.Is(err, ErrStoreLastOwner) {
+			t.Errorf("expected last owner on demote, got %v", err)
+		}
+		if err := s.DeleteWorkspaceMember(ctx, "ws1", "o2"); !errors.Is(err, ErrStoreLastOwner) {
+			t.Errorf("expected last owner on delete, got %v", err)
+		}
+		if m, _ := s.GetWorkspaceMember(ctx, "ws1", "o2"); m.Role != model.RoleOwner {
+			t.Errorf("last owner was changed: %+v", m)
+		}
+
+		// Взаимное понижение двух владельцев: проверка и запись атомарны, одно из них обязано отказать
+		s.SaveWorkspaceMember(ctx, model.WorkspaceMember{WorkspaceID: "ws1", UserID: "o1", Role: model.RoleOwner})
+		errs := make(chan error, 2)
+		for _, id := range []string{"o1", "o2"} {
+			go func() {
+				errs <- s.SaveWorkspaceMember(ctx, model.WorkspaceMember{WorkspaceID: "ws1", UserID: id, Role: model.RoleEditor})
+			}()
+		}
+		var refused int
+		for range 2 {
+			if err := <-errs; errors.Is(err, ErrStoreLastOwner) {
+				refused++
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}
+		owners := 0
+		members, _ := s.GetWorkspaceMembers(ctx, "ws1")
+		for _, m := range members {
+			if m.Role == model.RoleOwner {
+				owners++
+			}
+		}
+		if refused != 1 || owners != 1 {
+			t.Errorf("expected exactly one refusal and one owner, got %d and %d", refused, owners)
+		}
+	})
+
+	t.Run("users_and_keys", func(t *testing.T) {
+		s := newStore(t)
+
+		user := model.User{ID: "u1", Login: "alice", PasswordHash: "hash", CreatedAt: time.Now().UTC().Truncate(time.Second)}
+		if err := s.SaveUser(ctx, user); err != nil {
+			t.Fatalf("save user failed: %v", err)
+		}
+		if err := s.SaveUser(ctx, model.User{ID: "u2", Login: "alice", CreatedAt: user.CreatedAt}); !errors.Is(err, ErrStoreAlreadyExists) {
+			t.Errorf("expected already exists for duplicate login, got %v", err)
+		}
+		got, err := s.GetUserByLogin(ctx, "alice")
+		if err != nil || got.ID != user.ID || got.PasswordHash != user.PasswordHash || !got.CreatedAt.Equal(user.CreatedAt) {
+			t.Errorf("unexpected user: %+v, %v", got, err)
+		}
+		if _, err := s.GetUser(ctx, "nope"); !errors.Is(err, ErrStoreNotFound) {
+			t.Errorf("expected not found, got %v", err)
+		}
+
+		for i, id := range []string{"k1", "k2"} {
+			key := model.APIKey{ID: id, UserID: "u1", Name: "ci", Prefix: "sk_", Hash: "h-" + id, CreatedAt: user.CreatedAt.Add(time.Duration(i) * time.Hour)}
+			if err := s.SaveAPIKey(ctx, key); err != nil {
+				t.Fatalf("save api key failed: %v", err)
+			}
+		}
+		if err := s.RevokeAPIKey(ctx, "u2", "k1"); !errors.Is(err, ErrStoreNotFound) {
+			t.Errorf("other user revoked the key: %v", err)
+		}
+		if err := s.RevokeAPIKey(ctx, "u1", "k1"); err != nil {
+			t.Fatalf("revoke failed: %v", err)
+		}
+
+		key, err := s.GetAPIKeyByHash(ctx, "h-k1")
+		if err != nil || key.RevokedAt == nil || key.Name != "ci" {
+			t.Fatalf("unexpected api key: %+v, %v", key, err)
+		}
+		firstRevoke := *key.RevokedAt
+		s.RevokeAPIKey(ctx, "u1", "k1")
+
+		keys, _ := s.GetUserAPIKeys(ctx, "u1")
+		if len(keys) != 2 || keys[0].ID != "k1" || keys[1].RevokedAt != nil {
+			t.Fatalf("unexpected user api keys: %+v", keys)
+		}
+		if !keys[0].RevokedAt.Equal(firstRevoke) {
+			t.Errorf("second revoke changed revoked_at: %v != %v", keys[0].RevokedAt, firstRevoke)
+		}
+
+		s.SaveURL(ctx, "abc", "https://example.com", "anon")
+		if stats, _ := s.GetStats(ctx); stats.URLs != 1 || stats.Users != 2 {
+			t.Errorf("unexpected stats: %+v", stats)
+		}
+
+		if err := s.MergeUserAPIKeys(ctx, "u1", "u3"); err != nil {
+			t.Fatalf("merge api keys failed: %v", err)
+		}
+		if keys, _ := s.GetUserAPIKeys(ctx, "u1"); len(keys) != 0 {
+			t.Errorf("merged user still has api keys: %+v", keys)
+		}
+		keys, _ = s.GetUserAPIKeys(ctx, "u3")
+		if len(keys) != 2 || keys[0].UserID != "u3" || keys[0].RevokedAt == nil || keys[1].RevokedAt != nil {
+			t.Errorf("unexpected merged api keys: %+v", keys)
+		}
+		if key, _ := s.GetAPIKeyByHash(ctx, "h-k2"); key.UserID != "u3" {
+			t.Errorf("api key by hash still points to the old user: %+v", key)
+		}
+	})
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"slices"
+
+	"github.com/kayumovtd/url-shortener/internal/model"
+)
+
+// urlTable — ссылки в памяти с хеш-индексами, общая часть InMemoryStore и FileStore.
+// Записи не удаляются, поэтому индекс в records стабилен. Списки по пользователю и
+// пространству отсортированы: выдача идёт в порядке добавления, как при полном проходе.
+// Синхронизация — на стороне хранилища.
+type urlTable struct {
+	records     []model.URLRecord
+	byShort     map[string]int
+	byOriginal  map[string]int
+	byUser      map[string][]int
+	byWorkspace map[string][]int // личные ссылки (workspace_id = "") не индексируются
+}
+
+func newURLTable(records []model.URLRecord) *urlTable {
+	t := &urlTable{
+		records:     make([]model.URLRecord, 0, len(records)),
+		byShort:     make(map[string]int, len(records)),
+		byOriginal:  make(map[string]int, len(records)),
+		byUser:      map[string][]int{},
+		byWorkspace: map[string][]int{},
+	}
+	for _, rec := range records {
+		t.put(rec)
+	}
+	return t
+}
+
+func (t *urlTable) get(shortURL string) (model.URLRecord, bool) {
+	i, ok := t.byShort[shortURL]
+	if !ok {
+		return model.URLRecord{}, false
+	}
+	return t.records[i], true
+}
+
+func (t *urlTable) getByOriginal(originalURL string) (model.URLRecord, bool) {
+	i, ok := t.byOriginal[originalURL]
+	if !ok {
+		return model.URLRecord{}, false
+	}
+	return t.records[i], true
+}
+
+// batchConflict ищет в пачке оригинал, который уже занят другим short_url в таблице или в
+// самой пачке, — как уникальный индекс original_url в БД
+func (t *urlTable) batchConflict(urls map[string]string) error {
+	seen := make(map[string]string, len(urls))
+	for short, original := range urls {
+		owner, ok := seen[original]
+		if rec, found := t.getByOriginal(original); found {
+			owner, ok = rec.ShortURL, true
+		}
+		if ok && owner != short {
+			return NewErrStoreConflict(owner, original, nil)
+		}
+		seen[original] = short
+	}
+	return nil
+}
+
+// retarget готовит запись с новым оригиналом, не меняя таблицу. Занятый другой ссылкой
+// оригинал — конфликт, как уникальный индекс original_url в БД.
+func (t *urlTable) retarget(shortURL, originalURL string) (model.URLRecord, error) {
+	rec, ok := t.get(shortURL)
+	if !ok {
+		return model.URLRecord{}, ErrStoreNotFound
+	}
+	if owner, found := t.getByOriginal(originalURL); found && owner.ShortURL != shortURL {
+		return model.URLRecord{}, NewErrStoreConflict(owner.ShortURL, originalURL, nil)
+	}
+	rec.OriginalURL = originalURL
+	return rec, nil
+}
+
+// put добавляет запись или заменяет запись с тем же short_url, поддерживая индексы
+func (t *urlTable) put(rec model.URLRecord) {
+	i, ok := t.byShort[rec.ShortURL]
+	if !ok {
+		i = len(t.records)
+		t.records = append(t.records, rec)
+		t.byShort[rec.ShortURL] = i
+		t.byOriginal[rec.OriginalURL] = i
+		t.byUser[rec.UserID] = append(t.byUser[rec.UserID], i)
+		if rec.WorkspaceID != "" {
+			t.byWorkspace[rec.WorkspaceID] = append(t.byWorkspace[rec.WorkspaceID], i)
+		}
+		return
+	}
+
+	old := t.records[i]
+	t.records[i] = rec
+	if old.OriginalURL != rec.OriginalURL {
+		if t.byOriginal[old.OriginalURL] == i {
+			delete(t.byOriginal, old.OriginalURL)
+		}
+		t.byOriginal[rec.OriginalURL] = i
+	}
+	if old.UserID != rec.UserID {
+		removeIndex(t.byUser, old.UserID, i)
+		insertIndex(t.byUser, rec.UserID, i)
+	}
+	if old.WorkspaceID != rec.WorkspaceID {
+		if old.WorkspaceID != "" {
+			removeIndex(t.byWorkspace, old.WorkspaceID, i)
+		}
+		if rec.WorkspaceID != "" {
+			insertIndex(t.byWorkspace, rec.WorkspaceID, i)
+		}
+	}
+}
+
+// update меняет запись по short_url, поля-ключи индексов fn менять не должна
+func (t *urlTable) update(shortURL string, fn func(*model.URLRecord)) bool {
+	i, ok := t.byShort[shortURL]
+	if ok {
+		fn(&t.records[i])
+	}
+	return ok
+}
+
+// mergeUser переносит все ссылки fromUserID на toUserID за O(k), а не поштучным put
+func (t *urlTable) mergeUser(fromUserID, toUserID string) int {
+	moved := t.byUser[fromUserID]
+	if len(moved) == 0 || fromUserID == toUserID {
+		return 0
+	}
+
+	for _, i := range moved {
+		t.records[i].UserID = toUserID
+	}
+	delete(t.byUser, fromUserID)
+
+	merged := append(t.byUser[toUserID], moved...)
+	slices.Sort(merged)
+	t.byUser[toUserID] = merged
+	return len(moved)
+}
+
+func (t *urlTable) userURLs(userID string) []model.URLRecord {
+	return t.collect(t.byUser[userID])
+}
+
+func (t *urlTable) workspaceURLs(workspaceID string) []model.URLRecord {
+	return t.collect(t.byWorkspace[workspaceID])
+}
+
+func (t *urlTable) collect(idx []int) []model.URLRecord {
+	urls := make([]model.URLRecord, 0, len(idx))
+	for _, i := range idx {
+		urls = append(urls, t.records[i])
+	}
+	return urls
+}
+
+func insertIndex(index map[string][]int, key string, i int) {
+	list := index[key]
+	pos, _ := slices.BinarySearch(list, i)
+	index[key] = slices.Insert(list, pos, i)
+}
+
+func removeIndex(index map[string][]int, key string, i int) {
+	list := index[key]
+	pos, found := slices.BinarySearch(list, i)
+	if !found {
+		return
+	}
+	list = slices.Delete(list, pos, pos+1)
+	if len(list) == 0 {
+		delete(index, key)
+		return
+	}
+	index[key] = list
+}
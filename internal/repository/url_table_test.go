@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/kayumovtd/url-shortener/internal/logger"
+	"github.com/kayumovtd/url-shortener/internal/model"
+)
+
+// Бенчмарки на миллионе ссылок. Каждая операция меряется трижды на одних и тех же данных:
+// scan — полный проход по срезу под мьютексом, как хранилища искали ссылки до индексов,
+// in_memory и file — InMemoryStore и FileStore с urlTable. Сравнение до/после:
+//
+//	go test -run '^$' -bench URLTable ./internal/repository
+const (
+	benchRecords = 1_000_000
+	benchBatch   = 10_000
+)
+
+// scanTable воспроизводит старый поиск: O(n) на GetURL и конфликт, O(n·m) на удаление
+type scanTable struct {
+	mu      sync.Mutex
+	records []model.URLRecord
+}
+
+func (t *scanTable) get(shortURL string) (model.URLRecord, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, rec := range t.records {
+		if rec.ShortURL == shortURL {
+			return rec, true
+		}
+	}
+	return model.URLRecord{}, false
+}
+
+func (t *scanTable) conflict(shortURL, originalURL string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, rec := range t.records {
+		if rec.OriginalURL == originalURL || rec.ShortURL == shortURL {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *scanTable) markDeleted(userID string, shortURLs []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, short := range shortURLs {
+		for i := range t.records {
+			if t.records[i].ShortURL == short && t.records[i].UserID == userID {
+				t.records[i].IsDeleted = true
+			}
+		}
+	}
+}
+
+// benchDataset вызывает save для каждой пачки набора: у каждой пачки свой автор
+func benchDataset(save func(urls map[string]string, userID string)) {
+	for start := 0; start < benchRecords; start += benchBatch {
+		urls := make(map[string]string, benchBatch)
+		for i := start; i < start+benchBatch; i++ {
+			urls["s"+strconv.Itoa(i)] = "https://example.com/" + strconv.Itoa(i)
+		}
+		save(urls, "u"+strconv.Itoa(start/benchBatch))
+	}
+}
+
+// benchStores лениво загружает набор в каждый вариант: под -bench с фильтром грузится только нужный
+type benchStores struct {
+	scan     func() *scanTable
+	inMemory func() Store
+	file     func() Store
+}
+
+func newBenchStores(b *testing.B) benchStores {
+	ctx := context.Background()
+	// TempDir родительского бенчмарка живёт, пока идут все вложенные
+	dir := b.TempDir()
+
+	return benchStores{
+		scan: sync.OnceValue(func() *scanTable {
+			t := &scanTable{}
+			benchDataset(func(urls map[string]string, userID string) {
+				for short, orig := range urls {
+					t.records = append(t.records, model.URLRecord{UserID: userID, ShortURL: short, OriginalURL: orig})
+				}
+			})
+			return t
+		}),
+		inMemory: sync.OnceValue(func() Store {
+			s := NewInMemoryStore()
+			benchDataset(func(urls map[string]string, userID string) { s.SaveURLs(ctx, urls, userID) })
+			return s
+		}),
+		file: sync.OnceValue(func() Store {
+			s, err := NewFileStore(filepath.Join(dir, "storage.json"), logger.NewNoOp())
+			if err != nil {
+				b.Fatalf("failed to open file store: %v", err)
+			}
+			b.Cleanup(s.Close)
+			benchDataset(func(urls map[string]string, userID string) {
+				if err := s.SaveURLs(ctx, urls, userID); err != nil {
+					b.Fatalf("failed to load file store: %v", err)
+				}
+			})
+			return s
+		}),
+	}
+}
+
+// runStores запускает один и тот же бенчмарк на InMemoryStore и FileStore
+func (bs benchStores) runStores(b *testing.B, bench func(b *testing.B, s Store)) {
+	b.Run("in_memory", func(b *testing.B) { bench(b, bs.inMemory()) })
+	b.Run("file", func(b *testing.B) { bench(b, bs.file()) })
+}
+
+func BenchmarkURLTable(b *testing.B) {
+	ctx := context.Background()
+	bs := newBenchStores(b)
+
+	b.Run("GetURL", func(b *testing.B) {
+		b.Run("scan", func(b *testing.B) {
+			t := bs.scan()
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for i := 0; pb.Next(); i++ {
+					t.get("s" + strconv.Itoa(i*7919%benchRecords))
+				}
+			})
+		})
+		bs.runStores(b, func(b *testing.B, s Store) {
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for i := 0; pb.Next(); i++ {
+					s.GetURL(ctx, "s"+strconv.Itoa(i*7919%benchRecords))
+				}
+			})
+		})
+	})
+
+	b.Run("SaveURLConflict", func(b *testing.B) {
+		b.Run("scan", func(b *testing.B) {
+			t := bs.scan()
+			for i := 0; b.Loop(); i++ {
+				t.conflict("x", "https://example.com/"+strconv.Itoa(i*7919%benchRecords))
+			}
+		})
+		bs.runStores(b, func(b *testing.B, s Store) {
+			for i := 0; b.Loop(); i++ {
+				s.SaveURL(ctx, "x", "https://example.com/"+strconv.Itoa(i*7919%benchRecords), "u1")
+			}
+		})
+	})
+
+	// Удаление от чужого пользователя: ничего не меняется, меряется только поиск 100 ссылок
+	b.Run("MarkURLsDeleted", func(b *testing.B) {
+		shorts := make([]string, 100)
+		fill := func(i int) {
+			for j := range shorts {
+				shorts[j] = "s" + strconv.Itoa((i*100+j)%benchRecords)
+			}
+		}
+		b.Run("scan", func(b *testing.B) {
+			t := bs.scan()
+			for i := 0; b.Loop(); i++ {
+				fill(i)
+				t.markDeleted("nobody", shorts)
+			}
+		})
+		bs.runStores(b, func(b *testing.B, s Store) {
+			for i := 0; b.Loop(); i++ {
+				fill(i)
+				s.MarkURLsDeleted(ctx, "nobody", shorts)
+			}
+		})
+	})
+}
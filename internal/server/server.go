@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type Timeouts struct {
+	ReadHeader time.Duration
+	Read       time.Duration
+	Write      time.Duration
+	Idle       time.Duration
+}
+
+func New(addr string, h http.Handler, t Timeouts) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadHeaderTimeout: t.ReadHeader,
+		ReadTimeout:       t.Read,
+		WriteTimeout:      t.Write,
+		IdleTimeout:       t.Idle,
+	}
+}
+
+// Serve запускает srv и блокируется до его остановки.
+// Остановка через Shutdown ошибкой не считается.
+func Serve(srv *http.Server) error {
+	var err error
+	if srv.TLSConfig != nil {
+		err = srv.ListenAndServeTLS("", "")
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Closer — шаг остановки: фоновый обработчик, хранилище и т. п.
+type Closer struct {
+	Name  string
+	Close func(ctx context.Context) error
+}
+
+// Shutdown останавливает приложение по порядку: сначала перестаёт принимать соединения
+// и дожидается начатых запросов, потом по очереди закрывает closers.
+// Все шаги делят один дедлайн ctx; ошибка шага не мешает выполнить следующие.
+func Shutdown(ctx context.Context, servers []*http.Server, closers ...Closer) error {
+	var errs []error
+
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			// Не дождались запросов — рвём оставшиеся соединения
+			srv.Close()
+			errs = append(errs, fmt.Errorf("shutdown server %s: %w", srv.Addr, err))
+		}
+	}
+
+	for _, c := range closers {
+		if err := c.Close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("close %s: %w", c.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestShutdownDrainsRequestsBeforeClosers(t *testing.T) {
+	started := make(chan struct{})
+	var events []string
+
+	srv := New("", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		events = append(events, "request")
+	}), Timeouts{})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	go srv.Serve(ln)
+
+	go http.Get("http://" + ln.Addr().String())
+	<-started
+
+	closer := func(name string) Closer {
+		return Closer{Name: name, Close: func(context.Context) error {
+			events = append(events, name)
+			return nil
+		}}
+	}
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+	defer cancel()
+	if err := Shutdown(ctx, []*http.Server{srv}, closer("deleter"), closer("store")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"request", "deleter", "store"}
+	if len(events) != len(want) {
+		t.Fatalf("events = %v, want %v", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Fatalf("events = %v, want %v", events, want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSelfSignedServesHTTP2(t *testing.T) {
+	tlsCfg, err := NewTLSConfig("", "", []string{"localhost", "127.0.0.1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	srv.TLS = tlsCfg
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	leaf, err := x509.ParseCertificate(tlsCfg.Certificates[0].Certificate[0])
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: pool},
+		ForceAttemptHTTP2: true,
+	}}
+	res, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.ProtoMajor != 2 {
+		t.Errorf("protocol = %s, want HTTP/2", res.Proto)
+	}
+}
+
+func TestNewTLSConfigRequiresBothFiles(t *testing.T) {
+	if _, err := NewTLSConfig("cert.pem", "", nil); err == nil {
+		t.Error("expected error when key file is missing")
+	}
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	tests := []struct {
+		name      string
+		httpsAddr string
+		host      string
+		want      string
+	}{
+		{"default_port", ":443", "example.com", "https://example.com/abc?x=1"},
+		{"custom_port", ":8443", "example.com:8080", "https://example.com:8443/abc?x=1"},
+		{"ipv6_default_port", ":443", "[::1]", "https://[::1]/abc?x=1"},
+		{"ipv6_with_port", ":443", "[::1]:8080", "https://[::1]/abc?x=1"},
+		{"ipv6_custom_port", ":8443", "[::1]:8080", "https://[::1]:8443/abc?x=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/abc?x=1", nil)
+			req.Host = tt.host
+			w := httptest.NewRecorder()
+
+			RedirectToHTTPS(tt.httpsAddr).ServeHTTP(w, req)
+
+			if w.Code != http.StatusPermanentRedirect {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusPermanentRedirect)
+			}
+			if got := w.Header().Get("Location"); got != tt.want {
+				t.Errorf("location = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
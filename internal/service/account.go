@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"github.com/kayumovtd/url-shortener/internal/repository"
+)
+
+const (
+	minLoginLength    = 3
+	maxLoginLength    = 64
+	minPasswordLength = 8
+	maxPasswordLength = 72 // больше bcrypt не учитывает
+)
+
+var (
+	ErrUserExists         = errors.New("user already exists")
+	ErrInvalidCredentials = errors.New("invalid login or password")
+)
+
+type ErrInvalidAccount struct {
+	Reason string
+}
+
+func (e *ErrInvalidAccount) Error() string {
+	return fmt.Sprintf("invalid account: %s", e.Reason)
+}
+
+type AccountService struct {
+	store repository.Store
+	auth  *AuthService
+}
+
+func NewAccountService(store repository.Store, auth *AuthService) *AccountService {
+	return &AccountService{store: store, auth: auth}
+}
+
+// Register создаёт аккаунт и сразу логинит в него.
+// Данные анонимной сессии sessionUserID переезжают в новый аккаунт, см. mergeAnonymous.
+func (s *AccountService) Register(ctx context.Context, login, password, sessionUserID string) (model.User, string, error) {
+	login = strings.TrimSpace(login)
+	if err := validateCredentials(login, password); err != nil {
+		return model.User{}, "", err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return model.User{}, "", fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user := model.User{
+		ID:           uuid.NewString(),
+		Login:        login,
+		PasswordHash: string(hash),
+		CreatedAt:    time.Now().UTC(),
+	}
+
+	if err := s.store.SaveUser(ctx, user); err != nil {
+		if errors.Is(err, repository.ErrStoreAlreadyExists) {
+			return model.User{}, "", ErrUserExists
+		}
+		return model.User{}, "", fmt.Errorf("failed to save user: %w", err)
+	}
+
+	token, err := s.signIn(ctx, user, sessionUserID)
+	if err != nil {
+		return model.User{}, "", err
+	}
+
+	return user, token, nil
+}
+
+// Login проверяет пароль и выдаёт токен аккаунта.
+// Данные анонимной сессии sessionUserID переезжают в аккаунт, см. mergeAnonymous.
+func (s *AccountService) Login(ctx context.Context, login, password, sessionUserID string) (model.User, string, error) {
+	user, err := s.store.GetUserByLogin(ctx, strings.TrimSpace(login))
+	if errors.Is(err, repository.ErrStoreNotFound) {
+		// Сравниваем с заглушкой, чтобы по времени ответа нельзя было понять, есть ли такой логин
+		bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
+		return model.User{}, "", ErrInvalidCredentials
+	}
+	if err != nil {
+		return model.User{}, "", fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return model.User{}, "", ErrInvalidCredentials
+	}
+
+	token, err := s.signIn(ctx, user, sessionUserID)
+	if err != nil {
+		return model.User{}, "", err
+	}
+
+	return user, token, nil
+}
+
+func (s *AccountService) signIn(ctx context.Context, user model.User, sessionUserID string) (string, error) {
+	if err := s.mergeAnonymous(ctx, sessionUserID, user.ID); err != nil {
+		return "", err
+	}
+
+	token, err := s.auth.GenerateToken(user.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return token, nil
+}
+
+// mergeAnonymous забирает у анонимного пользователя ссылки, API-ключи и членство в рабочих пространствах.
+// Если в сессии уже другой зарегистрированный аккаунт, его данные не трогаем.
+func (s *AccountService) mergeAnonymous(ctx context.Context, sessionUserID, accountID string) error {
+	if sessionUserID == "" || sessionUserID == accountID {
+		return nil
+	}
+
+	_, err := s.store.GetUser(ctx, sessionUserID)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, repository.ErrStoreNotFound) {
+		return fmt.Errorf("failed to get session user: %w", err)
+	}
+
+	if err := s.store.MergeUserURLs(ctx, sessionUserID, accountID); err != nil {
+		return fmt.Errorf("failed to merge anonymous urls: %w", err)
+	}
+	if err := s.store.MergeUserAPIKeys(ctx, sessionUserID, accountID); err != nil {
+		return fmt.Errorf("failed to merge anonymous api keys: %w", err)
+	}
+	return s.mergeWorkspaces(ctx, sessionUserID, accountID)
+}
+
+var roleRank = map[model.WorkspaceRole]int{model.RoleViewer: 1, model.RoleEditor: 2, model.RoleOwner: 3}
+
+// mergeWorkspaces передаёт аккаунту членство анонима. Если аккаунт уже участник, остаётся более
+// сильная роль. Аккаунт добавляется раньше, чем исключается аноним, так что владелец не пропадает.
+func (s *AccountService) mergeWorkspaces(ctx context.Context, fromUserID, toUserID string) error {
+	workspaces, err := s.store.GetUserWorkspaces(ctx, fromUserID)
+	if err != nil {
+		return fmt.Errorf("failed to get anonymous workspaces: %w", err)
+	}
+
+	for _, ws := range workspaces {
+		role := ws.Role
+		current, err := s.store.GetWorkspaceMember(ctx, ws.ID, toUserID)
+		if err == nil && roleRank[current.Role] > roleRank[role] {
+			role = current.Role
+		}
+		if err != nil && !errors.Is(err, repository.ErrStoreNotFound) {
+			return fmt.Errorf("failed to get workspace member: %w", err)
+		}
+
+		member := model.WorkspaceMember{WorkspaceID: ws.ID, UserID: toUserID, Role: role}
+		if err := s.store.SaveWorkspaceMember(ctx, member); err != nil {
+			return fmt.Errorf("failed to save workspace member: %w", err)
+		}
+		err = s.store.DeleteWorkspaceMember(ctx, ws.ID, fromUserID)
+		if err != nil && !errors.Is(err, repository.ErrStoreNotFound) {
+			return fmt.Errorf("failed to delete workspace member: %w", err)
+		}
+	}
+	return nil
+}
+
+func validateCredentials(login, password string) error {
+	if n := utf8.RuneCountInString(login); n < minLoginLength || n > maxLoginLength {
+		return &ErrInvalidAccount{Reason: fmt.Sprintf("login must be %d to %d characters", minLoginLength, maxLoginLength)}
+	}
+	if strings.HasPrefix(login, oidcLoginPrefix) {
+		return &ErrInvalidAccount{Reason: fmt.Sprintf("login must not start with %q", oidcLoginPrefix)}
+	}
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return &ErrInvalidAccount{Reason: fmt.Sprintf("password must be %d to %d bytes", minPasswordLength, maxPasswordLength)}
+	}
+	return nil
+}
+
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+	return hash
+})
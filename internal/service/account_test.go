@@ -0,0 +1,147 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"github.com/kayumovtd/url-shortener/internal/repository"
+)
+
+func TestAccountService(t *testing.T) {
+	const anonUserID = "anon_user_id"
+
+	store := repository.NewMockStore()
+	store.Data = []model.URLRecord{
+		{ShortURL: "anon1", OriginalURL: "https://example1.com", UserID: anonUserID},
+		{ShortURL: "other", OriginalURL: "https://example2.com", UserID: "some_other_user"},
+	}
+	auth := NewAuthService("secret")
+	svc := NewAccountService(store, auth)
+
+	user, token, err := svc.Register(t.Context(), "alice", "password123", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.PasswordHash == "password123" {
+		t.Fatalf("password must be stored hashed")
+	}
+	if got, _, err := auth.ParseAndVerify(token); err != nil || got != user.ID {
+		t.Fatalf("token user id = %q, err = %v, want %q", got, err, user.ID)
+	}
+
+	t.Run("register_invalid", func(t *testing.T) {
+		_, _, err := svc.Register(t.Context(), "bob", "short", "")
+		var invalid *ErrInvalidAccount
+		if !errors.As(err, &invalid) {
+			t.Errorf("expected ErrInvalidAccount, got: %v", err)
+		}
+	})
+
+	t.Run("register_duplicate", func(t *testing.T) {
+		_, _, err := svc.Register(t.Context(), "alice", "password123", "")
+		if !errors.Is(err, ErrUserExists) {
+			t.Errorf("expected ErrUserExists, got: %v", err)
+		}
+	})
+
+	t.Run("login_wrong_password", func(t *testing.T) {
+		_, _, err := svc.Login(t.Context(), "alice", "wrong-password", "")
+		if !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("expected ErrInvalidCredentials, got: %v", err)
+		}
+	})
+
+	t.Run("login_unknown_user", func(t *testing.T) {
+		_, _, err := svc.Login(t.Context(), "nobody", "password123", "")
+		if !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("expected ErrInvalidCredentials, got: %v", err)
+		}
+	})
+
+	t.Run("login_merges_anonymous_urls", func(t *testing.T) {
+		got, _, err := svc.Login(t.Context(), "alice", "password123", anonUserID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got.ID != user.ID {
+			t.Errorf("user id = %q, want %q", got.ID, user.ID)
+		}
+
+		for _, rec := range store.Data {
+			if rec.ShortURL == "anon1" && rec.UserID != user.ID {
+				t.Errorf("anonymous url owner = %q, want %q", rec.UserID, user.ID)
+			}
+			if rec.ShortURL == "other" && rec.UserID != "some_other_user" {
+				t.Errorf("foreign url must not be merged")
+			}
+		}
+	})
+
+	t.Run("login_keeps_other_account_urls", func(t *testing.T) {
+		other, _, err := svc.Register(t.Context(), "carol", "password123", "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, _, err := svc.Login(t.Context(), "carol", "password123", user.ID); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		for _, rec := range store.Data {
+			if rec.UserID == other.ID {
+				t.Errorf("registered account urls must not be merged")
+			}
+		}
+	})
+}
+
+func TestAccountService_MergesKeysAndWorkspaces(t *testing.T) {
+	const anon = "anon_user_id"
+
+	ctx := t.Context()
+	store := repository.NewInMemoryStore()
+	svc := NewAccountService(store, NewAuthService("secret"))
+
+	user, _, err := svc.Register(ctx, "alice", "password123", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	store.SaveAPIKey(ctx, model.APIKey{ID: "k1", UserID: anon, Hash: "h1"})
+	// Аноним — единственный владелец ws1; в ws2 у аккаунта роль сильнее, в ws3 — слабее
+	store.SaveWorkspace(ctx, model.Workspace{ID: "ws1"}, anon)
+	for _, ws := range []string{"ws2", "ws3"} {
+		store.SaveWorkspace(ctx, model.Workspace{ID: ws}, "someone")
+	}
+	for _, m := range []model.WorkspaceMember{
+		{WorkspaceID: "ws2", UserID: anon, Role: model.RoleViewer},
+		{WorkspaceID: "ws2", UserID: user.ID, Role: model.RoleEditor},
+		{WorkspaceID: "ws3", UserID: anon, Role: model.RoleEditor},
+		{WorkspaceID: "ws3", UserID: user.ID, Role: model.RoleViewer},
+	} {
+		store.SaveWorkspaceMember(ctx, m)
+	}
+
+	if _, _, err := svc.Login(ctx, "alice", "password123", anon); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if keys, _ := store.GetUserAPIKeys(ctx, user.ID); len(keys) != 1 || keys[0].ID != "k1" {
+		t.Errorf("api keys were not merged: %+v", keys)
+	}
+	if wss, _ := store.GetUserWorkspaces(ctx, anon); len(wss) != 0 {
+		t.Errorf("anonymous user is still a member: %+v", wss)
+	}
+
+	want := map[string]model.WorkspaceRole{"ws1": model.RoleOwner, "ws2": model.RoleEditor, "ws3": model.RoleEditor}
+	wss, _ := store.GetUserWorkspaces(ctx, user.ID)
+	if len(wss) != len(want) {
+		t.Fatalf("unexpected workspaces: %+v", wss)
+	}
+	for _, ws := range wss {
+		if ws.Role != want[ws.ID] {
+			t.Errorf("workspace %s role = %q, want %q", ws.ID, ws.Role, want[ws.ID])
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"github.com/kayumovtd/url-shortener/internal/repository"
+)
+
+var ErrURLNotFound = errors.New("url not found")
+
+// AdminService — операции на весь сервис, без проверки владельца.
+// Доступ к ним ограничивается на уровне роутера.
+type AdminService struct {
+	store     repository.Store
+	shortener *ShortenerService
+}
+
+func NewAdminService(store repository.Store, shortener *ShortenerService) *AdminService {
+	return &AdminService{store: store, shortener: shortener}
+}
+
+func (s *AdminService) Stats(ctx context.Context) (model.Stats, error) {
+	stats, err := s.store.GetStats(ctx)
+	if err != nil {
+		return model.Stats{}, fmt.Errorf("failed to get stats: %w", err)
+	}
+	return stats, nil
+}
+
+func (s *AdminService) GetURL(ctx context.Context, shortID string) (model.AdminURLResponse, error) {
+	rec, err := s.store.GetURL(ctx, shortID)
+	if errors.Is(err, repository.ErrStoreNotFound) {
+		return model.AdminURLResponse{}, ErrURLNotFound
+	}
+	if err != nil {
+		return model.AdminURLResponse{}, fmt.Errorf("failed to get url: %w", err)
+	}
+	return s.makeAdminURL(rec), nil
+}
+
+// SetDisabled выключает или снова включает редирект по ссылке
+func (s *AdminService) SetDisabled(ctx context.Context, shortID string, disabled bool) error {
+	err := s.store.SetURLDisabled(ctx, shortID, disabled)
+	if errors.Is(err, repository.ErrStoreNotFound) {
+		return ErrURLNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update url: %w", err)
+	}
+	return nil
+}
+
+// UserURLs отдаёт все ссылки, автором которых является пользователь, включая удалённые и выключенные
+func (s *AdminService) UserURLs(ctx context.Context, userID string) ([]model.AdminURLResponse, error) {
+	urls, err := s.store.GetUserURLs(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user urls: %w", err)
+	}
+
+	response := make([]model.AdminURLResponse, 0, len(urls))
+	for _, rec := range urls {
+		response = append(response, s.makeAdminURL(rec))
+	}
+	return response, nil
+}
+
+func (s *AdminService) makeAdminURL(rec model.URLRecord) model.AdminURLResponse {
+	return model.AdminURLResponse{
+		ShortURL:    s.shortener.makeResultURL(rec.ShortURL),
+		OriginalURL: rec.OriginalURL,
+		UserID:      rec.UserID,
+		WorkspaceID: rec.WorkspaceID,
+		IsDeleted:   rec.IsDeleted,
+		IsDisabled:  rec.IsDisabled,
+
+		Moderation:       moderationState(rec),
+		ModerationReason: rec.ModerationReason,
+	}
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kayumovtd/url-shortener/internal/logger"
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"github.com/kayumovtd/url-shortener/internal/repository"
+)
+
+func TestAdminService(t *testing.T) {
+	store := repository.NewInMemoryStore()
+	bd := NewBatchDeleter(store, logger.NewNoOp())
+	defer bd.Close()
+	shortener := NewShortenerService(store, testBaseURL, bd)
+	svc := NewAdminService(store, shortener)
+
+	ctx := t.Context()
+	store.SaveURL(ctx, "first", "https://example.com/1", "user1")
+	store.SaveURL(ctx, "second", "https://example.com/2", "user1")
+	store.SaveURL(ctx, "third", "https://example.com/3", "user2")
+	store.SaveUser(ctx, model.User{ID: "user3", Login: "no_links"})
+	store.MarkURLsDeleted(ctx, "user1", []string{"second"})
+
+	t.Run("stats", func(t *testing.T) {
+		stats, err := svc.Stats(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := model.Stats{URLs: 2, Users: 3}
+		if stats != want {
+			t.Errorf("stats = %+v, want %+v", stats, want)
+		}
+	})
+
+	t.Run("disable_and_enable", func(t *testing.T) {
+		if err := svc.SetDisabled(ctx, "third", true); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		rec, err := svc.GetURL(ctx, "third")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !rec.IsDisabled || rec.UserID != "user2" || rec.ShortURL != testBaseURL+"/third" {
+			t.Errorf("unexpected record: %+v", rec)
+		}
+
+		if err := svc.SetDisabled(ctx, "third", false); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if rec, _ := svc.GetURL(ctx, "third"); rec.IsDisabled {
+			t.Error("url should be enabled again")
+		}
+	})
+
+	t.Run("unknown_url", func(t *testing.T) {
+		if _, err := svc.GetURL(ctx, "missing"); !errors.Is(err, ErrURLNotFound) {
+			t.Errorf("GetURL error = %v, want ErrURLNotFound", err)
+		}
+		if err := svc.SetDisabled(ctx, "missing", true); !errors.Is(err, ErrURLNotFound) {
+			t.Errorf("SetDisabled error = %v, want ErrURLNotFound", err)
+		}
+	})
+
+	t.Run("user_urls_include_deleted", func(t *testing.T) {
+		urls, err := svc.UserURLs(ctx, "user1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(urls) != 2 {
+			t.Fatalf("got %d urls, want 2", len(urls))
+		}
+	})
+}
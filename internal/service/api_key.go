@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"github.com/kayumovtd/url-shortener/internal/repository"
+)
+
+const (
+	apiKeyPrefix       = "sk_"
+	apiKeyRandomBytes  = 32
+	apiKeyDisplayChars = 8
+	maxAPIKeyNameLen   = 100
+)
+
+var (
+	ErrInvalidAPIKey  = errors.New("invalid api key")
+	ErrAPIKeyNotFound = errors.New("api key not found")
+)
+
+type ErrInvalidAPIKeyName struct {
+	Reason string
+}
+
+func (e *ErrInvalidAPIKeyName) Error() string {
+	return fmt.Sprintf("invalid api key name: %s", e.Reason)
+}
+
+type APIKeyService struct {
+	store repository.Store
+}
+
+func NewAPIKeyService(store repository.Store) *APIKeyService {
+	return &APIKeyService{store: store}
+}
+
+// Create выпускает новый ключ. Сам ключ возвращается только здесь,
+// в хранилище попадает лишь его хэш.
+func (s *APIKeyService) Create(ctx context.Context, userID, name string) (string, model.APIKey, error) {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) > maxAPIKeyNameLen {
+		return "", model.APIKey{}, &ErrInvalidAPIKeyName{Reason: fmt.Sprintf("name must be at most %d characters", maxAPIKeyNameLen)}
+	}
+
+	buf := make([]byte, apiKeyRandomBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", model.APIKey{}, fmt.Errorf("failed to generate api key: %w", err)
+	}
+	plain := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	key := model.APIKey{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    plain[:len(apiKeyPrefix)+apiKeyDisplayChars],
+		Hash:      hashAPIKey(plain),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := s.store.SaveAPIKey(ctx, key); err != nil {
+		return "", model.APIKey{}, fmt.Errorf("failed to save api key: %w", err)
+	}
+
+	return plain, key, nil
+}
+
+func (s *APIKeyService) List(ctx context.Context, userID string) ([]model.APIKeyResponseItem, error) {
+	keys, err := s.store.GetUserAPIKeys(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api keys: %w", err)
+	}
+
+	response := make([]model.APIKeyResponseItem, 0, len(keys))
+	for _, key := range keys {
+		response = append(response, model.APIKeyResponseItem{
+			ID:        key.ID,
+			Name:      key.Name,
+			Prefix:    key.Prefix,
+			CreatedAt: key.CreatedAt,
+			RevokedAt: key.RevokedAt,
+		})
+	}
+
+	return response, nil
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, userID, keyID string) error {
+	err := s.store.RevokeAPIKey(ctx, userID, keyID)
+	if errors.Is(err, repository.ErrStoreNotFound) {
+		return ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return nil
+}
+
+// Resolve возвращает владельца действующего ключа.
+func (s *APIKeyService) Resolve(ctx context.Context, plain string) (string, error) {
+	if !strings.HasPrefix(plain, apiKeyPrefix) {
+		return "", ErrInvalidAPIKey
+	}
+
+	key, err := s.store.GetAPIKeyByHash(ctx, hashAPIKey(plain))
+	if errors.Is(err, repository.ErrStoreNotFound) {
+		return "", ErrInvalidAPIKey
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get api key: %w", err)
+	}
+	if key.RevokedAt != nil {
+		return "", ErrInvalidAPIKey
+	}
+
+	return key.UserID, nil
+}
+
+// Ключи длинные и случайные, так что медленный хэш вроде bcrypt им не нужен
+func hashAPIKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
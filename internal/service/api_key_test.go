@@ -0,0 +1,65 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kayumovtd/url-shortener/internal/repository"
+)
+
+func TestAPIKeyService(t *testing.T) {
+	store := repository.NewMockStore()
+	svc := NewAPIKeyService(store)
+
+	plain, key, err := svc.Create(t.Context(), testUserID, "ci")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key.Hash == plain || store.APIKeys[0].Hash == plain {
+		t.Fatalf("api key must be stored hashed")
+	}
+
+	t.Run("resolve_valid", func(t *testing.T) {
+		userID, err := svc.Resolve(t.Context(), plain)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if userID != testUserID {
+			t.Errorf("user id = %q, want %q", userID, testUserID)
+		}
+	})
+
+	t.Run("resolve_unknown", func(t *testing.T) {
+		_, err := svc.Resolve(t.Context(), "sk_unknown")
+		if !errors.Is(err, ErrInvalidAPIKey) {
+			t.Errorf("expected ErrInvalidAPIKey, got: %v", err)
+		}
+	})
+
+	t.Run("list_hides_key", func(t *testing.T) {
+		keys, err := svc.List(t.Context(), testUserID)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(keys) != 1 || keys[0].ID != key.ID {
+			t.Fatalf("unexpected keys: %v", keys)
+		}
+	})
+
+	t.Run("revoke_other_user", func(t *testing.T) {
+		err := svc.Revoke(t.Context(), "some_other_user", key.ID)
+		if !errors.Is(err, ErrAPIKeyNotFound) {
+			t.Errorf("expected ErrAPIKeyNotFound, got: %v", err)
+		}
+	})
+
+	t.Run("resolve_revoked", func(t *testing.T) {
+		if err := svc.Revoke(t.Context(), testUserID, key.ID); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_, err := svc.Resolve(t.Context(), plain)
+		if !errors.Is(err, ErrInvalidAPIKey) {
+			t.Errorf("expected ErrInvalidAPIKey, got: %v", err)
+		}
+	})
+}
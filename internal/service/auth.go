@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -14,11 +15,16 @@ type UserProvider interface {
 }
 
 type AuthService struct {
-	secret []byte
+	keys *KeyRing
 }
 
 func NewAuthService(secret string) *AuthService {
-	return &AuthService{secret: []byte(secret)}
+	keys, _ := NewKeyRing("", []SigningKey{{Secret: secret}})
+	return &AuthService{keys: keys}
+}
+
+func NewAuthServiceWithKeyRing(keys *KeyRing) *AuthService {
+	return &AuthService{keys: keys}
 }
 
 type Claims struct {
@@ -32,20 +38,33 @@ func (s *AuthService) GenerateNewToken() (userID string, token string, err error
 	return id, tok, err
 }
 
-func (s *AuthService) ParseAndVerify(tokenStr string) (string, error) {
+// ParseAndVerify проверяет токен любым действующим ключом связки.
+// reissue == true, если токен подписан не активным ключом и его стоит перевыпустить.
+func (s *AuthService) ParseAndVerify(tokenStr string) (userID string, reissue bool, err error) {
+	var kid string
 	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return s.secret, nil
-	})
+		kid, _ = token.Header["kid"].(string)
+		key, ok := s.keys.Lookup(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		return []byte(key.Secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
 
 	claims, ok := token.Claims.(*Claims)
 	if !ok || !token.Valid {
-		return "", errors.New("invalid token")
+		return "", false, errors.New("invalid token")
 	}
 
-	return claims.UserID, nil
+	return claims.UserID, kid != s.keys.Active().ID, nil
+}
+
+// GenerateToken выпускает токен для уже известного пользователя.
+func (s *AuthService) GenerateToken(userID string) (string, error) {
+	return s.generateToken(userID)
 }
 
 func (s *AuthService) generateToken(userID string) (string, error) {
@@ -57,8 +76,12 @@ func (s *AuthService) generateToken(userID string) (string, error) {
 		},
 	}
 
+	key := s.keys.Active()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.secret)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+	return token.SignedString([]byte(key.Secret))
 }
 
 type userIDCtxKey struct{}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuthService_KeyRotation(t *testing.T) {
+	oldRing, err := ParseKeyRing("k1:old-secret", "", "")
+	if err != nil {
+		t.Fatalf("failed to build key ring: %v", err)
+	}
+	oldAuth := NewAuthServiceWithKeyRing(oldRing)
+
+	userID, oldToken, err := oldAuth.GenerateNewToken()
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	legacyToken, err := NewAuthService("legacy-secret").GenerateToken(userID)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	newRing, err := ParseKeyRing("k2:new-secret,k1:old-secret", "k2", "legacy-secret")
+	if err != nil {
+		t.Fatalf("failed to build key ring: %v", err)
+	}
+	auth := NewAuthServiceWithKeyRing(newRing)
+
+	newToken, err := auth.GenerateToken(userID)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		token       string
+		wantReissue bool
+	}{
+		{"active_key", newToken, false},
+		{"old_key", oldToken, true},
+		{"legacy_secret_without_kid", legacyToken, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, reissue, err := auth.ParseAndVerify(tt.token)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != userID {
+				t.Errorf("user id = %q, want %q", got, userID)
+			}
+			if reissue != tt.wantReissue {
+				t.Errorf("reissue = %v, want %v", reissue, tt.wantReissue)
+			}
+		})
+	}
+
+	t.Run("retired_key", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "keys.json")
+		data := `{"active":"k2","keys":[{"id":"k1","secret":"old-secret","retired":true},{"id":"k2","secret":"new-secret"}]}`
+		if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatalf("failed to write key file: %v", err)
+		}
+
+		ring, err := LoadKeyRingFile(path)
+		if err != nil {
+			t.Fatalf("failed to load key file: %v", err)
+		}
+		auth := NewAuthServiceWithKeyRing(ring)
+
+		if _, _, err := auth.ParseAndVerify(oldToken); err == nil {
+			t.Errorf("expected error for token signed with retired key")
+		}
+		if _, _, err := auth.ParseAndVerify(newToken); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("retired_active_key", func(t *testing.T) {
+		_, err := NewKeyRing("k1", []SigningKey{{ID: "k1", Secret: "s", Retired: true}})
+		if err == nil {
+			t.Errorf("expected error for retired active key")
+		}
+	})
+}
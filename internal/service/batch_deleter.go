@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"time"
 
@@ -10,16 +11,25 @@ import (
 	"go.uber.org/zap"
 )
 
+var ErrDeleterClosed = errors.New("batch deleter is closed")
+
 type DeleteTask struct {
 	UserID   string
 	ShortIDs []string
+	// RequestID запроса, поставившего задачу, — чтобы сбой сброса можно было найти в access-логе
+	RequestID string
 }
 
 type BatchDeleter struct {
-	store repository.Store
-	log   *logger.Logger
-
-	doneCh       chan struct{}
+	store   repository.Store
+	log     *logger.Logger
+	metrics Metrics
+
+	// mu защищает inputCh от закрытия, пока в него пишет Enqueue
+	mu           sync.RWMutex
+	closed       bool
+	closeOnce    sync.Once
+	stoppedCh    chan struct{}
 	inputCh      chan DeleteTask
 	aggregatorCh <-chan DeleteTask
 
@@ -28,16 +38,45 @@ type BatchDeleter struct {
 	timeout       time.Duration
 }
 
-func NewBatchDeleter(store repository.Store, log *logger.Logger) *BatchDeleter {
+// BatchDeleterOption настраивает BatchDeleter. Настройки применяются до запуска горутин,
+// поэтому менять их потом нельзя.
+type BatchDeleterOption func(*BatchDeleter)
+
+// WithBatchSize — сколько заданий копить до сброса
+func WithBatchSize(size int) BatchDeleterOption {
+	return func(h *BatchDeleter) {
+		h.batchSize = size
+	}
+}
+
+// WithFlushInterval — как часто сбрасывать неполный батч
+func WithFlushInterval(d time.Duration) BatchDeleterOption {
+	return func(h *BatchDeleter) {
+		h.flushInterval = d
+	}
+}
+
+// WithDeleterMetrics — куда отчитываться о сбросах
+func WithDeleterMetrics(m Metrics) BatchDeleterOption {
+	return func(h *BatchDeleter) {
+		h.metrics = m
+	}
+}
+
+func NewBatchDeleter(store repository.Store, log *logger.Logger, opts ...BatchDeleterOption) *BatchDeleter {
 	h := &BatchDeleter{
 		store:         store,
 		log:           log,
-		doneCh:        make(chan struct{}),
+		metrics:       noopMetrics{},
+		stoppedCh:     make(chan struct{}),
 		inputCh:       make(chan DeleteTask, 1000),
 		batchSize:     100,
 		flushInterval: 2 * time.Second,
 		timeout:       3 * time.Second,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
 
 	workers := h.fanOut(h.inputCh)
 	h.aggregatorCh = h.fanIn(workers...)
@@ -60,15 +99,11 @@ func (h *BatchDeleter) fanOut(input <-chan DeleteTask) []chan DeleteTask {
 			}
 		}()
 
-		// Раскидываем таски воркерам по кругу
+		// Раскидываем таски воркерам по кругу, пока не закроют вход
 		i := 0
 		for task := range input {
-			select {
-			case <-h.doneCh:
-				return
-			case workers[i] <- task:
-				i = (i + 1) % workerCount
-			}
+			workers[i] <- task
+			i = (i + 1) % workerCount
 		}
 	}()
 
@@ -89,11 +124,7 @@ func (h *BatchDeleter) fanIn(inputs ...chan DeleteTask) <-chan DeleteTask {
 			defer wg.Done()
 
 			for data := range chClosure {
-				select {
-				case <-h.doneCh:
-					return
-				case finalCh <- data:
-				}
+				finalCh <- data
 			}
 		}()
 	}
@@ -112,9 +143,18 @@ func (h *BatchDeleter) runAggregator(input <-chan DeleteTask) {
 	ticker := time.NewTicker(h.flushInterval)
 	defer ticker.Stop()
 
+	defer close(h.stoppedCh)
+
 	for {
 		select {
-		case task := <-input:
+		case task, ok := <-input:
+			// Вход закрывается только после того, как через конвейер прошли все задания
+			if !ok {
+				if len(batch) > 0 {
+					h.flush(batch)
+				}
+				return
+			}
 			batch = append(batch, task)
 			if len(batch) >= h.batchSize {
 				h.flush(batch)
@@ -126,12 +166,6 @@ func (h *BatchDeleter) runAggregator(input <-chan DeleteTask) {
 				h.flush(batch)
 				batch = batch[:0]
 			}
-
-		case <-h.doneCh:
-			if len(batch) > 0 {
-				h.flush(batch)
-			}
-			return
 		}
 	}
 }
@@ -141,34 +175,66 @@ func (h *BatchDeleter) flush(batch []DeleteTask) {
 	defer cancel()
 
 	userURLs := make(map[string][]string)
+	requestIDs := make(map[string][]string)
 	for _, t := range batch {
 		userURLs[t.UserID] = append(userURLs[t.UserID], t.ShortIDs...)
+		if t.RequestID != "" {
+			requestIDs[t.UserID] = append(requestIDs[t.UserID], t.RequestID)
+		}
 	}
 
+	size, failures := 0, 0
 	for userID, ids := range userURLs {
+		size += len(ids)
 		if err := h.store.MarkURLsDeleted(ctx, userID, ids); err != nil {
+			failures++
 			h.log.Error("failed to mark urls deleted",
-				zap.String("userID", userID),
+				zap.String("user_id", userID),
+				zap.Strings("request_ids", requestIDs[userID]),
 				zap.Strings("ids", ids),
 				zap.Error(err),
 			)
 		}
 	}
+	h.metrics.ObserveDeleteFlush(size, failures)
 }
 
-func (h *BatchDeleter) Enqueue(userID string, shortIDs []string) {
-	h.inputCh <- DeleteTask{UserID: userID, ShortIDs: shortIDs}
+// Enqueue ставит удаление в очередь. Из ctx берётся только ID запроса для логов:
+// сброс идёт уже после ответа клиенту. После Shutdown возвращает ErrDeleterClosed.
+func (h *BatchDeleter) Enqueue(ctx context.Context, userID string, shortIDs []string) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if h.closed {
+		return ErrDeleterClosed
+	}
+	h.inputCh <- DeleteTask{UserID: userID, ShortIDs: shortIDs, RequestID: logger.RequestID(ctx)}
+	return nil
 }
 
-func (h *BatchDeleter) Close() {
-	close(h.doneCh)
-	close(h.inputCh)
+// Shutdown перестаёт принимать задания и ждёт, пока уже принятые дойдут до хранилища.
+// Если ctx истёк раньше, возвращает его ошибку, а сброс доработает в фоне.
+func (h *BatchDeleter) Shutdown(ctx context.Context) error {
+	h.closeOnce.Do(func() {
+		h.mu.Lock()
+		h.closed = true
+		close(h.inputCh)
+		h.mu.Unlock()
+	})
+
+	select {
+	case <-h.stoppedCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-func (h *BatchDeleter) SetBatchSize(size int) {
-	h.batchSize = size
+func (h *BatchDeleter) Close() {
+	h.Shutdown(context.Background())
 }
 
-func (h *BatchDeleter) SetFlushInterval(d time.Duration) {
-	h.flushInterval = d
+// QueueLen — сколько заданий ждёт во входной очереди
+func (h *BatchDeleter) QueueLen() int {
+	return len(h.inputCh)
 }
@@ -1,73 +1,199 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/kayumovtd/url-shortener/internal/logger"
-	"github.com/kayumovtd/url-shortener/internal/model"
 	"github.com/kayumovtd/url-shortener/internal/repository"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 )
 
+// countDeleted ждёт, пока у user1 наберётся want удалённых ссылок, и возвращает, сколько вышло
+func countDeleted(t *testing.T, store repository.Store, want int, wait time.Duration) int {
+	t.Helper()
+
+	deadline := time.Now().Add(wait)
+	for {
+		urls, _ := store.GetUserURLs(t.Context(), "user1")
+		var deleted int
+		for _, rec := range urls {
+			if rec.IsDeleted {
+				deleted++
+			}
+		}
+		if deleted >= want || time.Now().After(deadline) {
+			return deleted
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func newDeleterTestStore(t *testing.T, ids ...string) repository.Store {
+	t.Helper()
+
+	store := repository.NewInMemoryStore()
+	for _, id := range ids {
+		store.SaveURL(t.Context(), id, "https://example.com/"+id, "user1")
+	}
+	return store
+}
+
 func TestBatchDeleter_FlushOnBatchSize(t *testing.T) {
-	mockStore := repository.NewMockStore()
-	mockStore.Data = []model.URLRecord{
-		{ShortURL: "a", UserID: "user1", IsDeleted: false},
-		{ShortURL: "b", UserID: "user1", IsDeleted: false},
-		{ShortURL: "c", UserID: "user1", IsDeleted: false},
+	store := newDeleterTestStore(t, "a", "b", "c")
+
+	// Таймер не успеет сработать, сбросить может только полный батч
+	deleter := NewBatchDeleter(store, logger.NewNoOp(), WithBatchSize(3), WithFlushInterval(10*time.Second))
+	defer deleter.Close()
+
+	// Отправляем 3 задания
+	deleter.Enqueue(t.Context(), "user1", []string{"a"})
+	deleter.Enqueue(t.Context(), "user1", []string{"b"})
+	deleter.Enqueue(t.Context(), "user1", []string{"c"})
+
+	if deleted := countDeleted(t, store, 3, time.Second); deleted != 3 {
+		t.Fatalf("expected 3 deleted ids, got %d", deleted)
 	}
+}
 
-	deleter := NewBatchDeleter(mockStore, logger.NewNoOp())
+func TestBatchDeleter_FlushOnTimer(t *testing.T) {
+	store := newDeleterTestStore(t, "a", "b", "c")
+
+	// Батч не наберётся, сбросить может только таймер
+	deleter := NewBatchDeleter(store, logger.NewNoOp(), WithBatchSize(100), WithFlushInterval(100*time.Millisecond))
 	defer deleter.Close()
 
-	deleter.SetBatchSize(3)
-	deleter.SetFlushInterval(10 * time.Second) // Чтобы не сработал таймер
+	// Отправляем 1 задание
+	deleter.Enqueue(t.Context(), "user1", []string{"a"})
 
-	// Отправляем 3 задания
-	deleter.Enqueue("user1", []string{"a"})
-	deleter.Enqueue("user1", []string{"b"})
-	deleter.Enqueue("user1", []string{"c"})
+	if deleted := countDeleted(t, store, 1, time.Second); deleted != 1 {
+		t.Fatalf("expected 1 deleted ids, got %d", deleted)
+	}
+}
+
+func TestBatchDeleter_ShutdownFlushesPending(t *testing.T) {
+	store := repository.NewInMemoryStore()
+	ctx := t.Context()
+
+	var ids []string
+	for i := range 50 {
+		id := fmt.Sprintf("id%d", i)
+		store.SaveURL(ctx, id, "https://example.com/"+id, "user1")
+		ids = append(ids, id)
+	}
 
-	time.Sleep(100 * time.Millisecond) // Даём время на обработку
+	// 50 заданий меньше размера батча, и Shutdown наступит раньше таймера — сбросить может только он
+	deleter := NewBatchDeleter(store, logger.NewNoOp())
 
-	var deleted int
-	for _, rec := range mockStore.Data {
+	// Удаления идут параллельно с остановкой: ни паники, ни потерянных принятых заданий
+	var wg sync.WaitGroup
+	var accepted atomic.Int32
+	for _, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := deleter.Enqueue(t.Context(), "user1", []string{id}); err == nil {
+				accepted.Add(1)
+			} else if !errors.Is(err, ErrDeleterClosed) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+
+	if err := deleter.Shutdown(ctx); err != nil {
+		t.Fatalf("unexpected shutdown error: %v", err)
+	}
+	wg.Wait()
+
+	urls, _ := store.GetUserURLs(ctx, "user1")
+	var deleted int32
+	for _, rec := range urls {
 		if rec.IsDeleted {
 			deleted++
 		}
 	}
+	if deleted != accepted.Load() {
+		t.Errorf("deleted %d urls, but %d deletions were accepted", deleted, accepted.Load())
+	}
 
-	if deleted != 3 {
-		t.Fatalf("expected 3 deleted ids, got %d", deleted)
+	if err := deleter.Enqueue(t.Context(), "user1", []string{"id0"}); !errors.Is(err, ErrDeleterClosed) {
+		t.Errorf("Enqueue after shutdown error = %v, want ErrDeleterClosed", err)
 	}
 }
 
-func TestBatchDeleter_FlushOnTimer(t *testing.T) {
-	mockStore := repository.NewMockStore()
-	mockStore.Data = []model.URLRecord{
-		{ShortURL: "a", UserID: "user1", IsDeleted: false},
-		{ShortURL: "b", UserID: "user1", IsDeleted: false},
-		{ShortURL: "c", UserID: "user1", IsDeleted: false},
+// blockingStore держит MarkURLsDeleted, пока не закроют release
+type blockingStore struct {
+	repository.Store
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingStore) MarkURLsDeleted(ctx context.Context, userID string, ids []string) error {
+	close(s.started)
+	<-s.release
+	return s.Store.MarkURLsDeleted(ctx, userID, ids)
+}
+
+func TestBatchDeleter_ShutdownTimeout(t *testing.T) {
+	store := &blockingStore{
+		Store:   newDeleterTestStore(t, "a"),
+		started: make(chan struct{}),
+		release: make(chan struct{}),
 	}
+	deleter := NewBatchDeleter(store, logger.NewNoOp(), WithBatchSize(1))
 
-	deleter := NewBatchDeleter(mockStore, logger.NewNoOp())
-	defer deleter.Close()
+	if err := deleter.Enqueue(t.Context(), "user1", []string{"a"}); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+	<-store.started
 
-	deleter.SetBatchSize(100) // Чтобы не сработал батч
-	deleter.SetFlushInterval(100 * time.Millisecond)
+	// Сброс висит в хранилище: Shutdown отдаёт ошибку по дедлайну, но принятое не теряет
+	ctx, cancel := context.WithTimeout(t.Context(), 50*time.Millisecond)
+	defer cancel()
+	if err := deleter.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown error = %v, want deadline exceeded", err)
+	}
+	if err := deleter.Enqueue(t.Context(), "user1", []string{"a"}); !errors.Is(err, ErrDeleterClosed) {
+		t.Errorf("Enqueue after shutdown error = %v, want ErrDeleterClosed", err)
+	}
 
-	// Отправляем 1 задание
-	deleter.Enqueue("user1", []string{"a"})
-	time.Sleep(200 * time.Millisecond) // Ждём flush по таймеру
+	close(store.release)
+	if err := deleter.Shutdown(t.Context()); err != nil {
+		t.Fatalf("repeated shutdown failed: %v", err)
+	}
+	if rec, _ := store.GetURL(t.Context(), "a"); !rec.IsDeleted {
+		t.Error("deletion accepted before shutdown was lost")
+	}
+}
 
-	var deleted int
-	for _, rec := range mockStore.Data {
-		if rec.IsDeleted {
-			deleted++
-		}
+func TestBatchDeleter_FailureLogHasRequestID(t *testing.T) {
+	store := repository.NewMockStore()
+	store.ErrorType = repository.SomeError
+
+	core, logs := observer.New(zap.ErrorLevel)
+	deleter := NewBatchDeleter(store, &logger.Logger{Logger: zap.New(core)})
+
+	ctx := logger.WithRequestID(t.Context(), "req-1")
+	if err := deleter.Enqueue(ctx, "user1", []string{"a"}); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
 	}
+	deleter.Close()
 
-	if deleted != 1 {
-		t.Fatalf("expected 1 deleted ids, got %d", deleted)
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d error log entries, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if ids, _ := fields["request_ids"].([]any); len(ids) != 1 || ids[0] != "req-1" {
+		t.Errorf("request_ids = %v, want [req-1]", fields["request_ids"])
+	}
+	if fields["user_id"] != "user1" {
+		t.Errorf("user_id = %v, want user1", fields["user_id"])
 	}
 }
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"github.com/kayumovtd/url-shortener/internal/repository"
+)
+
+const (
+	defaultHealthTimeout = 2 * time.Second
+	// Очередь удалений заполнена на столько процентов — новые запросы на удаление начнут ждать
+	deleteQueueSaturation = 90
+)
+
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// HealthService собирает готовность из именованных проверок компонентов
+type HealthService struct {
+	checks   []HealthCheck
+	timeout  time.Duration
+	draining atomic.Bool
+}
+
+func NewHealthService(checks ...HealthCheck) *HealthService {
+	return &HealthService{checks: checks, timeout: defaultHealthTimeout}
+}
+
+// SetDraining переводит сервис в неготовность до конца работы процесса: балансировщик
+// перестаёт слать новые запросы, пока дорабатывают текущие.
+func (h *HealthService) SetDraining() {
+	h.draining.Store(true)
+}
+
+// Ready запускает все проверки параллельно, каждую со своим таймаутом.
+// Сервис готов, только если прошли все.
+func (h *HealthService) Ready(ctx context.Context) model.HealthReport {
+	if h.draining.Load() {
+		return model.HealthReport{Status: model.HealthDraining}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	report := model.HealthReport{
+		Status: model.HealthOK,
+		Checks: make([]model.ComponentHealth, len(h.checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, c := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			start := time.Now()
+			err := c.Check(ctx)
+
+			res := model.ComponentHealth{
+				Name:      c.Name,
+				Status:    model.HealthOK,
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				res.Status = model.HealthFail
+				res.Error = err.Error()
+			}
+			report.Checks[i] = res
+		}()
+	}
+	wg.Wait()
+
+	for _, c := range report.Checks {
+		if c.Status != model.HealthOK {
+			report.Status = model.HealthFail
+		}
+	}
+	return report
+}
+
+func StoreHealthCheck(store repository.Store) HealthCheck {
+	return HealthCheck{Name: "store", Check: store.Ping}
+}
+
+func BatchDeleterHealthCheck(bd *BatchDeleter) HealthCheck {
+	return HealthCheck{Name: "batch_deleter", Check: func(context.Context) error {
+		bd.mu.RLock()
+		closed := bd.closed
+		bd.mu.RUnlock()
+		if closed {
+			return ErrDeleterClosed
+		}
+
+		queued, capacity := len(bd.inputCh), cap(bd.inputCh)
+		if queued*100 >= capacity*deleteQueueSaturation {
+			return fmt.Errorf("delete queue is saturated: %d of %d", queued, capacity)
+		}
+		return nil
+	}}
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kayumovtd/url-shortener/internal/logger"
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"github.com/kayumovtd/url-shortener/internal/repository"
+)
+
+func TestHealthService(t *testing.T) {
+	ok := HealthCheck{Name: "ok", Check: func(context.Context) error { return nil }}
+	broken := HealthCheck{Name: "broken", Check: func(context.Context) error { return errors.New("boom") }}
+
+	t.Run("all_ok", func(t *testing.T) {
+		h := NewHealthService(ok, StoreHealthCheck(repository.NewInMemoryStore()))
+		report := h.Ready(t.Context())
+		if report.Status != model.HealthOK || len(report.Checks) != 2 {
+			t.Fatalf("report = %+v, want ok with 2 checks", report)
+		}
+		if report.Checks[1].Name != "store" || report.Checks[1].Status != model.HealthOK {
+			t.Errorf("store check = %+v", report.Checks[1])
+		}
+	})
+
+	t.Run("one_failed", func(t *testing.T) {
+		report := NewHealthService(ok, broken).Ready(t.Context())
+		if report.Status != model.HealthFail {
+			t.Fatalf("status = %s, want %s", report.Status, model.HealthFail)
+		}
+		if report.Checks[1].Status != model.HealthFail || report.Checks[1].Error != "boom" {
+			t.Errorf("broken check = %+v", report.Checks[1])
+		}
+	})
+
+	t.Run("draining", func(t *testing.T) {
+		h := NewHealthService(ok)
+		h.SetDraining()
+		if report := h.Ready(t.Context()); report.Status != model.HealthDraining {
+			t.Errorf("status = %s, want %s", report.Status, model.HealthDraining)
+		}
+	})
+
+	t.Run("batch_deleter", func(t *testing.T) {
+		bd := NewBatchDeleter(repository.NewInMemoryStore(), logger.NewNoOp())
+		check := BatchDeleterHealthCheck(bd)
+		if err := check.Check(t.Context()); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		bd.Close()
+		if err := check.Check(t.Context()); !errors.Is(err, ErrDeleterClosed) {
+			t.Errorf("err = %v, want %v", err, ErrDeleterClosed)
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SigningKey — ключ подписи JWT. Токены без заголовка kid проверяются ключом с пустым ID,
+// так продолжают работать куки, выданные ещё с одним AUTH_SECRET.
+type SigningKey struct {
+	ID      string `json:"id"`
+	Secret  string `json:"secret"`
+	Retired bool   `json:"retired"`
+}
+
+type KeyRing struct {
+	keys   map[string]SigningKey
+	active string
+}
+
+func NewKeyRing(active string, keys []SigningKey) (*KeyRing, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("key ring is empty")
+	}
+
+	kr := &KeyRing{keys: make(map[string]SigningKey, len(keys)), active: active}
+	for _, k := range keys {
+		if _, ok := kr.keys[k.ID]; ok {
+			return nil, fmt.Errorf("duplicate signing key id %q", k.ID)
+		}
+		kr.keys[k.ID] = k
+	}
+
+	activeKey, ok := kr.keys[active]
+	if !ok {
+		return nil, fmt.Errorf("active signing key %q not found", active)
+	}
+	if activeKey.Retired {
+		return nil, fmt.Errorf("active signing key %q is retired", active)
+	}
+
+	return kr, nil
+}
+
+// ParseKeyRing собирает связку ключей из настроек:
+// spec — список "kid:secret" через запятую, legacySecret — старый AUTH_SECRET без kid.
+// Если активный ключ не указан, активным считается первый из spec.
+func ParseKeyRing(spec, active, legacySecret string) (*KeyRing, error) {
+	var keys []SigningKey
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, secret, ok := strings.Cut(part, ":")
+		if !ok || id == "" || secret == "" {
+			return nil, fmt.Errorf("invalid signing key %q: expected <kid>:<secret>", part)
+		}
+		keys = append(keys, SigningKey{ID: id, Secret: secret})
+	}
+
+	if active == "" && len(keys) > 0 {
+		active = keys[0].ID
+	}
+
+	// legacySecret подписывал токены без kid, держим его, пока его не уберут из конфига
+	if legacySecret != "" || len(keys) == 0 {
+		keys = append(keys, SigningKey{ID: "", Secret: legacySecret})
+	}
+
+	return NewKeyRing(active, keys)
+}
+
+// LoadKeyRingFile читает связку из JSON-файла вида
+// {"active": "k2", "keys": [{"id": "k1", "secret": "...", "retired": true}, {"id": "k2", "secret": "..."}]}.
+func LoadKeyRingFile(path string) (*KeyRing, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read key file: %w", err)
+	}
+
+	var file struct {
+		Active string       `json:"active"`
+		Keys   []SigningKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse key file: %w", err)
+	}
+
+	return NewKeyRing(file.Active, file.Keys)
+}
+
+func (kr *KeyRing) Active() SigningKey {
+	return kr.keys[kr.active]
+}
+
+// Lookup возвращает ключ для проверки подписи. Выведенные из оборота ключи не отдаём.
+func (kr *KeyRing) Lookup(id string) (SigningKey, bool) {
+	k, ok := kr.keys[id]
+	if !ok || k.Retired {
+		return SigningKey{}, false
+	}
+	return k, true
+}
@@ -0,0 +1,23 @@
+package service
+
+// События ShortenerService для метрик
+const (
+	EventShorten  = "shorten"
+	EventRedirect = "redirect"
+	EventConflict = "conflict"
+	EventDelete   = "delete"
+)
+
+// Metrics — то, что сервисы сообщают наружу. Реализация живёт в internal/metrics,
+// по умолчанию ничего не считаем.
+type Metrics interface {
+	// CountURLEvent — n событий ShortenerService
+	CountURLEvent(event string, n int)
+	// ObserveDeleteFlush — сброс пачки удалений: сколько id ушло в хранилище и сколько запросов к нему упало
+	ObserveDeleteFlush(size, failures int)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) CountURLEvent(string, int)   {}
+func (noopMetrics) ObserveDeleteFlush(int, int) {}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"github.com/kayumovtd/url-shortener/internal/repository"
+)
+
+const maxReportReasonLen = 500
+
+var ErrInvalidModeration = errors.New("invalid moderation request")
+
+// ModerationService принимает жалобы от кого угодно и собирает их в очередь для модераторов.
+// Кто модератор, решает роутер — те же правила, что и для админского API.
+type ModerationService struct {
+	store     repository.Store
+	shortener *ShortenerService
+}
+
+func NewModerationService(store repository.Store, shortener *ShortenerService) *ModerationService {
+	return &ModerationService{store: store, shortener: shortener}
+}
+
+func (s *ModerationService) Report(ctx context.Context, shortID, reason, reporterID string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || utf8.RuneCountInString(reason) > maxReportReasonLen {
+		return fmt.Errorf("%w: reason must be 1 to %d characters", ErrInvalidModeration, maxReportReasonLen)
+	}
+
+	if _, err := s.store.GetURL(ctx, shortID); err != nil {
+		if errors.Is(err, repository.ErrStoreNotFound) {
+			return ErrURLNotFound
+		}
+		return fmt.Errorf("failed to get url: %w", err)
+	}
+
+	report := model.AbuseReport{
+		ID:         uuid.NewString(),
+		ShortURL:   shortID,
+		Reason:     reason,
+		ReporterID: reporterID,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if err := s.store.SaveAbuseReport(ctx, report); err != nil {
+		return fmt.Errorf("failed to save abuse report: %w", err)
+	}
+	return nil
+}
+
+// Queue отдаёт ссылки с открытыми жалобами, начиная с той, на которую пожаловались раньше всех
+func (s *ModerationService) Queue(ctx context.Context) ([]model.ModerationQueueItem, error) {
+	reports, err := s.store.GetOpenAbuseReports(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get abuse reports: %w", err)
+	}
+
+	queue := []model.ModerationQueueItem{}
+	index := make(map[string]int)
+	for _, r := range reports {
+		i, ok := index[r.ShortURL]
+		if !ok {
+			rec, err := s.store.GetURL(ctx, r.ShortURL)
+			if errors.Is(err, repository.ErrStoreNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to get url: %w", err)
+			}
+
+			i = len(queue)
+			index[r.ShortURL] = i
+			queue = append(queue, model.ModerationQueueItem{
+				ShortURL:    s.shortener.makeResultURL(rec.ShortURL),
+				OriginalURL: rec.OriginalURL,
+				State:       moderationState(rec),
+			})
+		}
+		queue[i].Reports = append(queue[i].Reports, model.ModerationReportItem{Reason: r.Reason, CreatedAt: r.CreatedAt})
+	}
+
+	return queue, nil
+}
+
+// Moderate выносит решение по ссылке. Для blocked причина обязательна — её увидит пользователь.
+func (s *ModerationService) Moderate(ctx context.Context, shortID string, state model.ModerationState, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if !state.Valid() {
+		return fmt.Errorf("%w: unknown state %q", ErrInvalidModeration, state)
+	}
+	if state == model.ModerationBlocked && reason == "" {
+		return fmt.Errorf("%w: reason is required to block a url", ErrInvalidModeration)
+	}
+	if utf8.RuneCountInString(reason) > maxReportReasonLen {
+		return fmt.Errorf("%w: reason must be at most %d characters", ErrInvalidModeration, maxReportReasonLen)
+	}
+
+	err := s.store.SetURLModeration(ctx, shortID, state, reason)
+	if errors.Is(err, repository.ErrStoreNotFound) {
+		return ErrURLNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to moderate url: %w", err)
+	}
+	return nil
+}
+
+// moderationState — у старых записей состояния нет, это значит active
+func moderationState(rec model.URLRecord) model.ModerationState {
+	if rec.Moderation == "" {
+		return model.ModerationActive
+	}
+	return rec.Moderation
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kayumovtd/url-shortener/internal/logger"
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"github.com/kayumovtd/url-shortener/internal/repository"
+)
+
+func TestModerationService(t *testing.T) {
+	store := repository.NewInMemoryStore()
+	bd := NewBatchDeleter(store, logger.NewNoOp())
+	defer bd.Close()
+	svc := NewModerationService(store, NewShortenerService(store, testBaseURL, bd))
+
+	ctx := t.Context()
+	store.SaveURL(ctx, "bad", "https://phishing.example.com", "author")
+	store.SaveURL(ctx, "good", "https://example.com", "author")
+
+	t.Run("report_validation", func(t *testing.T) {
+		if err := svc.Report(ctx, "bad", "  ", "reporter"); !errors.Is(err, ErrInvalidModeration) {
+			t.Errorf("empty reason error = %v, want ErrInvalidModeration", err)
+		}
+		if err := svc.Report(ctx, "missing", "spam", "reporter"); !errors.Is(err, ErrURLNotFound) {
+			t.Errorf("unknown url error = %v, want ErrURLNotFound", err)
+		}
+	})
+
+	for _, reason := range []string{"phishing", "steals passwords"} {
+		if err := svc.Report(ctx, "bad", reason, ""); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	t.Run("queue_groups_reports", func(t *testing.T) {
+		queue, err := svc.Queue(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(queue) != 1 {
+			t.Fatalf("queue length = %d, want 1", len(queue))
+		}
+		item := queue[0]
+		if item.ShortURL != testBaseURL+"/bad" || item.State != model.ModerationActive || len(item.Reports) != 2 {
+			t.Errorf("unexpected queue item: %+v", item)
+		}
+	})
+
+	t.Run("moderate_validation", func(t *testing.T) {
+		if err := svc.Moderate(ctx, "bad", "deleted", ""); !errors.Is(err, ErrInvalidModeration) {
+			t.Errorf("unknown state error = %v, want ErrInvalidModeration", err)
+		}
+		if err := svc.Moderate(ctx, "bad", model.ModerationBlocked, ""); !errors.Is(err, ErrInvalidModeration) {
+			t.Errorf("block without reason error = %v, want ErrInvalidModeration", err)
+		}
+	})
+
+	t.Run("decision_resolves_reports", func(t *testing.T) {
+		if err := svc.Moderate(ctx, "bad", model.ModerationBlocked, "phishing"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		rec, _ := store.GetURL(ctx, "bad")
+		if rec.Moderation != model.ModerationBlocked || rec.ModerationReason != "phishing" {
+			t.Errorf("unexpected moderation state: %q %q", rec.Moderation, rec.ModerationReason)
+		}
+
+		queue, err := svc.Queue(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(queue) != 0 {
+			t.Errorf("queue should be empty after decision, got %+v", queue)
+		}
+	})
+}
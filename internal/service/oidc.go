@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"github.com/kayumovtd/url-shortener/internal/repository"
+)
+
+var ErrOIDCLogin = errors.New("oidc login failed")
+
+// oidcLoginPrefix — логины SSO-пользователей; при регистрации с паролем такой префикс запрещён
+const oidcLoginPrefix = "oidc:"
+
+type OIDCConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+}
+
+// OIDCFlow — состояние одного входа, живёт у клиента между редиректом к провайдеру и колбэком.
+type OIDCFlow struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+}
+
+// OIDCService реализует authorization code flow с PKCE.
+// Субъект провайдера детерминированно превращается в ID пользователя,
+// дальше работает обычная кука auth_token из AuthService.
+// При первом входе пользователь сохраняется в хранилище без пароля,
+// чтобы AccountService не принял его за анонимную сессию.
+type OIDCService struct {
+	cfg   OIDCConfig
+	auth  *AuthService
+	store repository.Store
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+func NewOIDCService(cfg OIDCConfig, auth *AuthService, store repository.Store) *OIDCService {
+	return &OIDCService{cfg: cfg, auth: auth, store: store}
+}
+
+// AuthURL готовит новый вход: адрес провайдера для редиректа и состояние, которое надо сохранить до колбэка.
+func (s *OIDCService) AuthURL(ctx context.Context) (string, OIDCFlow, error) {
+	provider, err := s.getProvider(ctx)
+	if err != nil {
+		return "", OIDCFlow{}, err
+	}
+
+	state, err := randomString()
+	if err != nil {
+		return "", OIDCFlow{}, err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return "", OIDCFlow{}, err
+	}
+
+	flow := OIDCFlow{State: state, Nonce: nonce, Verifier: oauth2.GenerateVerifier()}
+	url := s.oauth2Config(provider).AuthCodeURL(
+		flow.State,
+		oidc.Nonce(flow.Nonce),
+		oauth2.S256ChallengeOption(flow.Verifier),
+	)
+
+	return url, flow, nil
+}
+
+// Exchange меняет код на токены, проверяет ID-токен по JWKS провайдера
+// и выдаёт куку для пользователя, соответствующего субъекту.
+func (s *OIDCService) Exchange(ctx context.Context, code string, flow OIDCFlow) (userID string, token string, err error) {
+	provider, err := s.getProvider(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	oauth2Token, err := s.oauth2Config(provider).Exchange(ctx, code, oauth2.VerifierOption(flow.Verifier))
+	if err != nil {
+		return "", "", fmt.Errorf("%w: exchange code: %v", ErrOIDCLogin, err)
+	}
+
+	rawIDToken, ok := oauth2Token.Extra("id_token").(string)
+	if !ok {
+		return "", "", fmt.Errorf("%w: no id_token in token response", ErrOIDCLogin)
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: s.cfg.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: verify id_token: %v", ErrOIDCLogin, err)
+	}
+	if idToken.Nonce != flow.Nonce {
+		return "", "", fmt.Errorf("%w: nonce mismatch", ErrOIDCLogin)
+	}
+
+	userID = s.userIDForSubject(idToken.Issuer, idToken.Subject)
+	if err := s.ensureUser(ctx, userID); err != nil {
+		return "", "", err
+	}
+
+	token, err = s.auth.GenerateToken(userID)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return userID, token, nil
+}
+
+// userIDForSubject — один и тот же субъект всегда получает один и тот же ID, отдельная таблица не нужна.
+func (s *OIDCService) userIDForSubject(issuer, subject string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(issuer+"#"+subject)).String()
+}
+
+// ensureUser сохраняет SSO-пользователя при первом входе
+func (s *OIDCService) ensureUser(ctx context.Context, userID string) error {
+	_, err := s.store.GetUser(ctx, userID)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, repository.ErrStoreNotFound) {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	user := model.User{
+		ID:        userID,
+		Login:     oidcLoginPrefix + userID,
+		CreatedAt: time.Now().UTC(),
+	}
+	// Параллельный первый вход того же субъекта уже мог сохранить пользователя
+	if err := s.store.SaveUser(ctx, user); err != nil && !errors.Is(err, repository.ErrStoreAlreadyExists) {
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+	return nil
+}
+
+// getProvider лениво делает discovery: недоступный провайдер не должен мешать старту сервиса.
+func (s *OIDCService) getProvider(ctx context.Context) (*oidc.Provider, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.provider != nil {
+		return s.provider, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, s.cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+
+	s.provider = provider
+	return provider, nil
+}
+
+func (s *OIDCService) oauth2Config(provider *oidc.Provider) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     s.cfg.ClientID,
+		ClientSecret: s.cfg.ClientSecret,
+		RedirectURL:  s.cfg.RedirectURL,
+		Endpoint:     provider.Endpoint(),
+		Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+	}
+}
+
+func randomString() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random string: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
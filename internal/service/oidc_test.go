@@ -0,0 +1,111 @@
+package service
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"github.com/kayumovtd/url-shortener/internal/repository"
+	"github.com/kayumovtd/url-shortener/internal/service/mocks"
+)
+
+// authorize проходит шаг авторизации у мок-провайдера и возвращает выданный код.
+func authorize(t *testing.T, authURL string) string {
+	t.Helper()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	res, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorize request failed: %v", err)
+	}
+	defer res.Body.Close()
+
+	loc, err := url.Parse(res.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("invalid redirect: %v", err)
+	}
+	return loc.Query().Get("code")
+}
+
+func TestOIDCService(t *testing.T) {
+	idp := mocks.NewMockOIDCProvider("shortener", "employee-42")
+	defer idp.Close()
+
+	store := repository.NewMockStore()
+	auth := NewAuthService("secret")
+	svc := NewOIDCService(OIDCConfig{
+		Issuer:      idp.Issuer(),
+		ClientID:    "shortener",
+		RedirectURL: "http://localhost:8080/api/auth/oidc/callback",
+	}, auth, store)
+
+	t.Run("login", func(t *testing.T) {
+		authURL, flow, err := svc.AuthURL(t.Context())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		userID, token, err := svc.Exchange(t.Context(), authorize(t, authURL), flow)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		got, _, err := auth.ParseAndVerify(token)
+		if err != nil || got != userID {
+			t.Errorf("token user id = %q, err = %v, want %q", got, err, userID)
+		}
+
+		// тот же субъект — тот же пользователь
+		authURL, flow, _ = svc.AuthURL(t.Context())
+		again, _, err := svc.Exchange(t.Context(), authorize(t, authURL), flow)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if again != userID {
+			t.Errorf("user id = %q, want %q", again, userID)
+		}
+	})
+
+	t.Run("password_login_keeps_sso_links", func(t *testing.T) {
+		authURL, flow, _ := svc.AuthURL(t.Context())
+		ssoUserID, _, err := svc.Exchange(t.Context(), authorize(t, authURL), flow)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		store.Data = append(store.Data, model.URLRecord{ShortURL: "sso1", OriginalURL: "https://sso.example.com", UserID: ssoUserID})
+
+		accounts := NewAccountService(store, auth)
+		if _, _, err := accounts.Register(t.Context(), "carol", "password123", ""); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, _, err := accounts.Login(t.Context(), "carol", "password123", ssoUserID); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		rec, err := store.GetURL(t.Context(), "sso1")
+		if err != nil || rec.UserID != ssoUserID {
+			t.Errorf("sso link owner = %q, err = %v, want %q", rec.UserID, err, ssoUserID)
+		}
+	})
+
+	t.Run("wrong_verifier", func(t *testing.T) {
+		authURL, flow, _ := svc.AuthURL(t.Context())
+		flow.Verifier = "wrong"
+
+		if _, _, err := svc.Exchange(t.Context(), authorize(t, authURL), flow); err == nil {
+			t.Errorf("expected error for wrong PKCE verifier")
+		}
+	})
+
+	t.Run("wrong_nonce", func(t *testing.T) {
+		authURL, flow, _ := svc.AuthURL(t.Context())
+		flow.Nonce = "wrong"
+
+		if _, _, err := svc.Exchange(t.Context(), authorize(t, authURL), flow); err == nil {
+			t.Errorf("expected error for wrong nonce")
+		}
+	})
+}
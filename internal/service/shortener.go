@@ -6,27 +6,46 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"sync/atomic"
 
 	"github.com/kayumovtd/url-shortener/internal/model"
 	"github.com/kayumovtd/url-shortener/internal/repository"
 	"github.com/kayumovtd/url-shortener/internal/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type ShortenerService struct {
 	store        repository.Store
-	baseURL      string
+	baseURL      atomic.Pointer[string]
 	batchDeleter *BatchDeleter
+	metrics      Metrics
 }
 
 func NewShortenerService(store repository.Store, baseURL string, batchDeleter *BatchDeleter) *ShortenerService {
-	return &ShortenerService{
+	s := &ShortenerService{
 		store:        store,
-		baseURL:      baseURL,
 		batchDeleter: batchDeleter,
+		metrics:      noopMetrics{},
 	}
+	s.SetBaseURL(baseURL)
+	return s
 }
 
-func (s *ShortenerService) Shorten(ctx context.Context, originalURL string, userID string) (string, error) {
+// SetMetrics вызывается до начала обработки запросов
+func (s *ShortenerService) SetMetrics(m Metrics) {
+	s.metrics = m
+}
+
+// SetBaseURL меняет префикс коротких ссылок в ответах. Уже выданные ссылки не трогает.
+func (s *ShortenerService) SetBaseURL(baseURL string) {
+	s.baseURL.Store(&baseURL)
+}
+
+func (s *ShortenerService) Shorten(ctx context.Context, originalURL string, userID string) (_ string, err error) {
+	ctx, span := tracer.Start(ctx, "ShortenerService.Shorten")
+	defer func() { endSpan(span, err) }()
+
 	url, err := s.normalizeURL(originalURL)
 	if err != nil {
 		return "", err
@@ -37,11 +56,13 @@ func (s *ShortenerService) Shorten(ctx context.Context, originalURL string, user
 	if err := s.store.SaveURL(ctx, shortID, url, userID); err != nil {
 		var conflict *repository.ErrStoreConflict
 		if errors.As(err, &conflict) {
+			s.metrics.CountURLEvent(EventConflict, 1)
 			return "", NewErrShortenerConflict(s.makeResultURL(conflict.ShortURL), err)
 		}
 		return "", fmt.Errorf("failed to save url %q with id %q: %w", url, shortID, err)
 	}
 
+	s.metrics.CountURLEvent(EventShorten, 1)
 	return s.makeResultURL(shortID), nil
 }
 
@@ -49,7 +70,10 @@ func (s *ShortenerService) ShortenBatch(
 	ctx context.Context,
 	items []model.ShortenBatchRequestItem,
 	userID string,
-) ([]model.ShortenBatchResponseItem, error) {
+) (_ []model.ShortenBatchResponseItem, err error) {
+	ctx, span := tracer.Start(ctx, "ShortenerService.ShortenBatch", trace.WithAttributes(attribute.Int("batch.size", len(items))))
+	defer func() { endSpan(span, err) }()
+
 	if len(items) == 0 {
 		return nil, fmt.Errorf("empty batch")
 	}
@@ -79,7 +103,7 @@ func (s *ShortenerService) ShortenBatch(
 
 		responses = append(responses, model.ShortenBatchResponseItem{
 			CorrelationID: it.CorrelationID,
-			ShortURL:      fmt.Sprintf("%s/%s", s.baseURL, shortID),
+			ShortURL:      fmt.Sprintf("%s/%s", *s.baseURL.Load(), shortID),
 		})
 	}
 
@@ -92,11 +116,15 @@ func (s *ShortenerService) ShortenBatch(
 	if err := s.store.SaveURLs(ctx, pairs, userID); err != nil {
 		return nil, fmt.Errorf("failed to save batch: %w", err)
 	}
+	s.metrics.CountURLEvent(EventShorten, len(pairs))
 
 	return responses, nil
 }
 
-func (s *ShortenerService) Unshorten(ctx context.Context, id string) (model.URLRecord, error) {
+func (s *ShortenerService) Unshorten(ctx context.Context, id string) (_ model.URLRecord, err error) {
+	ctx, span := tracer.Start(ctx, "ShortenerService.Unshorten")
+	defer func() { endSpan(span, err) }()
+
 	if id == "" {
 		return model.URLRecord{}, fmt.Errorf("empty id")
 	}
@@ -105,6 +133,7 @@ func (s *ShortenerService) Unshorten(ctx context.Context, id string) (model.URLR
 	if err != nil {
 		return model.URLRecord{}, fmt.Errorf("not found: %w", err)
 	}
+	s.metrics.CountURLEvent(EventRedirect, 1)
 
 	return rec, nil
 }
@@ -113,7 +142,12 @@ func (s *ShortenerService) Ping(ctx context.Context) error {
 	return s.store.Ping(ctx)
 }
 
-func (s *ShortenerService) GetUserURLs(ctx context.Context, userID string) ([]model.UserURLsResponseItem, error) {
+// GetUserURLs отдаёт личные ссылки пользователя и ссылки всех его рабочих пространств.
+// Ссылки, которые автор передал в пространство, видны ему только через членство в нём.
+func (s *ShortenerService) GetUserURLs(ctx context.Context, userID string) (_ []model.UserURLsResponseItem, err error) {
+	ctx, span := tracer.Start(ctx, "ShortenerService.GetUserURLs")
+	defer func() { endSpan(span, err) }()
+
 	urls, err := s.store.GetUserURLs(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user URLs: %w", err)
@@ -121,10 +155,25 @@ func (s *ShortenerService) GetUserURLs(ctx context.Context, userID string) ([]mo
 
 	response := make([]model.UserURLsResponseItem, 0, len(urls))
 	for _, rec := range urls {
-		response = append(response, model.UserURLsResponseItem{
-			ShortURL:    s.makeResultURL(rec.ShortURL),
-			OriginalURL: rec.OriginalURL,
-		})
+		if rec.WorkspaceID != "" {
+			continue
+		}
+		response = append(response, s.makeUserURLsItem(rec))
+	}
+
+	workspaces, err := s.store.GetUserWorkspaces(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user workspaces: %w", err)
+	}
+
+	for _, ws := range workspaces {
+		wsURLs, err := s.store.GetWorkspaceURLs(ctx, ws.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get workspace %q URLs: %w", ws.ID, err)
+		}
+		for _, rec := range wsURLs {
+			response = append(response, s.makeUserURLsItem(rec))
+		}
 	}
 
 	return response, nil
@@ -142,9 +191,23 @@ func (s *ShortenerService) normalizeURL(originalURL string) (string, error) {
 }
 
 func (s *ShortenerService) makeResultURL(shortID string) string {
-	return fmt.Sprintf("%s/%s", s.baseURL, shortID)
+	return fmt.Sprintf("%s/%s", *s.baseURL.Load(), shortID)
 }
 
-func (s *ShortenerService) EnqueueDeletion(userID string, shortIDs []string) {
-	s.batchDeleter.Enqueue(userID, shortIDs)
+func (s *ShortenerService) makeUserURLsItem(rec model.URLRecord) model.UserURLsResponseItem {
+	return model.UserURLsResponseItem{
+		ShortURL:    s.makeResultURL(rec.ShortURL),
+		OriginalURL: rec.OriginalURL,
+		WorkspaceID: rec.WorkspaceID,
+	}
+}
+
+// EnqueueDeletion ставит удаление в очередь. В метриках считаются запрошенные id:
+// чужие ссылки хранилище потом молча пропустит.
+func (s *ShortenerService) EnqueueDeletion(ctx context.Context, userID string, shortIDs []string) error {
+	if err := s.batchDeleter.Enqueue(ctx, userID, shortIDs); err != nil {
+		return err
+	}
+	s.metrics.CountURLEvent(EventDelete, len(shortIDs))
+	return nil
 }
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/kayumovtd/url-shortener/internal/repository"
+	"github.com/kayumovtd/url-shortener/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/kayumovtd/url-shortener/internal/service")
+
+// endSpan не считает сбоем конфликт и ненайденную ссылку — это обычные ответы клиенту
+func endSpan(span trace.Span, err error) {
+	var conflict *ErrShortenerConflict
+	if errors.As(err, &conflict) || errors.Is(err, repository.ErrStoreNotFound) {
+		err = nil
+	}
+	tracing.End(span, err)
+}
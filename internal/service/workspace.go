@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"github.com/kayumovtd/url-shortener/internal/repository"
+)
+
+const maxWorkspaceNameLen = 100
+
+var (
+	// ErrWorkspaceNotFound отдаём и не-участникам, чтобы не раскрывать существование пространства
+	ErrWorkspaceNotFound  = errors.New("workspace not found")
+	ErrWorkspaceForbidden = errors.New("not enough rights in workspace")
+	ErrLastOwner          = errors.New("workspace must keep at least one owner")
+	ErrInvalidWorkspace   = errors.New("invalid workspace request")
+)
+
+type WorkspaceService struct {
+	store     repository.Store
+	shortener *ShortenerService
+}
+
+func NewWorkspaceService(store repository.Store, shortener *ShortenerService) *WorkspaceService {
+	return &WorkspaceService{store: store, shortener: shortener}
+}
+
+func (s *WorkspaceService) Create(ctx context.Context, userID, name string) (model.WorkspaceResponseItem, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxWorkspaceNameLen {
+		return model.WorkspaceResponseItem{}, fmt.Errorf("%w: name must be 1 to %d characters", ErrInvalidWorkspace, maxWorkspaceNameLen)
+	}
+
+	ws := model.Workspace{
+		ID:        uuid.NewString(),
+		Name:      name,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.store.SaveWorkspace(ctx, ws, userID); err != nil {
+		return model.WorkspaceResponseItem{}, fmt.Errorf("failed to save workspace: %w", err)
+	}
+
+	return model.WorkspaceResponseItem{ID: ws.ID, Name: ws.Name, Role: model.RoleOwner, CreatedAt: ws.CreatedAt}, nil
+}
+
+func (s *WorkspaceService) List(ctx context.Context, userID string) ([]model.WorkspaceResponseItem, error) {
+	workspaces, err := s.store.GetUserWorkspaces(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspaces: %w", err)
+	}
+
+	response := make([]model.WorkspaceResponseItem, 0, len(workspaces))
+	for _, ws := range workspaces {
+		response = append(response, model.WorkspaceResponseItem{
+			ID:        ws.ID,
+			Name:      ws.Name,
+			Role:      ws.Role,
+			CreatedAt: ws.CreatedAt,
+		})
+	}
+
+	return response, nil
+}
+
+func (s *WorkspaceService) Members(ctx context.Context, userID, workspaceID string) ([]model.WorkspaceMember, error) {
+	if _, err := s.authorize(ctx, workspaceID, userID); err != nil {
+		return nil, err
+	}
+
+	members, err := s.store.GetWorkspaceMembers(ctx, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace members: %w", err)
+	}
+	return members, nil
+}
+
+// SetMember добавляет участника или меняет ему роль. Доступно только владельцам.
+func (s *WorkspaceService) SetMember(ctx context.Context, userID, workspaceID, memberID string, role model.WorkspaceRole) error {
+	if !role.Valid() || memberID == "" {
+		return fmt.Errorf("%w: unknown role %q", ErrInvalidWorkspace, role)
+	}
+
+	current, err := s.authorize(ctx, workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if current != model.RoleOwner {
+		return ErrWorkspaceForbidden
+	}
+
+	member := model.WorkspaceMember{WorkspaceID: workspaceID, UserID: memberID, Role: role}
+	err = s.store.SaveWorkspaceMember(ctx, member)
+	if errors.Is(err, repository.ErrStoreLastOwner) {
+		return ErrLastOwner
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save workspace member: %w", err)
+	}
+	return nil
+}
+
+// RemoveMember исключает участника. Владельцы исключают кого угодно, остальные могут только выйти сами.
+func (s *WorkspaceService) RemoveMember(ctx context.Context, userID, workspaceID, memberID string) error {
+	current, err := s.authorize(ctx, workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if current != model.RoleOwner && memberID != userID {
+		return ErrWorkspaceForbidden
+	}
+
+	err = s.store.DeleteWorkspaceMember(ctx, workspaceID, memberID)
+	if errors.Is(err, repository.ErrStoreLastOwner) {
+		return ErrLastOwner
+	}
+	if errors.Is(err, repository.ErrStoreNotFound) {
+		return ErrWorkspaceNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete workspace member: %w", err)
+	}
+	return nil
+}
+
+// AddURLs передаёт личные ссылки пользователя в пространство. Чужие id молча пропускаются.
+func (s *WorkspaceService) AddURLs(ctx context.Context, userID, workspaceID string, shortIDs []string) error {
+	role, err := s.authorize(ctx, workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if !role.CanEdit() {
+		return ErrWorkspaceForbidden
+	}
+
+	if err := s.store.MoveURLsToWorkspace(ctx, userID, workspaceID, shortIDs); err != nil {
+		return fmt.Errorf("failed to move urls to workspace: %w", err)
+	}
+	return nil
+}
+
+func (s *WorkspaceService) URLs(ctx context.Context, userID, workspaceID string) ([]model.UserURLsResponseItem, error) {
+	if _, err := s.authorize(ctx, workspaceID, userID); err != nil {
+		return nil, err
+	}
+
+	urls, err := s.store.GetWorkspaceURLs(ctx, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace urls: %w", err)
+	}
+
+	response := make([]model.UserURLsResponseItem, 0, len(urls))
+	for _, rec := range urls {
+		response = append(response, s.shortener.makeUserURLsItem(rec))
+	}
+	return response, nil
+}
+
+// Retarget перенаправляет ссылку на новый адрес. Личную ссылку меняет только автор,
+// ссылку пространства — его редакторы и владельцы, даже если автор уже не участник.
+func (s *WorkspaceService) Retarget(ctx context.Context, userID, shortID, originalURL string) (model.UserURLsResponseItem, error) {
+	url, err := s.shortener.normalizeURL(originalURL)
+	if err != nil {
+		return model.UserURLsResponseItem{}, fmt.Errorf("%w: %v", ErrInvalidWorkspace, err)
+	}
+
+	rec, err := s.store.GetURL(ctx, shortID)
+	if errors.Is(err, repository.ErrStoreNotFound) || err == nil && rec.IsDeleted {
+		return model.UserURLsResponseItem{}, ErrURLNotFound
+	}
+	if err != nil {
+		return model.UserURLsResponseItem{}, fmt.Errorf("failed to get url: %w", err)
+	}
+
+	if rec.WorkspaceID == "" {
+		// Чужую личную ссылку не отличаем от несуществующей
+		if rec.UserID != userID {
+			return model.UserURLsResponseItem{}, ErrURLNotFound
+		}
+	} else {
+		role, err := s.authorize(ctx, rec.WorkspaceID, userID)
+		if errors.Is(err, ErrWorkspaceNotFound) {
+			return model.UserURLsResponseItem{}, ErrURLNotFound
+		}
+		if err != nil {
+			return model.UserURLsResponseItem{}, err
+		}
+		if !role.CanEdit() {
+			return model.UserURLsResponseItem{}, ErrWorkspaceForbidden
+		}
+	}
+
+	err = s.store.SetOriginalURL(ctx, shortID, url)
+	var conflict *repository.ErrStoreConflict
+	if errors.As(err, &conflict) {
+		return model.UserURLsResponseItem{}, NewErrShortenerConflict(s.shortener.makeResultURL(conflict.ShortURL), err)
+	}
+	if errors.Is(err, repository.ErrStoreNotFound) {
+		return model.UserURLsResponseItem{}, ErrURLNotFound
+	}
+	if err != nil {
+		return model.UserURLsResponseItem{}, fmt.Errorf("failed to update url: %w", err)
+	}
+
+	rec.OriginalURL = url
+	return s.shortener.makeUserURLsItem(rec), nil
+}
+
+func (s *WorkspaceService) authorize(ctx context.Context, workspaceID, userID string) (model.WorkspaceRole, error) {
+	m, err := s.store.GetWorkspaceMember(ctx, workspaceID, userID)
+	if errors.Is(err, repository.ErrStoreNotFound) {
+		return "", ErrWorkspaceNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get workspace member: %w", err)
+	}
+	return m.Role, nil
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kayumovtd/url-shortener/internal/logger"
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"github.com/kayumovtd/url-shortener/internal/repository"
+)
+
+func TestWorkspaceService(t *testing.T) {
+	const (
+		owner  = "owner_user"
+		editor = "editor_user"
+		viewer = "viewer_user"
+		// бывший участник, автор ссылки
+		leaver = "leaver_user"
+	)
+
+	store := repository.NewInMemoryStore()
+	bd := NewBatchDeleter(store, logger.NewNoOp())
+	defer bd.Close()
+	shortener := NewShortenerService(store, testBaseURL, bd)
+	svc := NewWorkspaceService(store, shortener)
+
+	ctx := t.Context()
+	store.SaveURL(ctx, "campaign", "https://example.com/campaign", leaver)
+	store.SaveURL(ctx, "personal", "https://example.com/personal", leaver)
+
+	ws, err := svc.Create(ctx, owner, "Marketing")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for member, role := range map[string]model.WorkspaceRole{editor: model.RoleEditor, viewer: model.RoleViewer, leaver: model.RoleEditor} {
+		if err := svc.SetMember(ctx, owner, ws.ID, member, role); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := svc.AddURLs(ctx, leaver, ws.ID, []string{"campaign"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := svc.RemoveMember(ctx, leaver, ws.ID, leaver); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("members_see_workspace_urls", func(t *testing.T) {
+		urls, err := shortener.GetUserURLs(ctx, viewer)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(urls) != 1 || urls[0].WorkspaceID != ws.ID {
+			t.Errorf("unexpected urls: %v", urls)
+		}
+	})
+
+	t.Run("former_member_loses_access", func(t *testing.T) {
+		urls, err := shortener.GetUserURLs(ctx, leaver)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(urls) != 1 || urls[0].OriginalURL != "https://example.com/personal" {
+			t.Errorf("unexpected urls: %v", urls)
+		}
+
+		if _, err := svc.URLs(ctx, leaver, ws.ID); !errors.Is(err, ErrWorkspaceNotFound) {
+			t.Errorf("expected ErrWorkspaceNotFound, got: %v", err)
+		}
+	})
+
+	t.Run("viewer_cannot_manage", func(t *testing.T) {
+		if err := svc.SetMember(ctx, viewer, ws.ID, "someone", model.RoleViewer); !errors.Is(err, ErrWorkspaceForbidden) {
+			t.Errorf("expected ErrWorkspaceForbidden, got: %v", err)
+		}
+		if err := svc.AddURLs(ctx, viewer, ws.ID, []string{"x"}); !errors.Is(err, ErrWorkspaceForbidden) {
+			t.Errorf("expected ErrWorkspaceForbidden, got: %v", err)
+		}
+	})
+
+	t.Run("retarget_authorized_by_membership", func(t *testing.T) {
+		if _, err := svc.Retarget(ctx, viewer, "campaign", "https://example.com/viewer"); !errors.Is(err, ErrWorkspaceForbidden) {
+			t.Errorf("expected ErrWorkspaceForbidden, got: %v", err)
+		}
+		// Бывший участник и чужой пользователь не видят ни ссылку пространства, ни личную
+		if _, err := svc.Retarget(ctx, leaver, "campaign", "https://example.com/leaver"); !errors.Is(err, ErrURLNotFound) {
+			t.Errorf("expected ErrURLNotFound, got: %v", err)
+		}
+		if _, err := svc.Retarget(ctx, editor, "personal", "https://example.com/editor"); !errors.Is(err, ErrURLNotFound) {
+			t.Errorf("expected ErrURLNotFound, got: %v", err)
+		}
+		if rec, _ := store.GetURL(ctx, "campaign"); rec.OriginalURL != "https://example.com/campaign" {
+			t.Fatalf("unauthorized retarget changed the url: %+v", rec)
+		}
+
+		for _, user := range []string{editor, owner} {
+			target := "https://example.com/" + user
+			item, err := svc.Retarget(ctx, user, "campaign", target)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if item.OriginalURL != target || item.WorkspaceID != ws.ID {
+				t.Errorf("unexpected item: %+v", item)
+			}
+		}
+
+		if _, err := svc.Retarget(ctx, leaver, "personal", "https://example.com/personal-v2"); err != nil {
+			t.Errorf("author must retarget personal url: %v", err)
+		}
+
+		var conflict *ErrShortenerConflict
+		if _, err := svc.Retarget(ctx, owner, "campaign", "https://example.com/personal-v2"); !errors.As(err, &conflict) {
+			t.Errorf("expected conflict, got: %v", err)
+		}
+		if _, err := svc.Retarget(ctx, owner, "campaign", "not a url"); !errors.Is(err, ErrInvalidWorkspace) {
+			t.Errorf("expected ErrInvalidWorkspace, got: %v", err)
+		}
+	})
+
+	t.Run("delete_authorized_by_membership", func(t *testing.T) {
+		store.MarkURLsDeleted(ctx, leaver, []string{"campaign"})
+		store.MarkURLsDeleted(ctx, viewer, []string{"campaign"})
+		if rec, _ := store.GetURL(ctx, "campaign"); rec.IsDeleted {
+			t.Fatalf("former member and viewer must not delete workspace urls")
+		}
+
+		store.MarkURLsDeleted(ctx, editor, []string{"campaign"})
+		if rec, _ := store.GetURL(ctx, "campaign"); !rec.IsDeleted {
+			t.Errorf("editor must be able to delete workspace urls")
+		}
+	})
+
+	t.Run("keeps_last_owner", func(t *testing.T) {
+		if err := svc.RemoveMember(ctx, owner, ws.ID, owner); !errors.Is(err, ErrLastOwner) {
+			t.Errorf("expected ErrLastOwner, got: %v", err)
+		}
+		if err := svc.SetMember(ctx, owner, ws.ID, owner, model.RoleViewer); !errors.Is(err, ErrLastOwner) {
+			t.Errorf("expected ErrLastOwner, got: %v", err)
+		}
+	})
+
+	t.Run("invalid_role", func(t *testing.T) {
+		if err := svc.SetMember(ctx, owner, ws.ID, viewer, "admin"); !errors.Is(err, ErrInvalidWorkspace) {
+			t.Errorf("expected ErrInvalidWorkspace, got: %v", err)
+		}
+	})
+
+	t.Run("name_length_in_runes", func(t *testing.T) {
+		if _, err := svc.Create(ctx, owner, strings.Repeat("я", maxWorkspaceNameLen)); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if _, err := svc.Create(ctx, owner, strings.Repeat("я", maxWorkspaceNameLen+1)); !errors.Is(err, ErrInvalidWorkspace) {
+			t.Errorf("expected ErrInvalidWorkspace, got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,255 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kayumovtd/url-shortener/internal/model"
+	"github.com/kayumovtd/url-shortener/internal/repository"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var storeTracer = otel.Tracer("github.com/kayumovtd/url-shortener/internal/repository")
+
+// tracedStore открывает дочерний спан на каждую операцию хранилища
+type tracedStore struct {
+	repository.Store
+	backend attribute.KeyValue
+}
+
+func TraceStore(store repository.Store, backend string) repository.Store {
+	return &tracedStore{Store: store, backend: attribute.String("store.backend", backend)}
+}
+
+func (s *tracedStore) start(ctx context.Context, op string) (context.Context, trace.Span) {
+	return storeTracer.Start(ctx, "Store."+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(s.backend),
+	)
+}
+
+// endStore не считает ошибкой штатные исходы: запись не найдена или уже существует
+func endStore(span trace.Span, err error) {
+	var conflict *repository.ErrStoreConflict
+	switch {
+	case errors.Is(err, repository.ErrStoreNotFound):
+		span.SetAttributes(attribute.String("store.result", "not_found"))
+		err = nil
+	case errors.Is(err, repository.ErrStoreAlreadyExists), errors.As(err, &conflict):
+		span.SetAttributes(attribute.String("store.result", "conflict"))
+		err = nil
+	}
+	End(span, err)
+}
+
+func (s *tracedStore) SaveURL(ctx context.Context, shortURL, originalURL string, userID string) error {
+	ctx, span := s.start(ctx, "SaveURL")
+	err := s.Store.SaveURL(ctx, shortURL, originalURL, userID)
+	endStore(span, err)
+	return err
+}
+
+func (s *tracedStore) SaveURLs(ctx context.Context, urls map[string]string, userID string) error {
+	ctx, span := s.start(ctx, "SaveURLs")
+	err := s.Store.SaveURLs(ctx, urls, userID)
+	endStore(span, err)
+	return err
+}
+
+func (s *tracedStore) GetURL(ctx context.Context, shortURL string) (model.URLRecord, error) {
+	ctx, span := s.start(ctx, "GetURL")
+	res, err := s.Store.GetURL(ctx, shortURL)
+	endStore(span, err)
+	return res, err
+}
+
+func (s *tracedStore) GetUserURLs(ctx context.Context, userID string) ([]model.URLRecord, error) {
+	ctx, span := s.start(ctx, "GetUserURLs")
+	res, err := s.Store.GetUserURLs(ctx, userID)
+	endStore(span, err)
+	return res, err
+}
+
+func (s *tracedStore) MarkURLsDeleted(ctx context.Context, userID string, shortURLs []string) error {
+	ctx, span := s.start(ctx, "MarkURLsDeleted")
+	err := s.Store.MarkURLsDeleted(ctx, userID, shortURLs)
+	endStore(span, err)
+	return err
+}
+
+func (s *tracedStore) SaveAPIKey(ctx context.Context, key model.APIKey) error {
+	ctx, span := s.start(ctx, "SaveAPIKey")
+	err := s.Store.SaveAPIKey(ctx, key)
+	endStore(span, err)
+	return err
+}
+
+func (s *tracedStore) GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error) {
+	ctx, span := s.start(ctx, "GetAPIKeyByHash")
+	res, err := s.Store.GetAPIKeyByHash(ctx, hash)
+	endStore(span, err)
+	return res, err
+}
+
+func (s *tracedStore) GetUserAPIKeys(ctx context.Context, userID string) ([]model.APIKey, error) {
+	ctx, span := s.start(ctx, "GetUserAPIKeys")
+	res, err := s.Store.GetUserAPIKeys(ctx, userID)
+	endStore(span, err)
+	return res, err
+}
+
+func (s *tracedStore) RevokeAPIKey(ctx context.Context, userID, keyID string) error {
+	ctx, span := s.start(ctx, "RevokeAPIKey")
+	err := s.Store.RevokeAPIKey(ctx, userID, keyID)
+	endStore(span, err)
+	return err
+}
+
+func (s *tracedStore) SaveUser(ctx context.Context, user model.User) error {
+	ctx, span := s.start(ctx, "SaveUser")
+	err := s.Store.SaveUser(ctx, user)
+	endStore(span, err)
+	return err
+}
+
+func (s *tracedStore) GetUser(ctx context.Context, userID string) (model.User, error) {
+	ctx, span := s.start(ctx, "GetUser")
+	res, err := s.Store.GetUser(ctx, userID)
+	endStore(span, err)
+	return res, err
+}
+
+func (s *tracedStore) GetUserByLogin(ctx context.Context, login string) (model.User, error) {
+	ctx, span := s.start(ctx, "GetUserByLogin")
+	res, err := s.Store.GetUserByLogin(ctx, login)
+	endStore(span, err)
+	return res, err
+}
+
+func (s *tracedStore) MergeUserURLs(ctx context.Context, fromUserID, toUserID string) error {
+	ctx, span := s.start(ctx, "MergeUserURLs")
+	err := s.Store.MergeUserURLs(ctx, fromUserID, toUserID)
+	endStore(span, err)
+	return err
+}
+
+func (s *tracedStore) MergeUserAPIKeys(ctx context.Context, fromUserID, toUserID string) error {
+	ctx, span := s.start(ctx, "MergeUserAPIKeys")
+	err := s.Store.MergeUserAPIKeys(ctx, fromUserID, toUserID)
+	endStore(span, err)
+	return err
+}
+
+func (s *tracedStore) SaveWorkspace(ctx context.Context, ws model.Workspace, ownerID string) error {
+	ctx, span := s.start(ctx, "SaveWorkspace")
+	err := s.Store.SaveWorkspace(ctx, ws, ownerID)
+	endStore(span, err)
+	return err
+}
+
+func (s *tracedStore) GetWorkspace(ctx context.Context, workspaceID string) (model.Workspace, error) {
+	ctx, span := s.start(ctx, "GetWorkspace")
+	res, err := s.Store.GetWorkspace(ctx, workspaceID)
+	endStore(span, err)
+	return res, err
+}
+
+func (s *tracedStore) GetUserWorkspaces(ctx context.Context, userID string) ([]model.UserWorkspace, error) {
+	ctx, span := s.start(ctx, "GetUserWorkspaces")
+	res, err := s.Store.GetUserWorkspaces(ctx, userID)
+	endStore(span, err)
+	return res, err
+}
+
+func (s *tracedStore) GetWorkspaceMember(ctx context.Context, workspaceID, userID string) (model.WorkspaceMember, error) {
+	ctx, span := s.start(ctx, "GetWorkspaceMember")
+	res, err := s.Store.GetWorkspaceMember(ctx, workspaceID, userID)
+	endStore(span, err)
+	return res, err
+}
+
+func (s *tracedStore) GetWorkspaceMembers(ctx context.Context, workspaceID string) ([]model.WorkspaceMember, error) {
+	ctx, span := s.start(ctx, "GetWorkspaceMembers")
+	res, err := s.Store.GetWorkspaceMembers(ctx, workspaceID)
+	endStore(span, err)
+	return res, err
+}
+
+func (s *tracedStore) SaveWorkspaceMember(ctx context.Context, member model.WorkspaceMember) error {
+	ctx, span := s.start(ctx, "SaveWorkspaceMember")
+	err := s.Store.SaveWorkspaceMember(ctx, member)
+	endStore(span, err)
+	return err
+}
+
+func (s *tracedStore) DeleteWorkspaceMember(ctx context.Context, workspaceID, userID string) error {
+	ctx, span := s.start(ctx, "DeleteWorkspaceMember")
+	err := s.Store.DeleteWorkspaceMember(ctx, workspaceID, userID)
+	endStore(span, err)
+	return err
+}
+
+func (s *tracedStore) MoveURLsToWorkspace(ctx context.Context, userID, workspaceID string, shortURLs []string) error {
+	ctx, span := s.start(ctx, "MoveURLsToWorkspace")
+	err := s.Store.MoveURLsToWorkspace(ctx, userID, workspaceID, shortURLs)
+	endStore(span, err)
+	return err
+}
+
+func (s *tracedStore) GetWorkspaceURLs(ctx context.Context, workspaceID string) ([]model.URLRecord, error) {
+	ctx, span := s.start(ctx, "GetWorkspaceURLs")
+	res, err := s.Store.GetWorkspaceURLs(ctx, workspaceID)
+	endStore(span, err)
+	return res, err
+}
+
+func (s *tracedStore) GetStats(ctx context.Context) (model.Stats, error) {
+	ctx, span := s.start(ctx, "GetStats")
+	res, err := s.Store.GetStats(ctx)
+	endStore(span, err)
+	return res, err
+}
+
+func (s *tracedStore) SetOriginalURL(ctx context.Context, shortURL, originalURL string) error {
+	ctx, span := s.start(ctx, "SetOriginalURL")
+	err := s.Store.SetOriginalURL(ctx, shortURL, originalURL)
+	endStore(span, err)
+	return err
+}
+
+func (s *tracedStore) SetURLDisabled(ctx context.Context, shortURL string, disabled bool) error {
+	ctx, span := s.start(ctx, "SetURLDisabled")
+	err := s.Store.SetURLDisabled(ctx, shortURL, disabled)
+	endStore(span, err)
+	return err
+}
+
+func (s *tracedStore) SaveAbuseReport(ctx context.Context, report model.AbuseReport) error {
+	ctx, span := s.start(ctx, "SaveAbuseReport")
+	err := s.Store.SaveAbuseReport(ctx, report)
+	endStore(span, err)
+	return err
+}
+
+func (s *tracedStore) GetOpenAbuseReports(ctx context.Context) ([]model.AbuseReport, error) {
+	ctx, span := s.start(ctx, "GetOpenAbuseReports")
+	res, err := s.Store.GetOpenAbuseReports(ctx)
+	endStore(span, err)
+	return res, err
+}
+
+func (s *tracedStore) SetURLModeration(ctx context.Context, shortURL string, state model.ModerationState, reason string) error {
+	ctx, span := s.start(ctx, "SetURLModeration")
+	err := s.Store.SetURLModeration(ctx, shortURL, state, reason)
+	endStore(span, err)
+	return err
+}
+
+func (s *tracedStore) Ping(ctx context.Context) error {
+	ctx, span := s.start(ctx, "Ping")
+	err := s.Store.Ping(ctx)
+	endStore(span, err)
+	return err
+}
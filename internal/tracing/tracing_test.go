@@ -0,0 +1,78 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/kayumovtd/url-shortener/internal/middleware"
+	"github.com/kayumovtd/url-shortener/internal/repository"
+	"github.com/kayumovtd/url-shortener/internal/service"
+	"github.com/kayumovtd/url-shortener/internal/tracing"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	parentTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentSpanID  = "00f067aa0ba902b7"
+)
+
+func TestSpansFollowRequest(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	if _, err := tracing.Setup(context.Background(), tracing.Config{}); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	store := tracing.TraceStore(repository.NewInMemoryStore(), "memory")
+	svc := service.NewShortenerService(store, "http://localhost", nil)
+	if err := store.SaveURL(context.Background(), "abc", "https://example.com", "user"); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	rec.Reset()
+
+	r := chi.NewRouter()
+	r.Use(middleware.TracingMiddleware)
+	r.Get("/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := svc.Unshorten(r.Context(), chi.URLParam(r, "id")); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusTemporaryRedirect)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/abc", nil)
+	req.Header.Set("traceparent", "00-"+parentTraceID+"-"+parentSpanID+"-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := rec.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+
+	byName := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range spans {
+		byName[s.Name()] = s
+		if s.SpanContext().TraceID().String() != parentTraceID {
+			t.Errorf("span %q has trace id %s, want %s", s.Name(), s.SpanContext().TraceID(), parentTraceID)
+		}
+	}
+
+	server, svcSpan, storeSpan := byName["GET /{id}"], byName["ShortenerService.Unshorten"], byName["Store.GetURL"]
+	if server == nil || svcSpan == nil || storeSpan == nil {
+		t.Fatalf("unexpected span names: %v", byName)
+	}
+	if server.Parent().SpanID().String() != parentSpanID {
+		t.Errorf("server span parent = %s, want %s", server.Parent().SpanID(), parentSpanID)
+	}
+	if svcSpan.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Error("service span is not a child of the server span")
+	}
+	if storeSpan.Parent().SpanID() != svcSpan.SpanContext().SpanID() {
+		t.Error("store span is not a child of the service span")
+	}
+}
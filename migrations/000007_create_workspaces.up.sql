@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS workspaces (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id TEXT NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL,
+    role TEXT NOT NULL,
+    PRIMARY KEY (workspace_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_workspace_members_user_id ON workspace_members(user_id);
+
+-- Пустая строка — личная ссылка, как и с user_id
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS workspace_id TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_urls_workspace_id ON urls(workspace_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS abuse_reports;
+ALTER TABLE urls DROP COLUMN IF EXISTS moderation_reason;
+ALTER TABLE urls DROP COLUMN IF EXISTS moderation_state;
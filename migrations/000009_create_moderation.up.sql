@@ -0,0 +1,13 @@
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS moderation_state TEXT NOT NULL DEFAULT 'active';
+ALTER TABLE urls ADD COLUMN IF NOT EXISTS moderation_reason TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS abuse_reports (
+    id TEXT PRIMARY KEY,
+    short_url TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    reporter_id TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    resolved_at TIMESTAMPTZ
+);
+-- Очередь модерации — только открытые жалобы
+CREATE INDEX IF NOT EXISTS idx_abuse_reports_open ON abuse_reports(short_url) WHERE resolved_at IS NULL;
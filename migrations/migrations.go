@@ -2,14 +2,19 @@ package migrations
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"os"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
+const sourceURL = "file://migrations"
+
 func ApplyMigrations(dsn string) error {
 	db, err := sql.Open("pgx", dsn)
 	if err != nil {
@@ -23,7 +28,7 @@ func ApplyMigrations(dsn string) error {
 	}
 
 	m, err := migrate.NewWithDatabaseInstance(
-		"file://migrations",
+		sourceURL,
 		"postgres", driver,
 	)
 	if err != nil {
@@ -36,3 +41,27 @@ func ApplyMigrations(dsn string) error {
 
 	return nil
 }
+
+// LatestVersion — номер последней миграции в каталоге, до него ApplyMigrations поднимает базу
+func LatestVersion() (uint, error) {
+	src, err := source.Open(sourceURL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open migrations: %w", err)
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read migrations: %w", err)
+		}
+		version = next
+	}
+}
@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// Схема SQLite повторяет миграции PostgreSQL с поправкой на диалект.
+// Файлы встроены в бинарник: встроенную базу запускают без каталога migrations рядом.
+//
+//go:embed sqlite/*.sql
+var sqliteFS embed.FS
+
+func ApplySQLiteMigrations(db *sql.DB) error {
+	src, err := iofs.New(sqliteFS, "sqlite")
+	if err != nil {
+		return fmt.Errorf("failed to open sqlite migrations: %w", err)
+	}
+
+	driver, err := sqlite.WithInstance(db, &sqlite.Config{})
+	if err != nil {
+		return fmt.Errorf("failed to create sqlite driver: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "sqlite", driver)
+	if err != nil {
+		return fmt.Errorf("failed to init migrate: %w", err)
+	}
+
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,2 @@
+-- SQLite не умеет ADD CONSTRAINT, уникальность задаём индексом
+CREATE UNIQUE INDEX IF NOT EXISTS urls_original_url_unique ON urls(original_url);
@@ -0,0 +1,2 @@
+ALTER TABLE urls ADD COLUMN user_id TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_urls_user_id ON urls(user_id);
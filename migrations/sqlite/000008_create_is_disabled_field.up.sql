@@ -0,0 +1 @@
+ALTER TABLE urls ADD COLUMN is_disabled BOOLEAN NOT NULL DEFAULT FALSE;